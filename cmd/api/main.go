@@ -0,0 +1,18 @@
+// API binary starts HTTP-server only.
+package main
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"CryptocoinPrice/internal/app"
+)
+
+func main() {
+	application, err := app.New(app.ModeAPI)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if err := application.Run(); err != nil {
+		logrus.Fatal(err)
+	}
+}
@@ -1,4 +1,5 @@
-// App binary starts HTTP-server.
+// App binary starts HTTP-server and price collectors
+// or one of them according to RUN_MODE.
 package main
 
 import (
@@ -8,7 +9,7 @@ import (
 )
 
 func main() {
-	application, err := app.New()
+	application, err := app.New("")
 	if err != nil {
 		logrus.Fatal(err)
 	}
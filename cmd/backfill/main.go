@@ -0,0 +1,124 @@
+// Backfill binary loads historical prices of observed coin into server DB.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	cli "github.com/urfave/cli/v3"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/entity"
+	repopg "CryptocoinPrice/internal/app/repo/pg"
+	"CryptocoinPrice/internal/app/repo/provider"
+	"CryptocoinPrice/internal/app/usecase"
+	"CryptocoinPrice/internal/pkg/database"
+	"CryptocoinPrice/internal/pkg/logger"
+)
+
+const _defaultBackfillDays = 30 // amount of days to backfill if window start is not set
+
+func main() {
+	if err := startBackfill(); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+func startBackfill() error {
+	// load config
+	cfg, err := config.New()
+	if err != nil {
+		return err
+	}
+	// setup logger
+	logger.InitLogrus(cfg.App.LogLevel, cfg.App.LogFormat)
+
+	// connect to DB
+	gormDB, err := database.New(cfg.DB.ConnString,
+		database.WithTranslateError(),
+		database.WithIgnoreNotFound(),
+		database.WithDisableColorful(),
+		database.WithLogLevel(cfg.App.LogLevel),
+		database.WithLogger(logrus.StandardLogger()))
+	if err != nil {
+		return fmt.Errorf("db: %w", err)
+	}
+	// create backfill usecase
+	backfillUC := usecase.NewBackfillUC(repopg.NewCoinRepoPG(gormDB),
+		repopg.NewPriceRepoPG(gormDB),
+		repopg.NewBackfillJobRepoPG(gormDB),
+		provider.NewDefaultRegistry().NewPriceHistoryRepoAPI(cfg),
+		cfg.App.QuoteCurrencies)
+
+	// create backfill cmd
+	cmd := &cli.Command{
+		Name:   "backfill",
+		Usage:  "Load historical prices of observed coin into application DB",
+		Action: newBackfillAction(backfillUC),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "coin",
+				Aliases:  []string{"c"},
+				Usage:    "Short name of observed coin",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "id",
+				Usage: "Canonical ID of observed coin (to choose one of coins with the same name)",
+			},
+			&cli.Int64Flag{
+				Name:  "from",
+				Usage: "Unix timestamp of window start (the flag -days is used if not specified)",
+			},
+			&cli.Int64Flag{
+				Name:        "to",
+				Usage:       "Unix timestamp of window end (current time if not specified)",
+				HideDefault: true,
+			},
+			&cli.IntFlag{
+				Name:    "days",
+				Aliases: []string{"d"},
+				Value:   _defaultBackfillDays,
+				Usage:   "Amount of days before window end to backfill",
+			},
+		},
+	}
+	// run backfill cmd
+	if err := cmd.Run(context.Background(), os.Args); err != nil {
+		return fmt.Errorf("backfill cmd: %w", err)
+	}
+	return nil
+}
+
+// Handler for backfill command.
+func newBackfillAction(backfillUC usecase.BackfillUsecase) cli.ActionFunc {
+	return func(ctx context.Context, cmd *cli.Command) error {
+		symbol, from, to := cmd.String("coin"), cmd.Int64("from"), cmd.Int64("to")
+		if from == 0 {
+			windowEnd := time.Now()
+			if to != 0 {
+				windowEnd = time.Unix(to, 0)
+			}
+			from = windowEnd.AddDate(0, 0, -cmd.Int("days")).Unix()
+		}
+
+		fmt.Printf("Backfill %s prices from %d... \n", symbol, from)
+		job, err := backfillUC.Backfill(ctx, symbol, cmd.String("id"), nil, from, to,
+			func(job entity.BackfillJob) {
+				fmt.Printf("Progress: %d/%d steps, %d prices saved, %d skipped \n",
+					job.DoneSteps, job.TotalSteps, job.Saved, job.Skipped)
+			})
+		if err != nil {
+			if errors.Is(err, usecase.ErrNotFound) {
+				return fmt.Errorf("coin %s is not observed: %w", symbol, err)
+			}
+			return err
+		}
+		fmt.Printf("Successfully! %d prices saved, %d skipped \n", job.Saved, job.Skipped)
+		return nil
+	}
+}
@@ -0,0 +1,18 @@
+// Collector binary starts price collectors only.
+package main
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"CryptocoinPrice/internal/app"
+)
+
+func main() {
+	application, err := app.New(app.ModeCollector)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if err := application.Run(); err != nil {
+		logrus.Fatal(err)
+	}
+}
@@ -3,8 +3,10 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -22,10 +24,106 @@ type (
 		LogLevel string `env:"LOG_LEVEL" env-default:"info"`
 		// text/json
 		LogFormat string `env:"LOG_FORMAT" env-default:"text"`
+		// api/collector/both (services started by app binary)
+		RunMode string `env:"RUN_MODE" env-default:"both"`
 
-		CoingeckoAPIKey      string        `env-required:"true" env:"COINGECKO_API_KEY"`
-		PriceCollectInterval time.Duration `env:"PRICE_COLLECT_INTERVAL" env-default:"5s"`
-		ShutdownTimeout      time.Duration `env:"SHUTDOWN_TIMEOUT" env-default:"5s"`
+		// ordered list of coingecko/binance/cryptocompare (the first one is primary)
+		PriceProviders []string `env:"PRICE_PROVIDERS" env-default:"coingecko"`
+		// failover/consensus
+		PriceProvidersMode string `env:"PRICE_PROVIDERS_MODE" env-default:"failover"`
+		// max deviation of provider quote from the median in percents (for consensus mode)
+		PriceConsensusMaxDeviation float64 `env:"PRICE_CONSENSUS_MAX_DEVIATION" env-default:"5"`
+
+		// amount of consecutive provider failures to open its circuit breaker
+		PriceBreakerFailures int `env:"PRICE_BREAKER_FAILURES" env-default:"5"`
+		// time to keep provider circuit breaker open before trial request
+		PriceBreakerCoolDown time.Duration `env:"PRICE_BREAKER_COOLDOWN" env-default:"30s"`
+
+		// quote currencies to collect prices in (the first one is default)
+		QuoteCurrencies []string `env:"QUOTE_CURRENCIES" env-default:"usd"`
+
+		CoingeckoAPIKey string `env:"COINGECKO_API_KEY"`
+		// demo/pro (selects API host and header for API key)
+		CoingeckoAPIPlan string `env:"COINGECKO_API_PLAN" env-default:"demo"`
+		// custom API base URL (e.g. mock server or proxy) instead of plan host
+		CoingeckoBaseURL string `env:"COINGECKO_BASE_URL"`
+		// timeout for one request to API
+		CoingeckoRequestTimeout time.Duration `env:"COINGECKO_REQUEST_TIMEOUT" env-default:"2s"`
+		// amount of retries attempts in error cases
+		CoingeckoRetryCount int `env:"COINGECKO_RETRY_COUNT" env-default:"3"`
+		// time between first request and first retry
+		CoingeckoRetryWaitTime time.Duration `env:"COINGECKO_RETRY_WAIT_TIME" env-default:"500ms"`
+		// max time between request and retry
+		CoingeckoRetryMaxWaitTime time.Duration `env:"COINGECKO_RETRY_MAX_WAIT_TIME" env-default:"2s"`
+		// max amount of coins in one request to API
+		CoingeckoBatchSize int `env:"COINGECKO_BATCH_SIZE" env-default:"100"`
+		// max amount of concurrent requests to API
+		CoingeckoBatchWorkers int `env:"COINGECKO_BATCH_WORKERS" env-default:"4"`
+		// max amount of calls to API per minute (0 - unlimited)
+		CoingeckoRateLimit int `env:"COINGECKO_RATE_LIMIT" env-default:"30"`
+		// max amount of calls to API per calendar month (0 - unlimited)
+		CoingeckoMonthlyQuota int `env:"COINGECKO_MONTHLY_QUOTA" env-default:"10000"`
+		// live/record/replay (record API responses into fixtures or replay them offline)
+		CoingeckoMode string `env:"COINGECKO_MODE" env-default:"live"`
+		// dir with recorded API responses (for record and replay modes)
+		CoingeckoFixturesDir string `env:"COINGECKO_FIXTURES_DIR" env-default:"./fixtures/coingecko"`
+
+		// seed of fake random-walk price provider
+		RandomWalkSeed uint64 `env:"RANDOM_WALK_SEED" env-default:"1"`
+
+		// amount of consecutive failed collections to mark coin as failing
+		CoinFailingThreshold int `env:"COIN_FAILING_THRESHOLD" env-default:"10"`
+
+		// stream prices of observed coins over exchange WebSocket
+		PriceStreamEnabled bool `env:"PRICE_STREAM_ENABLED" env-default:"false"`
+		// exchange WebSocket streams URL
+		PriceStreamURL string `env:"PRICE_STREAM_URL" env-default:"wss://stream.binance.com:9443/ws"`
+		// interval to save the latest streamed prices
+		PriceStreamThrottle time.Duration `env:"PRICE_STREAM_THROTTLE" env-default:"5s"`
+		// interval to refresh observed coins and resubscribe stream
+		PriceStreamResubscribeInterval time.Duration `env:"PRICE_STREAM_RESUBSCRIBE_INTERVAL" env-default:"30s"` // nolint:lll // env tag
+		// min and max time to wait before stream reconnect (doubled on each attempt)
+		PriceStreamReconnectMinWait time.Duration `env:"PRICE_STREAM_RECONNECT_MIN_WAIT" env-default:"1s"`
+		PriceStreamReconnectMaxWait time.Duration `env:"PRICE_STREAM_RECONNECT_MAX_WAIT" env-default:"1m"`
+
+		CryptocompareAPIKey string `env:"CRYPTOCOMPARE_API_KEY"`
+
+		// key of PostgreSQL advisory lock held by the leader replica
+		LeaderLockKey int64 `env:"LEADER_LOCK_KEY" env-default:"7315004521"`
+		// interval to try to become the leader
+		LeaderRetryInterval time.Duration `env:"LEADER_RETRY_INTERVAL" env-default:"5s"`
+		// interval to check that the leader still holds the lock
+		LeaderCheckInterval time.Duration `env:"LEADER_CHECK_INTERVAL" env-default:"5s"`
+
+		// default interval between price collections of coin without its own interval
+		PriceCollectInterval time.Duration `env:"PRICE_COLLECT_INTERVAL" env-default:"5m"`
+		// interval to check which coins are due to collect their prices
+		PriceScheduleTick time.Duration `env:"PRICE_SCHEDULE_TICK" env-default:"1s"`
+		// max duration of one prices collection
+		PriceCollectTimeout time.Duration `env:"PRICE_COLLECT_TIMEOUT" env-default:"10s"`
+		// max random delay of prices collection after schedule tick
+		PriceCollectJitter time.Duration `env:"PRICE_COLLECT_JITTER" env-default:"200ms"`
+
+		// interval to detect gaps in collected prices
+		GapDetectInterval time.Duration `env:"GAP_DETECT_INTERVAL" env-default:"10m"`
+		// window before the current time to detect gaps in
+		GapDetectWindow time.Duration `env:"GAP_DETECT_WINDOW" env-default:"24h"`
+		// min duration of gap between consecutive prices
+		GapMinDuration time.Duration `env:"GAP_MIN_DURATION" env-default:"10m"`
+		// backfill prices of detected gaps from historical prices provider
+		GapAutoBackfill bool `env:"GAP_AUTO_BACKFILL" env-default:"false"`
+
+		// interval to check queued backfill jobs
+		BackfillPollInterval time.Duration `env:"BACKFILL_POLL_INTERVAL" env-default:"5s"`
+
+		// dir of on-disk spool of prices which are failed to save into DB
+		PriceSpoolDir string `env:"PRICE_SPOOL_DIR" env-default:"./spool"`
+		// max size of prices spool file in bytes
+		PriceSpoolMaxSize int64 `env:"PRICE_SPOOL_MAX_SIZE" env-default:"67108864"`
+		// interval to replay spooled prices into DB
+		PriceSpoolReplayInterval time.Duration `env:"PRICE_SPOOL_REPLAY_INTERVAL" env-default:"5s"`
+
+		ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" env-default:"5s"`
 	}
 
 	Server struct {
@@ -47,6 +145,10 @@ type (
 var (
 	_acceptedLogFormats = []string{"text", "json"}
 	_acceptedLogLevels  = []string{"info", "warn", "error"}
+	_acceptedPriceModes = []string{"failover", "consensus"}
+	_acceptedAPIPlans   = []string{"demo", "pro"}
+	_acceptedAPIModes   = []string{"live", "record", "replay"}
+	_acceptedRunModes   = []string{"api", "collector", "both"}
 )
 
 // New returns app config loaded from ENV-vars.
@@ -71,6 +173,102 @@ func New() (*Config, error) {
 		)
 	}
 
+	// if no one quote currency is given
+	if len(cfg.App.QuoteCurrencies) == 0 {
+		return nil, errors.New("at least one quote currency is required")
+	}
+	for i := range cfg.App.QuoteCurrencies {
+		cfg.App.QuoteCurrencies[i] = strings.ToLower(strings.TrimSpace(cfg.App.QuoteCurrencies[i]))
+	}
+	// if invalid price providers mode
+	if !slices.Contains(_acceptedPriceModes, cfg.App.PriceProvidersMode) {
+		return nil, fmt.Errorf(
+			"invalid price providers mode %s. Accepted modes: %v",
+			cfg.App.PriceProvidersMode, _acceptedPriceModes,
+		)
+	}
+
+	// if invalid run mode
+	if !slices.Contains(_acceptedRunModes, cfg.App.RunMode) {
+		return nil, fmt.Errorf(
+			"invalid run mode %s. Accepted modes: %v",
+			cfg.App.RunMode, _acceptedRunModes,
+		)
+	}
+
+	// if invalid failing coins threshold
+	if cfg.App.CoinFailingThreshold < 1 {
+		return nil, errors.New("coin failing threshold must be positive")
+	}
+	// if invalid circuit breaker settings
+	if cfg.App.PriceBreakerFailures < 1 {
+		return nil, errors.New("price breaker failures must be positive")
+	}
+
+	// if invalid price collection schedule
+	if cfg.App.PriceCollectInterval <= 0 || cfg.App.PriceScheduleTick <= 0 {
+		return nil, errors.New("price collect interval and schedule tick must be positive")
+	}
+	if cfg.App.PriceCollectTimeout <= 0 {
+		return nil, errors.New("price collect timeout must be positive")
+	}
+	if cfg.App.PriceCollectJitter < 0 || cfg.App.PriceCollectJitter >= cfg.App.PriceScheduleTick {
+		return nil, errors.New("price collect jitter must not be negative and must be less than schedule tick") // nolint:lll // error message
+	}
+	// if invalid gap detection settings
+	if cfg.App.GapDetectInterval <= 0 || cfg.App.GapDetectWindow <= 0 ||
+		cfg.App.GapMinDuration <= 0 {
+		return nil, errors.New("gap detect interval, detect window and min duration must be positive") // nolint:lll // error message
+	}
+	// if invalid backfill jobs settings
+	if cfg.App.BackfillPollInterval <= 0 {
+		return nil, errors.New("backfill poll interval must be positive")
+	}
+	// if invalid prices spool settings
+	if cfg.App.PriceSpoolDir == "" || cfg.App.PriceSpoolMaxSize <= 0 ||
+		cfg.App.PriceSpoolReplayInterval <= 0 {
+		return nil, errors.New("price spool dir must be set and its max size and replay interval must be positive") // nolint:lll // error message
+	}
+	// if invalid leader election settings
+	if cfg.App.LeaderRetryInterval <= 0 || cfg.App.LeaderCheckInterval <= 0 {
+		return nil, errors.New("leader retry and check intervals must be positive")
+	}
+	// if invalid price stream settings
+	if cfg.App.PriceStreamThrottle <= 0 || cfg.App.PriceStreamResubscribeInterval <= 0 {
+		return nil, errors.New("price stream throttle and resubscribe interval must be positive")
+	}
+	if cfg.App.PriceStreamReconnectMinWait <= 0 ||
+		cfg.App.PriceStreamReconnectMinWait > cfg.App.PriceStreamReconnectMaxWait {
+		return nil, errors.New("price stream reconnect min wait must be positive and not greater than max wait") // nolint:lll // error message
+	}
+
+	// if invalid CoinGecko API plan
+	if !slices.Contains(_acceptedAPIPlans, cfg.App.CoingeckoAPIPlan) {
+		return nil, fmt.Errorf(
+			"invalid coingecko api plan %s. Accepted plans: %v",
+			cfg.App.CoingeckoAPIPlan, _acceptedAPIPlans,
+		)
+	}
+	// if invalid CoinGecko API mode
+	if !slices.Contains(_acceptedAPIModes, cfg.App.CoingeckoMode) {
+		return nil, fmt.Errorf(
+			"invalid coingecko mode %s. Accepted modes: %v",
+			cfg.App.CoingeckoMode, _acceptedAPIModes,
+		)
+	}
+	// if invalid retry policy
+	if cfg.App.CoingeckoRetryCount < 0 {
+		return nil, errors.New("coingecko retry count must not be negative")
+	}
+	// if invalid batching
+	if cfg.App.CoingeckoBatchSize < 1 || cfg.App.CoingeckoBatchWorkers < 1 {
+		return nil, errors.New("coingecko batch size and workers must be positive")
+	}
+	// if invalid API calls limits
+	if cfg.App.CoingeckoRateLimit < 0 || cfg.App.CoingeckoMonthlyQuota < 0 {
+		return nil, errors.New("coingecko rate limit and monthly quota must not be negative")
+	}
+
 	cfg.DB.ConnString = fmt.Sprintf(
 		"user=%s password=%s host=%s port=%s dbname=%s sslmode=disable connect_timeout=10",
 		cfg.DB.User, cfg.DB.Password,
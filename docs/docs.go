@@ -23,7 +23,7 @@ const docTemplate = `{
     "paths": {
         "/currency/add": {
             "post": {
-                "description": "Добавление криптовалюты в список наблюдения.",
+                "description": "Добавление криптовалюты в список наблюдения.\nЕсли несколько криптовалют имеют одинаковое название, необходимо указать id нужной.\nЕсли указано поле backfill_days, запускается загрузка исторических цен за это количество дней.",
                 "tags": [
                     "currency"
                 ],
@@ -36,26 +36,217 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/coinmanage.coinObservedInput"
+                            "$ref": "#/definitions/coinmanage.coinAddInput"
                         }
                     }
                 ],
                 "responses": {
+                    "202": {
+                        "description": "Успешное добавление в список наблюдения и запуск загрузки исторических цен (см. заголовок Location)"
+                    },
                     "204": {
-                        "description": "Успешное добавление в список наблюдения"
+                        "description": "Успешное добавление в список наблюдения (если загрузку исторических цен запустить не удалось, ошибка в заголовке X-Backfill-Error)"
                     },
                     "400": {
                         "description": "Невалидное тело запроса"
                     },
                     "404": {
-                        "description": "Криптовалюта с таким названием не существует"
+                        "description": "Криптовалюта с таким названием (или id) не существует"
+                    },
+                    "409": {
+                        "description": "Несколько криптовалют с таким названием",
+                        "schema": {
+                            "$ref": "#/definitions/coinmanage.ambiguousCoinOutput"
+                        }
+                    },
+                    "503": {
+                        "description": "Провайдеры цен временно недоступны (см. заголовок Retry-After)"
+                    }
+                }
+            }
+        },
+        "/currency/backfill": {
+            "post": {
+                "description": "Постановка в очередь фоновой загрузки исторических цен криптовалюты за указанный\nпериод во всех валютах котировки. Задачи выполняет реплика, собирающая цены.\nУже сохранённые цены пропускаются.\nЕсли загрузка цен этой криптовалюты уже идёт или ожидает в очереди,\nвозвращается её задача.\nЕсли несколько криптовалют имеют одинаковое название, необходимо указать id нужной.",
+                "tags": [
+                    "currency"
+                ],
+                "summary": "Загрузка исторических цен криптовалюты",
+                "operationId": "start-backfill",
+                "parameters": [
+                    {
+                        "description": "Криптовалюта и период",
+                        "name": "Backfill",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backfill.backfillInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/backfill.backfillJobOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидное тело запроса"
+                    },
+                    "404": {
+                        "description": "Криптовалюта с таким названием не была добавлена в список наблюдения ранее"
+                    },
+                    "409": {
+                        "description": "Несколько криптовалют с таким названием"
+                    },
+                    "503": {
+                        "description": "Провайдер исторических цен не настроен"
+                    }
+                }
+            }
+        },
+        "/currency/backfill/{id}": {
+            "get": {
+                "description": "Получение прогресса фоновой загрузки исторических цен криптовалюты.\nЗавершённые задачи хранятся в течение суток.",
+                "tags": [
+                    "currency"
+                ],
+                "summary": "Прогресс загрузки исторических цен",
+                "operationId": "get-backfill-job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи загрузки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backfill.backfillJobOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный ID задачи"
+                    },
+                    "404": {
+                        "description": "Задача не найдена"
+                    }
+                }
+            }
+        },
+        "/currency/gaps": {
+            "get": {
+                "description": "Получение пропусков в собранных ценах криптовалют (от самого нового).\nПропуск обнаруживается, если соседние цены отстоят друг от друга\nбольше, чем на несколько интервалов сбора монеты.",
+                "tags": [
+                    "currency"
+                ],
+                "summary": "Пропуски в ценах криптовалют",
+                "operationId": "get-price-gaps",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название криптовалюты (по умолчанию - все)",
+                        "name": "coin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор криптовалюты (CoinGecko ID) для выбора одной из криптовалют с одинаковым названием",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта котировки (по умолчанию - все)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "detected",
+                            "repaired",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Состояние пропуска (по умолчанию - все)",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальное количество пропусков (по умолчанию - 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/gap.gapsOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидные параметры запроса"
+                    },
+                    "404": {
+                        "description": "Криптовалюта с таким названием не была добавлена в список наблюдения ранее"
+                    },
+                    "409": {
+                        "description": "Несколько криптовалют с таким названием"
+                    }
+                }
+            }
+        },
+        "/currency/interval": {
+            "put": {
+                "description": "Настройка интервала сбора цен криптовалюты в секундах.\nИнтервал 0 сбрасывает его на интервал по умолчанию (PRICE_COLLECT_INTERVAL).\nЕсли несколько криптовалют имеют одинаковое название, необходимо указать id нужной.",
+                "tags": [
+                    "currency"
+                ],
+                "summary": "Настройка интервала сбора цен криптовалюты",
+                "operationId": "set-coin-collect-interval",
+                "parameters": [
+                    {
+                        "description": "Название криптовалюты и интервал",
+                        "name": "Interval",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/coinmanage.coinIntervalInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/coinmanage.coinIntervalOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидное тело запроса"
+                    },
+                    "404": {
+                        "description": "Криптовалюта с таким названием не была добавлена в список наблюдения ранее"
+                    },
+                    "409": {
+                        "description": "Несколько криптовалют с таким названием",
+                        "schema": {
+                            "$ref": "#/definitions/coinmanage.ambiguousCoinOutput"
+                        }
                     }
                 }
             }
         },
         "/currency/price": {
             "get": {
-                "description": "Получение цены криптовалюты.",
+                "description": "Получение цены криптовалюты.\nЕсли несколько криптовалют имеют одинаковое название, необходимо указать id нужной.",
                 "tags": [
                     "currency"
                 ],
@@ -69,12 +260,54 @@ const docTemplate = `{
                         "in": "query",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор криптовалюты (CoinGecko ID) для выбора одной из криптовалют с одинаковым названием",
+                        "name": "id",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
+                        "format": "int64",
                         "description": "Время в UNIX-формате",
                         "name": "timestamp",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта котировки (по умолчанию - основная валюта)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "source",
+                            "ingested"
+                        ],
+                        "type": "string",
+                        "description": "Ось времени для поиска: время цены у провайдера (source, по умолчанию) или время сбора (ingested)",
+                        "name": "time_axis",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "nearest",
+                            "before",
+                            "after",
+                            "linear"
+                        ],
+                        "type": "string",
+                        "description": "Режим поиска: ближайшая цена (nearest, по умолчанию), последняя цена не позже времени (before), первая цена не раньше времени (after) или линейная интерполяция между ними (linear)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Максимальное расстояние в секундах от времени до найденной цены (без ограничения по умолчанию)",
+                        "name": "max_distance",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -88,14 +321,20 @@ const docTemplate = `{
                         "description": "Невалидное тело запроса"
                     },
                     "404": {
-                        "description": "Ни одна цена криптовалюты не найдена"
+                        "description": "Ни одна цена криптовалюты не найдена (в пределах max_distance, если задано)"
+                    },
+                    "409": {
+                        "description": "Несколько криптовалют с таким названием",
+                        "schema": {
+                            "$ref": "#/definitions/coinmanage.ambiguousCoinOutput"
+                        }
                     }
                 }
             }
         },
         "/currency/remove": {
             "delete": {
-                "description": "Удаление криптовалюты из списка наблюдения.",
+                "description": "Удаление криптовалюты из списка наблюдения.\nЕсли несколько криптовалют имеют одинаковое название, необходимо указать id нужной.",
                 "tags": [
                     "currency"
                 ],
@@ -121,12 +360,338 @@ const docTemplate = `{
                     },
                     "404": {
                         "description": "Криптовалюта с таким названием не была добавлена в список наблюдения ранее"
+                    },
+                    "409": {
+                        "description": "Несколько криптовалют с таким названием",
+                        "schema": {
+                            "$ref": "#/definitions/coinmanage.ambiguousCoinOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/currency/{coin}/prices": {
+            "get": {
+                "description": "Получение цен криптовалюты за период постранично.\nДля получения следующей страницы передаётся курсор next_cursor из текущей.\nЕсли несколько криптовалют имеют одинаковое название, необходимо указать id нужной.",
+                "tags": [
+                    "currency"
+                ],
+                "summary": "История цен криптовалюты",
+                "operationId": "get-coin-price-history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название криптовалюты",
+                        "name": "coin",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор криптовалюты (CoinGecko ID) для выбора одной из криптовалют с одинаковым названием",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Начало периода в UNIX-формате (по умолчанию - 0)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Конец периода в UNIX-формате",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта котировки (по умолчанию - основная валюта)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Порядок цен по времени: от старых (asc, по умолчанию) или от новых (desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальное количество цен на странице (по умолчанию - 100, не больше 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор страницы из ответа на запрос предыдущей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/coinmanage.priceHistoryOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидные параметры запроса"
+                    },
+                    "404": {
+                        "description": "Криптовалюта с таким названием не была добавлена в список наблюдения ранее"
+                    },
+                    "409": {
+                        "description": "Несколько криптовалют с таким названием",
+                        "schema": {
+                            "$ref": "#/definitions/coinmanage.ambiguousCoinOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/status": {
+            "get": {
+                "description": "Получение статуса сервиса: состояние circuit breaker каждого провайдера цен.\nДля реплики, собирающей цены, также возвращается, является ли она лидером,\nи объём очереди цен, которые не удалось сохранить в БД.",
+                "tags": [
+                    "status"
+                ],
+                "summary": "Получение статуса сервиса",
+                "operationId": "get-status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/status.statusOutput"
+                        }
                     }
                 }
             }
         }
     },
     "definitions": {
+        "backfill.backfillInput": {
+            "description": "Input to start backfill of historical coin prices.",
+            "type": "object",
+            "required": [
+                "coin",
+                "from"
+            ],
+            "properties": {
+                "coin": {
+                    "description": "Coin short name",
+                    "type": "string",
+                    "example": "btc"
+                },
+                "from": {
+                    "description": "Unix timestamp of window start",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1751367600
+                },
+                "id": {
+                    "description": "Canonical coin ID (CoinGecko ID) to choose one of coins with the same name",
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "bitcoin"
+                },
+                "to": {
+                    "description": "Unix timestamp of window end (current time if empty)",
+                    "type": "integer",
+                    "example": 1754045773
+                }
+            }
+        },
+        "backfill.backfillJobOutput": {
+            "description": "Output with backfill job progress.",
+            "type": "object",
+            "properties": {
+                "coin": {
+                    "description": "Coin short name",
+                    "type": "string",
+                    "example": "btc"
+                },
+                "done_steps": {
+                    "description": "Amount of done requests to price provider",
+                    "type": "integer",
+                    "example": 1
+                },
+                "error": {
+                    "description": "Error message if job is failed",
+                    "type": "string",
+                    "example": ""
+                },
+                "finished_at": {
+                    "description": "Unix timestamp of job finish (0 if job is not finished)",
+                    "type": "integer",
+                    "example": 0
+                },
+                "from": {
+                    "description": "Unix timestamp of window start",
+                    "type": "integer",
+                    "example": 1751367600
+                },
+                "id": {
+                    "description": "Backfill job ID",
+                    "type": "string",
+                    "example": "0c1d0c57-7c53-4c4b-9c4c-6f0f5e0f4b9e"
+                },
+                "queued_at": {
+                    "description": "Unix timestamp the job is queued at",
+                    "type": "integer",
+                    "example": 1754045770
+                },
+                "saved": {
+                    "description": "Amount of saved prices",
+                    "type": "integer",
+                    "example": 720
+                },
+                "skipped": {
+                    "description": "Amount of skipped prices which are already saved",
+                    "type": "integer",
+                    "example": 3
+                },
+                "started_at": {
+                    "description": "Unix timestamp of job start (0 if job is queued)",
+                    "type": "integer",
+                    "example": 1754045773
+                },
+                "state": {
+                    "description": "Job state (queued/running/done/failed)",
+                    "type": "string",
+                    "example": "running"
+                },
+                "to": {
+                    "description": "Unix timestamp of window end",
+                    "type": "integer",
+                    "example": 1754045773
+                },
+                "total_steps": {
+                    "description": "Amount of requests to price provider",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "coinmanage.ambiguousCoinOutput": {
+            "description": "Output with coins having the same name to choose one of them.",
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "description": "Coins with the same name",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/coinmanage.coinCandidateOutput"
+                    }
+                },
+                "message": {
+                    "description": "Error message",
+                    "type": "string",
+                    "example": "ambiguous: 2 coins have symbol uni: coin id must be specified"
+                }
+            }
+        },
+        "coinmanage.coinAddInput": {
+            "description": "Input to add coin to observed list.",
+            "type": "object",
+            "required": [
+                "coin"
+            ],
+            "properties": {
+                "backfill_days": {
+                    "description": "Amount of days to backfill historical coin prices for (no backfill if empty)",
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 1,
+                    "example": 30
+                },
+                "coin": {
+                    "description": "Coin short name",
+                    "type": "string",
+                    "example": "uni"
+                },
+                "id": {
+                    "description": "Canonical coin ID (CoinGecko ID) to choose one of coins with the same name",
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "uniswap"
+                }
+            }
+        },
+        "coinmanage.coinCandidateOutput": {
+            "description": "Coin with requested name.",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "Canonical coin ID (CoinGecko ID)",
+                    "type": "string",
+                    "example": "uniswap"
+                },
+                "name": {
+                    "description": "Coin full name",
+                    "type": "string",
+                    "example": "Uniswap"
+                },
+                "platforms": {
+                    "description": "Blockchain platform names with contract addresses",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "coinmanage.coinIntervalInput": {
+            "description": "Input to set interval between coin price collections.",
+            "type": "object",
+            "required": [
+                "coin"
+            ],
+            "properties": {
+                "coin": {
+                    "description": "Coin short name",
+                    "type": "string",
+                    "example": "btc"
+                },
+                "id": {
+                    "description": "Canonical coin ID (CoinGecko ID) to choose one of coins with the same name",
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "bitcoin"
+                },
+                "interval": {
+                    "description": "Interval between coin price collections in seconds (0 - default interval)",
+                    "type": "integer",
+                    "maximum": 86400,
+                    "minimum": 0,
+                    "example": 10
+                }
+            }
+        },
+        "coinmanage.coinIntervalOutput": {
+            "description": "Output with interval between coin price collections.",
+            "type": "object",
+            "properties": {
+                "coin": {
+                    "description": "Coin short name",
+                    "type": "string",
+                    "example": "btc"
+                },
+                "interval": {
+                    "description": "Interval between coin price collections in seconds (0 - default interval)",
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
         "coinmanage.coinObservedInput": {
             "description": "Input to add/remove coin to/from observed list..",
             "type": "object",
@@ -138,6 +703,12 @@ const docTemplate = `{
                     "description": "Coin short name",
                     "type": "string",
                     "example": "btc"
+                },
+                "id": {
+                    "description": "Canonical coin ID (CoinGecko ID) to choose one of coins with the same name",
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "bitcoin"
                 }
             }
         },
@@ -154,18 +725,283 @@ const docTemplate = `{
                     "type": "string",
                     "example": "btc"
                 },
+                "currency": {
+                    "description": "Quote currency of the price",
+                    "type": "string",
+                    "example": "usd"
+                },
+                "ingested_at": {
+                    "description": "Unix timestamp the price is collected at (ingested time axis).\nIf interpolated it is requested timestamp for ingested time axis\nand interpolated one for source time axis",
+                    "type": "integer",
+                    "example": 1754045775
+                },
+                "mode": {
+                    "description": "Lookup mode the price is found in",
+                    "type": "string",
+                    "example": "before"
+                },
                 "price": {
                     "description": "Coin price",
                     "type": "string",
                     "example": "114818"
                 },
+                "source_timestamps": {
+                    "description": "Unix timestamps at provider of saved prices the price is found by\n(two timestamps if price is interpolated)",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1754045773
+                    ]
+                },
                 "timestamp": {
-                    "description": "Unix timestamp",
+                    "description": "Unix timestamp of the price last update at provider (source time axis).\nIf interpolated it is requested timestamp for source time axis\nand interpolated one for ingested time axis",
                     "type": "integer",
                     "minimum": 0,
                     "example": 1754045773
                 }
             }
+        },
+        "coinmanage.priceHistoryItemOutput": {
+            "description": "Coin price in time range.",
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "description": "Quote currency of the price",
+                    "type": "string",
+                    "example": "usd"
+                },
+                "ingested_at": {
+                    "description": "Unix timestamp the price is collected at",
+                    "type": "integer",
+                    "example": 1754010002
+                },
+                "price": {
+                    "description": "Coin price",
+                    "type": "string",
+                    "example": "115412.48"
+                },
+                "source": {
+                    "description": "Name of the price provider which supplied the price",
+                    "type": "string",
+                    "example": "coingecko"
+                },
+                "timestamp": {
+                    "description": "Unix timestamp of the price last update at provider",
+                    "type": "integer",
+                    "example": 1754010000
+                }
+            }
+        },
+        "coinmanage.priceHistoryOutput": {
+            "description": "Output with page of coin prices in time range.",
+            "type": "object",
+            "properties": {
+                "coin": {
+                    "description": "Coin short name",
+                    "type": "string",
+                    "example": "btc"
+                },
+                "next_cursor": {
+                    "description": "Cursor of the next page (empty if the page is the last one)",
+                    "type": "string",
+                    "example": "MTc1NDAxMDAwMDowYzFkMGM1Ny03YzUzLTRjNGItOWM0Yy02ZjBmNWUwZjRiOWU"
+                },
+                "prices": {
+                    "description": "Prices in requested order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/coinmanage.priceHistoryItemOutput"
+                    }
+                }
+            }
+        },
+        "gap.gapOutput": {
+            "description": "Price gap.",
+            "type": "object",
+            "properties": {
+                "backfilled": {
+                    "description": "Amount of prices saved by backfill of gap",
+                    "type": "integer",
+                    "example": 48
+                },
+                "coin": {
+                    "description": "Coin short name",
+                    "type": "string",
+                    "example": "btc"
+                },
+                "currency": {
+                    "description": "Quote currency of gap prices",
+                    "type": "string",
+                    "example": "usd"
+                },
+                "detected_at": {
+                    "description": "Unix timestamp of gap detection",
+                    "type": "integer",
+                    "example": 1754021400
+                },
+                "duration": {
+                    "description": "Gap duration in seconds",
+                    "type": "integer",
+                    "example": 7200
+                },
+                "error": {
+                    "description": "Error message if backfill of gap is failed",
+                    "type": "string",
+                    "example": ""
+                },
+                "expected_interval": {
+                    "description": "Expected interval between prices in seconds",
+                    "type": "integer",
+                    "example": 5
+                },
+                "from": {
+                    "description": "Unix timestamp of the last price before gap",
+                    "type": "integer",
+                    "example": 1754013600
+                },
+                "id": {
+                    "description": "Gap ID",
+                    "type": "string",
+                    "example": "0c1d0c57-7c53-4c4b-9c4c-6f0f5e0f4b9e"
+                },
+                "state": {
+                    "description": "Gap state (detected/repaired/failed)",
+                    "type": "string",
+                    "example": "repaired"
+                },
+                "to": {
+                    "description": "Unix timestamp of the first price after gap",
+                    "type": "integer",
+                    "example": 1754020800
+                }
+            }
+        },
+        "gap.gapsOutput": {
+            "description": "Output with price gaps.",
+            "type": "object",
+            "properties": {
+                "gaps": {
+                    "description": "Price gaps from the newest one",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/gap.gapOutput"
+                    }
+                }
+            }
+        },
+        "status.leaderStatusOutput": {
+            "description": "Leader election status of the service replica.",
+            "type": "object",
+            "properties": {
+                "instance": {
+                    "description": "Replica name (host name)",
+                    "type": "string",
+                    "example": "3f2a1c9b8d7e"
+                },
+                "leader": {
+                    "description": "True if the replica is the leader collecting prices",
+                    "type": "boolean",
+                    "example": true
+                },
+                "leader_since": {
+                    "description": "Unix timestamp the replica became the leader at (0 if not the leader)",
+                    "type": "integer",
+                    "example": 1754045773
+                }
+            }
+        },
+        "status.providerStatusOutput": {
+            "description": "Price provider circuit breaker status.",
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "description": "Amount of consecutive failures",
+                    "type": "integer",
+                    "example": 5
+                },
+                "name": {
+                    "description": "Provider name",
+                    "type": "string",
+                    "example": "coingecko"
+                },
+                "opened_at": {
+                    "description": "Unix timestamp of the last breaker opening (0 if never opened)",
+                    "type": "integer",
+                    "example": 1754045773
+                },
+                "retry_after": {
+                    "description": "Seconds until the next attempt to request provider (0 if not open)",
+                    "type": "integer",
+                    "example": 25
+                },
+                "state": {
+                    "description": "Circuit breaker state (closed/open/half-open)",
+                    "type": "string",
+                    "example": "open"
+                }
+            }
+        },
+        "status.spoolStatusOutput": {
+            "description": "Backlog depth of on-disk spool of prices failed to save into DB.",
+            "type": "object",
+            "properties": {
+                "batches": {
+                    "description": "Amount of batches which are not saved yet",
+                    "type": "integer",
+                    "example": 12
+                },
+                "max_size": {
+                    "description": "Max spool size in bytes",
+                    "type": "integer",
+                    "example": 67108864
+                },
+                "oldest_at": {
+                    "description": "Unix timestamp the oldest batch is spooled at (0 if spool is empty)",
+                    "type": "integer",
+                    "example": 1754045773
+                },
+                "prices": {
+                    "description": "Amount of prices which are not saved yet",
+                    "type": "integer",
+                    "example": 36
+                },
+                "size": {
+                    "description": "Size of batches which are not saved yet in bytes",
+                    "type": "integer",
+                    "example": 10452
+                }
+            }
+        },
+        "status.statusOutput": {
+            "description": "Output with service status.",
+            "type": "object",
+            "properties": {
+                "leader": {
+                    "description": "Leader election status of the replica (only if the replica collects prices)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/status.leaderStatusOutput"
+                        }
+                    ]
+                },
+                "providers": {
+                    "description": "Price providers status",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/status.providerStatusOutput"
+                    }
+                },
+                "spool": {
+                    "description": "Spool status of prices failed to save (only if the replica collects prices)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/status.spoolStatusOutput"
+                        }
+                    ]
+                }
+            }
         }
     }
 }`
@@ -6,21 +6,45 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"slices"
 	"sync"
 	"syscall"
 
 	"github.com/sirupsen/logrus"
 
 	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/backfillrunner"
+	"CryptocoinPrice/internal/app/gapdetector"
+	"CryptocoinPrice/internal/app/leader"
 	"CryptocoinPrice/internal/app/pricecollector"
+	"CryptocoinPrice/internal/app/pricestream"
+	"CryptocoinPrice/internal/app/repo"
+	"CryptocoinPrice/internal/app/repo/binance"
+	"CryptocoinPrice/internal/app/repo/coingecko"
+	"CryptocoinPrice/internal/app/repo/provider"
+	"CryptocoinPrice/internal/app/repo/spool"
 	"CryptocoinPrice/internal/app/server"
+	"CryptocoinPrice/internal/app/spoolreplayer"
 	"CryptocoinPrice/internal/pkg/database"
 	"CryptocoinPrice/internal/pkg/jsonify"
 	"CryptocoinPrice/internal/pkg/logger"
 	"CryptocoinPrice/internal/pkg/validator"
 )
 
-var _ Service = (*pricecollector.PriceCollector)(nil)
+var (
+	_ Service        = (*leader.Elector)(nil)
+	_ leader.Service = (*pricecollector.PriceCollector)(nil)
+	_ leader.Service = (*pricestream.PriceStream)(nil)
+	_ leader.Service = (*gapdetector.GapDetector)(nil)
+	_ leader.Service = (*backfillrunner.BackfillRunner)(nil)
+	_ Service        = (*spoolreplayer.SpoolReplayer)(nil)
+)
+
+const (
+	ModeAPI       = "api"       // start HTTP API only
+	ModeCollector = "collector" // start price collectors and service status endpoint only
+	ModeBoth      = "both"      // start HTTP API and price collectors
+)
 
 // App service interface.
 type Service interface {
@@ -32,8 +56,9 @@ type App struct {
 	services []Service
 }
 
-// New returns new app instance.
-func New() (*App, error) {
+// New returns new app instance starting services of given run mode
+// (api, collector or both). If mode is empty the RUN_MODE from config is used.
+func New(mode string) (*App, error) {
 	// load config
 	cfg, err := config.New()
 	if err != nil {
@@ -41,6 +66,9 @@ func New() (*App, error) {
 	}
 	// setup logger
 	logger.InitLogrus(cfg.App.LogLevel, cfg.App.LogFormat)
+	if mode == "" {
+		mode = cfg.App.RunMode
+	}
 
 	// connect to DB
 	gormDB, err := database.New(cfg.DB.ConnString,
@@ -53,21 +81,74 @@ func New() (*App, error) {
 		return nil, fmt.Errorf("db: %w", err)
 	}
 
-	// init serv
-	srv, err := server.New(cfg, gormDB, validator.New(), jsonify.New())
+	// create price providers selected in config
+	// (repos of the same provider share API calls limiter,
+	// registry keeps providers circuit breakers to report its status)
+	providerRegistry := provider.NewDefaultRegistry()
+	priceRepoAPI, err := providerRegistry.NewFromConfig(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("create server: %w", err)
+		return nil, fmt.Errorf("price provider: %w", err)
+	}
+
+	// create coin resolver and historical prices provider
+	// (nil if CoinGecko is not configured)
+	coinRepoAPI := providerRegistry.NewCoinRepoAPI(cfg)
+	historyRepoAPI := providerRegistry.NewPriceHistoryRepoAPI(cfg)
+
+	// init price collector with prices spool, price gap detector, backfill
+	// jobs runner and price stream collector (if enabled)
+	services := make([]Service, 0)
+	var leaderStatusAPI repo.LeaderStatusAPI
+	var spoolStatusAPI repo.SpoolStatusAPI
+	if mode != ModeAPI {
+		priceSpool, err := spool.NewPriceSpoolFile(cfg.App.PriceSpoolDir, cfg.App.PriceSpoolMaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("price spool: %w", err)
+		}
+		spoolStatusAPI = priceSpool
+		warnCoingeckoQuota(cfg)
+		// spool is replayed by each replica (not the leader only)
+		// to save prices spooled before leadership is lost
+		services = append(services, spoolreplayer.New(cfg, gormDB, priceSpool))
+		collectors := []leader.Service{
+			pricecollector.New(cfg, gormDB, priceRepoAPI, priceSpool),
+			gapdetector.New(cfg, gormDB, historyRepoAPI),
+			backfillrunner.New(cfg, gormDB, historyRepoAPI),
+		}
+		if cfg.App.PriceStreamEnabled {
+			priceStreamAPI := binance.NewPriceStreamBinance(cfg.App.PriceStreamURL)
+			collectors = append(collectors, pricestream.New(cfg, gormDB, priceStreamAPI))
+		}
+		// collectors are run by the leader replica only
+		elector := leader.New(cfg, gormDB, collectors...)
+		leaderStatusAPI = elector
+		services = append(services, elector)
 	}
-	// init price collector
-	priceCollector := pricecollector.New(cfg, gormDB)
+
+	// init serv (status endpoint only in collector mode)
+	if mode == ModeCollector {
+		services = append(services, server.NewStatus(cfg, providerRegistry,
+			leaderStatusAPI, spoolStatusAPI, jsonify.New()))
+	} else {
+		srv, err := server.New(cfg, gormDB, priceRepoAPI, coinRepoAPI, historyRepoAPI,
+			providerRegistry, leaderStatusAPI, spoolStatusAPI, validator.New(), jsonify.New())
+		if err != nil {
+			return nil, fmt.Errorf("create server: %w", err)
+		}
+		services = append(services, srv)
+	}
+	logrus.Infof("Run app in %s mode", mode)
 
 	return &App{
 		cfg:      cfg,
-		services: []Service{srv, priceCollector},
+		services: services,
 	}, nil
 }
 
-// Run starts HTTP-server service and price collector service.
+// Run starts services of app run mode: HTTP-server service (api mode),
+// spool replayer, service status HTTP-server and leader election service which
+// runs price collector, price gap detector, backfill jobs runner and price stream
+// collector (if enabled) while the replica is the leader (collector mode).
 // This function is blocking. It waits for os signal to gracefully shutdown all services.
 func (a *App) Run() error {
 	var appErr error
@@ -116,3 +197,17 @@ func (a *App) Run() error {
 	logrus.Info("All services was stopped. Shutdown app")
 	return appErr
 }
+
+// warnCoingeckoQuota warns if prices collection with default interval
+// exhausts CoinGecko API monthly quota before the end of month.
+// At least one call is done per collection, and retries are accounted too.
+func warnCoingeckoQuota(cfg *config.Config) {
+	if !slices.Contains(cfg.App.PriceProviders, coingecko.ProviderName) {
+		return
+	}
+	minInterval := coingecko.MinCallInterval(cfg.App.CoingeckoMonthlyQuota)
+	if cfg.App.PriceCollectInterval < minInterval {
+		logrus.Warnf("Price collect interval %s exhausts CoinGecko API monthly quota %d calls before the end of month. Min interval: %s", // nolint:lll // log message
+			cfg.App.PriceCollectInterval, cfg.App.CoingeckoMonthlyQuota, minInterval)
+	}
+}
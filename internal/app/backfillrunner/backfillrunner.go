@@ -0,0 +1,98 @@
+// Package backfillrunner provides service to run queued
+// backfill jobs of historical coin prices.
+package backfillrunner
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/repo"
+	repopg "CryptocoinPrice/internal/app/repo/pg"
+	"CryptocoinPrice/internal/app/usecase"
+)
+
+// Backfill jobs runner. It periodically runs backfill jobs queued
+// by API replicas one by one and removes old finished jobs.
+type BackfillRunner struct {
+	backfillUC usecase.BackfillUsecase
+	// interval to check queued jobs
+	interval time.Duration
+}
+
+// New returns new backfill jobs runner instance.
+// Given historical prices API repo is used to backfill prices
+// (it can be nil, then queued jobs are not run).
+func New(cfg *config.Config, db *gorm.DB,
+	historyRepoAPI repo.PriceHistoryRepoAPI) *BackfillRunner {
+
+	// create usecases
+	backfillUC := usecase.NewBackfillUC(repopg.NewCoinRepoPG(db), repopg.NewPriceRepoPG(db),
+		repopg.NewBackfillJobRepoPG(db), historyRepoAPI, cfg.App.QuoteCurrencies)
+
+	return NewWithUsecase(cfg, backfillUC)
+}
+
+// NewWithUsecase returns new backfill jobs runner instance with given usecase.
+func NewWithUsecase(cfg *config.Config, backfillUC usecase.BackfillUsecase) *BackfillRunner {
+	return &BackfillRunner{
+		backfillUC: backfillUC,
+		interval:   cfg.App.BackfillPollInterval,
+	}
+}
+
+// StartWithShutdown starts backfill jobs runner and waits for
+// context is done for gracefully shutdown runner. Jobs left running
+// by the previous runner (e.g. on died replica) are queued again on start.
+// Running job is queued again on shutdown.
+// This method is blocking.
+func (r *BackfillRunner) StartWithShutdown(ctx context.Context) error {
+	logrus.Info("Start backfill jobs runner")
+	defer logrus.Info("Backfill jobs runner is shutdown")
+
+	requeued, err := r.backfillUC.RequeueJobs()
+	if err != nil {
+		logrus.Errorf("Requeue backfill jobs: %v", err)
+	}
+	if requeued != 0 {
+		logrus.Infof("%d interrupted backfill jobs are queued again", requeued)
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.run(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// run removes old finished jobs and runs queued jobs
+// until queue is empty or context is done.
+func (r *BackfillRunner) run(ctx context.Context) {
+	if _, err := r.backfillUC.PruneJobs(); err != nil {
+		logrus.Errorf("Prune backfill jobs: %v", err)
+	}
+
+	for ctx.Err() == nil {
+		job, err := r.backfillUC.RunQueuedJob(ctx)
+		switch {
+		case errors.Is(err, usecase.ErrNotFound):
+			return
+		case errors.Is(err, usecase.ErrUnavailable):
+			logrus.Warnf("Skip backfill jobs: %v", err)
+			return
+		// if job is not claimed
+		case err != nil && job == nil:
+			logrus.Errorf("Run backfill job: %v", err)
+			return
+		}
+	}
+}
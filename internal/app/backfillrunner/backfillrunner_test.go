@@ -0,0 +1,65 @@
+package backfillrunner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/usecase"
+)
+
+// stubBackfillUC is a backfill usecase stub with queue of job errors.
+type stubBackfillUC struct {
+	queue []error
+	runs  int
+}
+
+func (u *stubBackfillUC) StartBackfill(_, _ string, _, _ int64) (*entity.BackfillJob, error) {
+	return nil, nil
+}
+
+func (u *stubBackfillUC) Backfill(_ context.Context, _, _ string, _ []string, _, _ int64,
+	_ func(job entity.BackfillJob)) (*entity.BackfillJob, error) {
+
+	return nil, nil
+}
+
+func (u *stubBackfillUC) GetBackfillJob(_ string) (*entity.BackfillJob, error) {
+	return nil, nil
+}
+
+func (u *stubBackfillUC) RunQueuedJob(_ context.Context) (*entity.BackfillJob, error) {
+	if len(u.queue) == 0 {
+		return nil, usecase.ErrNotFound
+	}
+	u.runs++
+	err := u.queue[0]
+	u.queue = u.queue[1:]
+	return &entity.BackfillJob{}, err
+}
+
+func (u *stubBackfillUC) RequeueJobs() (int, error) {
+	return 0, nil
+}
+
+func (u *stubBackfillUC) PruneJobs() (int, error) {
+	return 0, nil
+}
+
+func TestBackfillRunner_Run(t *testing.T) {
+	t.Log("Run all queued jobs including ones after failed job")
+
+	uc := &stubBackfillUC{queue: []error{nil, errors.New("provider is down"), nil}}
+	runner := NewWithUsecase(&config.Config{App: config.App{
+		BackfillPollInterval: time.Minute,
+	}}, uc)
+
+	runner.run(context.Background())
+	require.Equal(t, 3, uc.runs)
+	require.Empty(t, uc.queue)
+}
@@ -0,0 +1,131 @@
+package backfill
+
+import (
+	"errors"
+	"fmt"
+
+	fiber "github.com/gofiber/fiber/v2"
+
+	httpv1 "CryptocoinPrice/internal/app/controller/http/v1"
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/usecase"
+	"CryptocoinPrice/internal/pkg/validator"
+)
+
+var _ httpv1.BackfillController = (*Controller)(nil)
+
+// Controller is a HTTP-controller for historical prices backfill usecase.
+type Controller struct {
+	uc    usecase.BackfillUsecase
+	valid validator.Validator
+}
+
+// NewController returns new backfill controller.
+func NewController(uc usecase.BackfillUsecase, valid validator.Validator) *Controller {
+	return &Controller{
+		uc:    uc,
+		valid: valid,
+	}
+}
+
+// StartBackfill starts backfill of historical coin prices in background.
+//
+//	@summary		Загрузка исторических цен криптовалюты
+//	@description	Постановка в очередь фоновой загрузки исторических цен криптовалюты за указанный
+//	@description	период во всех валютах котировки. Задачи выполняет реплика, собирающая цены.
+//	@description	Уже сохранённые цены пропускаются.
+//	@description	Если загрузка цен этой криптовалюты уже идёт или ожидает в очереди,
+//	@description	возвращается её задача.
+//	@description	Если несколько криптовалют имеют одинаковое название, необходимо указать id нужной.
+//	@router			/currency/backfill [post]
+//	@id				start-backfill
+//	@tags			currency
+//	@param			Backfill	body		backfillInput	true	"Криптовалюта и период"
+//	@success		202			{object}	backfillJobOutput
+//	@failure		400			"Невалидное тело запроса"
+//	@failure		404			"Криптовалюта с таким названием не была добавлена в список наблюдения ранее"
+//	@failure		409			"Несколько криптовалют с таким названием"
+//	@failure		503			"Провайдер исторических цен не настроен"
+func (c *Controller) StartBackfill(ctx *fiber.Ctx) error {
+	bodyData := &backfillInput{}
+	// parse body
+	if err := ctx.BodyParser(bodyData); err != nil {
+		return fmt.Errorf("parse body: %w", err)
+	}
+	// validate parsed data
+	if err := c.valid.Validate(bodyData); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, validateErrStr(err))
+	}
+
+	job, err := c.uc.StartBackfill(bodyData.Symbol, bodyData.ExternalID, bodyData.From, bodyData.To)
+	switch {
+	case errors.Is(err, usecase.ErrAmbiguous):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, usecase.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrValidateData):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrUnavailable):
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	case err != nil:
+		return fmt.Errorf("start backfill: %w", err)
+	}
+	return ctx.Status(fiber.StatusAccepted).JSON(newBackfillJobOutput(job))
+}
+
+// GetBackfillJob returns backfill job progress.
+//
+//	@summary		Прогресс загрузки исторических цен
+//	@description	Получение прогресса фоновой загрузки исторических цен криптовалюты.
+//	@description	Завершённые задачи хранятся в течение суток.
+//	@router			/currency/backfill/{id} [get]
+//	@id				get-backfill-job
+//	@tags			currency
+//	@param			id	path		string	true	"ID задачи загрузки"
+//	@success		200	{object}	backfillJobOutput
+//	@failure		400	"Невалидный ID задачи"
+//	@failure		404	"Задача не найдена"
+func (c *Controller) GetBackfillJob(ctx *fiber.Ctx) error {
+	paramsData := &backfillJobInput{}
+	// parse path params
+	if err := ctx.ParamsParser(paramsData); err != nil {
+		return fmt.Errorf("parse params: %w", err)
+	}
+	// validate parsed data
+	if err := c.valid.Validate(paramsData); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, validateErrStr(err))
+	}
+
+	job, err := c.uc.GetBackfillJob(paramsData.ID)
+	if errors.Is(err, usecase.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fmt.Errorf("get backfill job: %w", err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(newBackfillJobOutput(job))
+}
+
+// newBackfillJobOutput returns output with given backfill job.
+func newBackfillJobOutput(job *entity.BackfillJob) *backfillJobOutput {
+	return &backfillJobOutput{
+		ID:         job.ID,
+		Symbol:     job.Symbol,
+		From:       job.From,
+		To:         job.To,
+		State:      job.State,
+		TotalSteps: job.TotalSteps,
+		DoneSteps:  job.DoneSteps,
+		Saved:      job.Saved,
+		Skipped:    job.Skipped,
+		Error:      job.Error,
+		QueuedAt:   job.QueuedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
+
+// validateErrStr returns string with validate error.
+func validateErrStr(err error) string {
+	return "validate data: " + err.Error()
+}
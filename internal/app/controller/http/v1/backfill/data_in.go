@@ -0,0 +1,49 @@
+package backfill
+
+// @description Input to start backfill of historical coin prices.
+type backfillInput struct {
+	// Coin short name
+	Symbol string `json:"coin" validate:"required,alpha" example:"btc"`
+	// Canonical coin ID (CoinGecko ID) to choose one of coins with the same name
+	ExternalID string `json:"id" validate:"omitempty,max=100" example:"bitcoin"`
+	// Unix timestamp of window start
+	From int64 `json:"from" validate:"required,min=0" example:"1751367600"`
+	// Unix timestamp of window end (current time if empty)
+	To int64 `json:"to" validate:"omitempty,gtfield=From" example:"1754045773"`
+}
+
+// @description Input to get backfill job.
+type backfillJobInput struct {
+	// Backfill job ID
+	ID string `params:"id" validate:"required,uuid"`
+}
+
+// @description Output with backfill job progress.
+type backfillJobOutput struct {
+	// Backfill job ID
+	ID string `json:"id" example:"0c1d0c57-7c53-4c4b-9c4c-6f0f5e0f4b9e"`
+	// Coin short name
+	Symbol string `json:"coin" example:"btc"`
+	// Unix timestamp of window start
+	From int64 `json:"from" example:"1751367600"`
+	// Unix timestamp of window end
+	To int64 `json:"to" example:"1754045773"`
+	// Job state (queued/running/done/failed)
+	State string `json:"state" example:"running"`
+	// Amount of requests to price provider
+	TotalSteps int `json:"total_steps" example:"2"`
+	// Amount of done requests to price provider
+	DoneSteps int `json:"done_steps" example:"1"`
+	// Amount of saved prices
+	Saved int `json:"saved" example:"720"`
+	// Amount of skipped prices which are already saved
+	Skipped int `json:"skipped" example:"3"`
+	// Error message if job is failed
+	Error string `json:"error,omitempty" example:""`
+	// Unix timestamp the job is queued at
+	QueuedAt int64 `json:"queued_at" example:"1754045770"`
+	// Unix timestamp of job start (0 if job is queued)
+	StartedAt int64 `json:"started_at" example:"1754045773"`
+	// Unix timestamp of job finish (0 if job is not finished)
+	FinishedAt int64 `json:"finished_at" example:"0"`
+}
@@ -1,47 +1,72 @@
 package coinmanage
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	fiber "github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 
 	httpv1 "CryptocoinPrice/internal/app/controller/http/v1"
+	"CryptocoinPrice/internal/app/entity"
 	"CryptocoinPrice/internal/app/usecase"
 	"CryptocoinPrice/internal/pkg/validator"
 )
 
 var _ httpv1.CoinManageController = (*Controller)(nil)
 
+// header with error of backfill failed to start after coin is observed
+const _headerBackfillError = "X-Backfill-Error"
+
+const _defaultPageLimit = 100 // amount of prices in page if limit is not set
+
 // Controller is a HTTP-controller for coin manage usecase.
 type Controller struct {
-	uc    usecase.CoinManageUsecase
-	valid validator.Validator
+	uc         usecase.CoinManageUsecase
+	backfillUC usecase.BackfillUsecase
+	valid      validator.Validator
 }
 
 // NewController returns new coin manage controller.
-func NewController(uc usecase.CoinManageUsecase,
+// Backfill usecase is used to backfill prices of observed coin on demand.
+func NewController(uc usecase.CoinManageUsecase, backfillUC usecase.BackfillUsecase,
 	valid validator.Validator) *Controller {
 
 	return &Controller{
-		uc:    uc,
-		valid: valid,
+		uc:         uc,
+		backfillUC: backfillUC,
+		valid:      valid,
 	}
 }
 
 // AddObserve appends coin to observed list.
+// If many coins have the same name the id of needed one must be specified.
+// If backfill days are given the backfill of coin prices is started.
+// Coin is observed even if backfill is failed to start, then backfill
+// error is returned in header.
 //
 //	@summary		Добавление криптовалюты в список наблюдения
 //	@description	Добавление криптовалюты в список наблюдения.
+//	@description	Если несколько криптовалют имеют одинаковое название, необходимо указать id нужной.
+//	@description	Если указано поле backfill_days, запускается загрузка исторических цен за это количество дней.
 //	@router			/currency/add [post]
 //	@id				observe-coin
 //	@tags			currency
-//	@param			Coin	body	coinObservedInput	true	"Название криптовалюты"
-//	@success		204		"Успешное добавление в список наблюдения"
+//	@param			Coin	body	coinAddInput	true	"Название криптовалюты"
+//	@success		202		"Успешное добавление в список наблюдения и запуск загрузки исторических цен (см. заголовок Location)"
+//	@success		204		"Успешное добавление в список наблюдения (если загрузку исторических цен запустить не удалось, ошибка в заголовке X-Backfill-Error)"
 //	@failure		400		"Невалидное тело запроса"
-//	@failure		404		"Криптовалюта с таким названием не существует"
+//	@failure		404		"Криптовалюта с таким названием (или id) не существует"
+//	@failure		409		{object}	ambiguousCoinOutput	"Несколько криптовалют с таким названием"
+//	@failure		503		"Провайдеры цен временно недоступны (см. заголовок Retry-After)"
 func (c *Controller) AddObserve(ctx *fiber.Ctx) error {
-	bodyData := &coinObservedInput{}
+	bodyData := &coinAddInput{}
 	// parse body
 	if err := ctx.BodyParser(bodyData); err != nil {
 		return fmt.Errorf("parse body: %w", err)
@@ -52,19 +77,42 @@ func (c *Controller) AddObserve(ctx *fiber.Ctx) error {
 	}
 
 	// observe coin
-	if _, err := c.uc.ObserveCoin(bodyData.Symbol); err != nil {
-		if errors.Is(err, usecase.ErrNotFound) {
-			return fiber.NewError(fiber.StatusNotFound, err.Error())
-		}
+	_, err := c.uc.ObserveCoin(ctx.UserContext(), bodyData.Symbol, bodyData.ExternalID)
+	var ambiguousErr *usecase.AmbiguousCoinError
+	var unavailableErr *usecase.UnavailableError
+	switch {
+	case errors.As(err, &ambiguousErr):
+		return ctx.Status(fiber.StatusConflict).JSON(newAmbiguousCoinOutput(ambiguousErr))
+	case errors.As(err, &unavailableErr):
+		ctx.Set(fiber.HeaderRetryAfter, retryAfterSeconds(unavailableErr.RetryAfter))
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, usecase.ErrNotFound), errors.Is(err, usecase.ErrValidateData):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case err != nil:
 		return err
 	}
-	return ctx.Status(fiber.StatusNoContent).Send(nil)
+
+	if bodyData.BackfillDays == 0 {
+		return ctx.Status(fiber.StatusNoContent).Send(nil)
+	}
+	// start backfill of observed coin prices
+	from := time.Now().AddDate(0, 0, -bodyData.BackfillDays).Unix()
+	job, err := c.backfillUC.StartBackfill(bodyData.Symbol, bodyData.ExternalID, from, 0)
+	// coin is already observed so request is not failed
+	if err != nil {
+		logrus.WithField("coin", bodyData.Symbol).Errorf("Start backfill: %v", err)
+		ctx.Set(_headerBackfillError, err.Error())
+		return ctx.Status(fiber.StatusNoContent).Send(nil)
+	}
+	ctx.Location("/api/v1/currency/backfill/" + job.ID)
+	return ctx.Status(fiber.StatusAccepted).Send(nil)
 }
 
 // RemoveObserve removes coin from observed list.
 //
 //	@summary		Удаление криптовалюты из списка наблюдения
 //	@description	Удаление криптовалюты из списка наблюдения.
+//	@description	Если несколько криптовалют имеют одинаковое название, необходимо указать id нужной.
 //	@router			/currency/remove [delete]
 //	@id				disable-observe-coin
 //	@tags			currency
@@ -72,6 +120,7 @@ func (c *Controller) AddObserve(ctx *fiber.Ctx) error {
 //	@success		204		"Успешное добавление в список наблюдения"
 //	@failure		400		"Невалидное тело запроса"
 //	@failure		404		"Криптовалюта с таким названием не была добавлена в список наблюдения ранее"
+//	@failure		409		{object}	ambiguousCoinOutput	"Несколько криптовалют с таким названием"
 func (c *Controller) RemoveObserve(ctx *fiber.Ctx) error {
 	bodyData := &coinObservedInput{}
 	// parse body
@@ -84,27 +133,82 @@ func (c *Controller) RemoveObserve(ctx *fiber.Ctx) error {
 	}
 
 	// observe coin
-	if _, err := c.uc.DisableObserveCoin(bodyData.Symbol); err != nil {
-		if errors.Is(err, usecase.ErrNotFound) {
-			return fiber.NewError(fiber.StatusNotFound, err.Error())
-		}
+	_, err := c.uc.DisableObserveCoin(bodyData.Symbol, bodyData.ExternalID)
+	var ambiguousErr *usecase.AmbiguousCoinError
+	switch {
+	case errors.As(err, &ambiguousErr):
+		return ctx.Status(fiber.StatusConflict).JSON(newAmbiguousCoinOutput(ambiguousErr))
+	case errors.Is(err, usecase.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case err != nil:
 		return err
 	}
 	return ctx.Status(fiber.StatusNoContent).Send(nil)
 }
 
-// GetPrice returns nearest price for coin and timestamp.
+// SetCollectInterval sets interval between coin price collections.
+//
+//	@summary		Настройка интервала сбора цен криптовалюты
+//	@description	Настройка интервала сбора цен криптовалюты в секундах.
+//	@description	Интервал 0 сбрасывает его на интервал по умолчанию (PRICE_COLLECT_INTERVAL).
+//	@description	Если несколько криптовалют имеют одинаковое название, необходимо указать id нужной.
+//	@router			/currency/interval [put]
+//	@id				set-coin-collect-interval
+//	@tags			currency
+//	@param			Interval	body		coinIntervalInput	true	"Название криптовалюты и интервал"
+//	@success		200			{object}	coinIntervalOutput
+//	@failure		400			"Невалидное тело запроса"
+//	@failure		404			"Криптовалюта с таким названием не была добавлена в список наблюдения ранее"
+//	@failure		409			{object}	ambiguousCoinOutput	"Несколько криптовалют с таким названием"
+func (c *Controller) SetCollectInterval(ctx *fiber.Ctx) error {
+	bodyData := &coinIntervalInput{}
+	// parse body
+	if err := ctx.BodyParser(bodyData); err != nil {
+		return fmt.Errorf("parse body: %w", err)
+	}
+	// validate parsed data
+	if err := c.valid.Validate(bodyData); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, validateErrStr(err))
+	}
+
+	// set coin collect interval
+	coin, err := c.uc.SetCollectInterval(bodyData.Symbol, bodyData.ExternalID, bodyData.Interval)
+	var ambiguousErr *usecase.AmbiguousCoinError
+	switch {
+	case errors.As(err, &ambiguousErr):
+		return ctx.Status(fiber.StatusConflict).JSON(newAmbiguousCoinOutput(ambiguousErr))
+	case errors.Is(err, usecase.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrValidateData):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case err != nil:
+		return fmt.Errorf("set collect interval: %w", err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(coinIntervalOutput{
+		Symbol:   coin.Symbol,
+		Interval: coin.CollectInterval,
+	})
+}
+
+// GetPrice returns price for coin and timestamp found in lookup mode.
 //
 //	@summary		Получение цены криптовалюты
 //	@description	Получение цены криптовалюты.
+//	@description	Если несколько криптовалют имеют одинаковое название, необходимо указать id нужной.
 //	@router			/currency/price [get]
 //	@id				get-coin-price
 //	@tags			currency
 //	@param			coin		query		string	true	"Название криптовалюты и время"
+//	@param			id			query		string	false	"Идентификатор криптовалюты (CoinGecko ID) для выбора одной из криптовалют с одинаковым названием"
 //	@param			timestamp	query		int64	true	"Время в UNIX-формате"
+//	@param			currency	query		string	false	"Валюта котировки (по умолчанию - основная валюта)"
+//	@param			time_axis	query		string	false	"Ось времени для поиска: время цены у провайдера (source, по умолчанию) или время сбора (ingested)"	Enums(source, ingested)
+//	@param			mode		query		string	false	"Режим поиска: ближайшая цена (nearest, по умолчанию), последняя цена не позже времени (before), первая цена не раньше времени (after) или линейная интерполяция между ними (linear)"	Enums(nearest, before, after, linear)
+//	@param			max_distance	query		int64	false	"Максимальное расстояние в секундах от времени до найденной цены (без ограничения по умолчанию)"
 //	@success		200			{object}	coinPriceOutput
 //	@failure		400			"Невалидное тело запроса"
-//	@failure		404			"Ни одна цена криптовалюты не найдена"
+//	@failure		404			"Ни одна цена криптовалюты не найдена (в пределах max_distance, если задано)"
+//	@failure		409			{object}	ambiguousCoinOutput	"Несколько криптовалют с таким названием"
 func (c *Controller) GetPrice(ctx *fiber.Ctx) error {
 	bodyData := &coinPriceInput{}
 	// parse body
@@ -117,21 +221,156 @@ func (c *Controller) GetPrice(ctx *fiber.Ctx) error {
 	}
 
 	// get coin price
-	price, err := c.uc.GetNearestPrice(bodyData.Symbol, bodyData.Timestamp)
-	if err != nil && errors.Is(err, usecase.ErrNotFound) {
+	price, err := c.uc.GetPrice(bodyData.Symbol, bodyData.ExternalID, bodyData.Currency,
+		bodyData.Timestamp, bodyData.TimeAxis, bodyData.Mode, bodyData.MaxDistance)
+	var ambiguousErr *usecase.AmbiguousCoinError
+	switch {
+	case errors.As(err, &ambiguousErr):
+		return ctx.Status(fiber.StatusConflict).JSON(newAmbiguousCoinOutput(ambiguousErr))
+	case errors.Is(err, usecase.ErrNotFound):
 		return fiber.NewError(fiber.StatusNotFound, err.Error())
-	}
-	if err != nil {
+	case err != nil:
 		return fmt.Errorf("get coin price: %w", err)
 	}
 	outputPrice := coinPriceOutput{
-		Symbol:    bodyData.Symbol,
-		Timestamp: price.Timestamp,
-		Price:     price.Price,
+		Symbol:     bodyData.Symbol,
+		Timestamp:  price.Timestamp,
+		IngestedAt: price.IngestedAt,
+		Price:      price.Price.String(),
+		Currency:   price.Currency,
+		Mode:       price.Mode,
+	}
+	for _, source := range price.Sources {
+		outputPrice.SourceTimestamps = append(outputPrice.SourceTimestamps, source.Timestamp)
 	}
 	return ctx.Status(fiber.StatusOK).JSON(outputPrice)
 }
 
+// GetPriceHistory returns page of coin prices in time range.
+//
+//	@summary		История цен криптовалюты
+//	@description	Получение цен криптовалюты за период постранично.
+//	@description	Для получения следующей страницы передаётся курсор next_cursor из текущей.
+//	@description	Если несколько криптовалют имеют одинаковое название, необходимо указать id нужной.
+//	@router			/currency/{coin}/prices [get]
+//	@id				get-coin-price-history
+//	@tags			currency
+//	@param			coin		path		string	true	"Название криптовалюты"
+//	@param			id			query		string	false	"Идентификатор криптовалюты (CoinGecko ID) для выбора одной из криптовалют с одинаковым названием"
+//	@param			from		query		int64	false	"Начало периода в UNIX-формате (по умолчанию - 0)"
+//	@param			to			query		int64	true	"Конец периода в UNIX-формате"
+//	@param			currency	query		string	false	"Валюта котировки (по умолчанию - основная валюта)"
+//	@param			order		query		string	false	"Порядок цен по времени: от старых (asc, по умолчанию) или от новых (desc)"	Enums(asc, desc)
+//	@param			limit		query		int		false	"Максимальное количество цен на странице (по умолчанию - 100, не больше 1000)"
+//	@param			cursor		query		string	false	"Курсор страницы из ответа на запрос предыдущей страницы"
+//	@success		200			{object}	priceHistoryOutput
+//	@failure		400			"Невалидные параметры запроса"
+//	@failure		404			"Криптовалюта с таким названием не была добавлена в список наблюдения ранее"
+//	@failure		409			{object}	ambiguousCoinOutput	"Несколько криптовалют с таким названием"
+func (c *Controller) GetPriceHistory(ctx *fiber.Ctx) error {
+	queryData := &priceHistoryInput{}
+	// parse path params and query
+	if err := ctx.ParamsParser(queryData); err != nil {
+		return fmt.Errorf("parse params: %w", err)
+	}
+	if err := ctx.QueryParser(queryData); err != nil {
+		return fmt.Errorf("parse query: %w", err)
+	}
+	// validate parsed data
+	if err := c.valid.Validate(queryData); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, validateErrStr(err))
+	}
+	if queryData.Limit == 0 {
+		queryData.Limit = _defaultPageLimit
+	}
+	var cursor *entity.PriceCursor
+	if queryData.Cursor != "" {
+		var err error
+		if cursor, err = decodeCursor(queryData.Cursor); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, validateErrStr(err))
+		}
+	}
+
+	priceList, nextCursor, err := c.uc.GetPriceHistory(queryData.Symbol, queryData.ExternalID,
+		queryData.Currency, queryData.From, queryData.To, queryData.Order, cursor, queryData.Limit)
+	var ambiguousErr *usecase.AmbiguousCoinError
+	switch {
+	case errors.As(err, &ambiguousErr):
+		return ctx.Status(fiber.StatusConflict).JSON(newAmbiguousCoinOutput(ambiguousErr))
+	case errors.Is(err, usecase.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case err != nil:
+		return fmt.Errorf("get price history: %w", err)
+	}
+
+	output := priceHistoryOutput{
+		Symbol: queryData.Symbol,
+		Prices: make([]priceHistoryItemOutput, 0, len(priceList)),
+	}
+	for _, price := range priceList {
+		output.Prices = append(output.Prices, priceHistoryItemOutput{
+			Timestamp:  price.Timestamp,
+			IngestedAt: price.IngestedAt,
+			Price:      price.Price.String(),
+			Currency:   price.Currency,
+			Source:     price.Source,
+		})
+	}
+	if nextCursor != nil {
+		output.NextCursor = encodeCursor(nextCursor)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(output)
+}
+
+// encodeCursor returns opaque string with given price cursor.
+func encodeCursor(cursor *entity.PriceCursor) string {
+	return base64.RawURLEncoding.EncodeToString(
+		fmt.Appendf(nil, "%d:%s", cursor.Timestamp, cursor.ID))
+}
+
+// decodeCursor returns price cursor from opaque string.
+func decodeCursor(encoded string) (*entity.PriceCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	rawTimestamp, id, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return nil, errors.New("invalid cursor")
+	}
+	timestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	return &entity.PriceCursor{Timestamp: timestamp, ID: id}, nil
+}
+
+// newAmbiguousCoinOutput returns output with coin candidates from error.
+func newAmbiguousCoinOutput(err *usecase.AmbiguousCoinError) *ambiguousCoinOutput {
+	output := &ambiguousCoinOutput{
+		Message:    err.Error(),
+		Candidates: make([]coinCandidateOutput, 0, len(err.Candidates)),
+	}
+	for _, candidate := range err.Candidates {
+		output.Candidates = append(output.Candidates, coinCandidateOutput{
+			ExternalID: candidate.ExternalID,
+			Name:       candidate.Name,
+			Platforms:  candidate.Platforms,
+		})
+	}
+	return output
+}
+
+// retryAfterSeconds returns value of Retry-After header
+// with given duration rounded up to whole seconds.
+func retryAfterSeconds(retryAfter time.Duration) string {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	return strconv.FormatInt(max(seconds, 1), 10)
+}
+
 // validateErrStr returns string with validate error.
 func validateErrStr(err error) string {
 	return "validate data: " + err.Error()
@@ -4,22 +4,138 @@ package coinmanage
 type coinObservedInput struct {
 	// Coin short name
 	Symbol string `json:"coin" validate:"required,alpha" example:"btc"`
+	// Canonical coin ID (CoinGecko ID) to choose one of coins with the same name
+	ExternalID string `json:"id" validate:"omitempty,max=100" example:"bitcoin"`
+}
+
+// @description Input to add coin to observed list.
+type coinAddInput struct {
+	// Coin short name
+	Symbol string `json:"coin" validate:"required,alpha" example:"uni"`
+	// Canonical coin ID (CoinGecko ID) to choose one of coins with the same name
+	ExternalID string `json:"id" validate:"omitempty,max=100" example:"uniswap"`
+	// Amount of days to backfill historical coin prices for (no backfill if empty)
+	BackfillDays int `json:"backfill_days" validate:"omitempty,min=1,max=365" example:"30"`
+}
+
+// @description Input to set interval between coin price collections.
+type coinIntervalInput struct {
+	// Coin short name
+	Symbol string `json:"coin" validate:"required,alpha" example:"btc"`
+	// Canonical coin ID (CoinGecko ID) to choose one of coins with the same name
+	ExternalID string `json:"id" validate:"omitempty,max=100" example:"bitcoin"`
+	// Interval between coin price collections in seconds (0 - default interval)
+	Interval int64 `json:"interval" validate:"min=0,max=86400" example:"10"`
+}
+
+// @description Output with interval between coin price collections.
+type coinIntervalOutput struct {
+	// Coin short name
+	Symbol string `json:"coin" example:"btc"`
+	// Interval between coin price collections in seconds (0 - default interval)
+	Interval int64 `json:"interval" example:"10"`
 }
 
 // @description Input to get coin price at timestamp.
 type coinPriceInput struct {
 	// Coin short name
 	Symbol string `query:"coin" validate:"required,alpha" example:"btc"`
+	// Canonical coin ID (CoinGecko ID) to choose one of coins with the same name
+	ExternalID string `query:"id" validate:"omitempty,max=100" example:"bitcoin"`
 	// Unix timestamp
 	Timestamp int64 `query:"timestamp" validate:"required,min=0" example:"1736500490"`
+	// Quote currency (default quote currency if empty)
+	Currency string `query:"currency" validate:"omitempty,alpha,lowercase,max=10" example:"usd"`
+	// Time axis to search price on: source timestamp (default) or ingestion timestamp
+	TimeAxis string `query:"time_axis" validate:"omitempty,oneof=source ingested" example:"source"`
+	// Lookup mode: the nearest price (default), the last price before timestamp,
+	// the first price after timestamp or price interpolated between them
+	Mode string `query:"mode" validate:"omitempty,oneof=nearest before after linear" example:"before"`
+	// Max distance in seconds from timestamp to found price (unlimited if empty)
+	MaxDistance int64 `query:"max_distance" validate:"min=0,max=31536000" example:"3600"`
 }
 
 // @description Output for gotten coin price at timestamp.
 type coinPriceOutput struct {
 	// Coin short name
 	Symbol string `json:"coin" validate:"required,alpha" example:"btc"`
-	// Unix timestamp
+	// Unix timestamp of the price last update at provider (source time axis).
+	// If interpolated it is requested timestamp for source time axis
+	// and interpolated one for ingested time axis
 	Timestamp int64 `json:"timestamp" validate:"required,min=0" example:"1754045773"`
+	// Unix timestamp the price is collected at (ingested time axis).
+	// If interpolated it is requested timestamp for ingested time axis
+	// and interpolated one for source time axis
+	IngestedAt int64 `json:"ingested_at" example:"1754045775"`
 	// Coin price
 	Price string `json:"price" example:"114818"`
+	// Quote currency of the price
+	Currency string `json:"currency" example:"usd"`
+	// Lookup mode the price is found in
+	Mode string `json:"mode" example:"before"`
+	// Unix timestamps at provider of saved prices the price is found by
+	// (two timestamps if price is interpolated)
+	SourceTimestamps []int64 `json:"source_timestamps" example:"1754045773"`
+}
+
+// @description Input to get coin prices in time range.
+type priceHistoryInput struct {
+	// Coin short name
+	Symbol string `params:"coin" validate:"required,alpha" example:"btc"`
+	// Canonical coin ID (CoinGecko ID) to choose one of coins with the same name
+	ExternalID string `query:"id" validate:"omitempty,max=100" example:"bitcoin"`
+	// Unix timestamp of range start
+	From int64 `query:"from" validate:"min=0" example:"1754006400"`
+	// Unix timestamp of range end
+	To int64 `query:"to" validate:"required,gtefield=From" example:"1754092800"`
+	// Quote currency (default quote currency if empty)
+	Currency string `query:"currency" validate:"omitempty,alpha,lowercase,max=10" example:"usd"`
+	// Order of prices by timestamp: from the oldest (default) or from the newest
+	Order string `query:"order" validate:"omitempty,oneof=asc desc" example:"asc"`
+	// Max amount of prices in page (100 if empty)
+	Limit int `query:"limit" validate:"omitempty,min=1,max=1000" example:"100"`
+	// Cursor of page from previous page (the first page if empty)
+	Cursor string `query:"cursor" validate:"omitempty,base64rawurl,max=100" example:""`
+}
+
+// @description Output with page of coin prices in time range.
+type priceHistoryOutput struct {
+	// Coin short name
+	Symbol string `json:"coin" example:"btc"`
+	// Prices in requested order
+	Prices []priceHistoryItemOutput `json:"prices"`
+	// Cursor of the next page (empty if the page is the last one)
+	NextCursor string `json:"next_cursor,omitempty" example:"MTc1NDAxMDAwMDowYzFkMGM1Ny03YzUzLTRjNGItOWM0Yy02ZjBmNWUwZjRiOWU"` // nolint:lll // example
+}
+
+// @description Coin price in time range.
+type priceHistoryItemOutput struct {
+	// Unix timestamp of the price last update at provider
+	Timestamp int64 `json:"timestamp" example:"1754010000"`
+	// Unix timestamp the price is collected at
+	IngestedAt int64 `json:"ingested_at" example:"1754010002"`
+	// Coin price
+	Price string `json:"price" example:"115412.48"`
+	// Quote currency of the price
+	Currency string `json:"currency" example:"usd"`
+	// Name of the price provider which supplied the price
+	Source string `json:"source" example:"coingecko"`
+}
+
+// @description Output with coins having the same name to choose one of them.
+type ambiguousCoinOutput struct {
+	// Error message
+	Message string `json:"message" example:"ambiguous: 2 coins have symbol uni: coin id must be specified"` // nolint:lll // example
+	// Coins with the same name
+	Candidates []coinCandidateOutput `json:"candidates"`
+}
+
+// @description Coin with requested name.
+type coinCandidateOutput struct {
+	// Canonical coin ID (CoinGecko ID)
+	ExternalID string `json:"id" example:"uniswap"`
+	// Coin full name
+	Name string `json:"name" example:"Uniswap"`
+	// Blockchain platform names with contract addresses
+	Platforms map[string]string `json:"platforms"`
 }
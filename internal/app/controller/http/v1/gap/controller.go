@@ -0,0 +1,102 @@
+package gap
+
+import (
+	"errors"
+	"fmt"
+
+	fiber "github.com/gofiber/fiber/v2"
+
+	httpv1 "CryptocoinPrice/internal/app/controller/http/v1"
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/usecase"
+	"CryptocoinPrice/internal/pkg/validator"
+)
+
+var _ httpv1.GapController = (*Controller)(nil)
+
+const _defaultLimit = 100 // amount of returned gaps if limit is not set
+
+// Controller is a HTTP-controller for price gaps usecase.
+type Controller struct {
+	uc    usecase.PriceGapUsecase
+	valid validator.Validator
+}
+
+// NewController returns new price gaps controller.
+func NewController(uc usecase.PriceGapUsecase, valid validator.Validator) *Controller {
+	return &Controller{
+		uc:    uc,
+		valid: valid,
+	}
+}
+
+// GetGaps returns detected gaps in collected prices.
+//
+//	@summary		Пропуски в ценах криптовалют
+//	@description	Получение пропусков в собранных ценах криптовалют (от самого нового).
+//	@description	Пропуск обнаруживается, если соседние цены отстоят друг от друга
+//	@description	больше, чем на несколько интервалов сбора монеты.
+//	@router			/currency/gaps [get]
+//	@id				get-price-gaps
+//	@tags			currency
+//	@param			coin		query		string	false	"Название криптовалюты (по умолчанию - все)"
+//	@param			id			query		string	false	"Идентификатор криптовалюты (CoinGecko ID) для выбора одной из криптовалют с одинаковым названием"
+//	@param			currency	query		string	false	"Валюта котировки (по умолчанию - все)"
+//	@param			state		query		string	false	"Состояние пропуска (по умолчанию - все)"	Enums(detected, repaired, failed)
+//	@param			limit		query		int		false	"Максимальное количество пропусков (по умолчанию - 100)"
+//	@success		200			{object}	gapsOutput
+//	@failure		400			"Невалидные параметры запроса"
+//	@failure		404			"Криптовалюта с таким названием не была добавлена в список наблюдения ранее"
+//	@failure		409			"Несколько криптовалют с таким названием"
+func (c *Controller) GetGaps(ctx *fiber.Ctx) error {
+	queryData := &gapsInput{}
+	// parse query
+	if err := ctx.QueryParser(queryData); err != nil {
+		return fmt.Errorf("parse query: %w", err)
+	}
+	// validate parsed data
+	if err := c.valid.Validate(queryData); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, validateErrStr(err))
+	}
+	if queryData.Limit == 0 {
+		queryData.Limit = _defaultLimit
+	}
+
+	gapList, err := c.uc.GetGaps(queryData.Symbol, queryData.ExternalID, queryData.Currency,
+		queryData.State, queryData.Limit)
+	switch {
+	case errors.Is(err, usecase.ErrAmbiguous):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, usecase.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case err != nil:
+		return fmt.Errorf("get gaps: %w", err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(newGapsOutput(gapList))
+}
+
+// newGapsOutput returns output with given price gaps.
+func newGapsOutput(gapList entity.PriceGapList) *gapsOutput {
+	output := &gapsOutput{Gaps: make([]gapOutput, 0, len(gapList))}
+	for _, gap := range gapList {
+		output.Gaps = append(output.Gaps, gapOutput{
+			ID:               gap.ID,
+			Symbol:           gap.Coin.Symbol,
+			Currency:         gap.Currency,
+			From:             gap.FromTimestamp,
+			To:               gap.ToTimestamp,
+			Duration:         gap.ToTimestamp - gap.FromTimestamp,
+			ExpectedInterval: gap.ExpectedInterval,
+			State:            gap.State,
+			Backfilled:       gap.Backfilled,
+			Error:            gap.Error,
+			DetectedAt:       gap.DetectedAt,
+		})
+	}
+	return output
+}
+
+// validateErrStr returns string with validate error.
+func validateErrStr(err error) string {
+	return "validate data: " + err.Error()
+}
@@ -0,0 +1,47 @@
+package gap
+
+// @description Input to get price gaps.
+type gapsInput struct {
+	// Coin short name (all coins if empty)
+	Symbol string `query:"coin" validate:"omitempty,alpha" example:"btc"`
+	// Canonical coin ID (CoinGecko ID) to choose one of coins with the same name
+	ExternalID string `query:"id" validate:"omitempty,max=100" example:"bitcoin"`
+	// Quote currency (all currencies if empty)
+	Currency string `query:"currency" validate:"omitempty,alpha,lowercase,max=10" example:"usd"`
+	// Gap state (all states if empty)
+	State string `query:"state" validate:"omitempty,oneof=detected repaired failed" example:"detected"` // nolint:lll // validate tag
+	// Max amount of gaps (100 if empty)
+	Limit int `query:"limit" validate:"omitempty,min=1,max=1000" example:"100"`
+}
+
+// @description Output with price gaps.
+type gapsOutput struct {
+	// Price gaps from the newest one
+	Gaps []gapOutput `json:"gaps"`
+}
+
+// @description Price gap.
+type gapOutput struct {
+	// Gap ID
+	ID string `json:"id" example:"0c1d0c57-7c53-4c4b-9c4c-6f0f5e0f4b9e"`
+	// Coin short name
+	Symbol string `json:"coin" example:"btc"`
+	// Quote currency of gap prices
+	Currency string `json:"currency" example:"usd"`
+	// Unix timestamp of the last price before gap
+	From int64 `json:"from" example:"1754013600"`
+	// Unix timestamp of the first price after gap
+	To int64 `json:"to" example:"1754020800"`
+	// Gap duration in seconds
+	Duration int64 `json:"duration" example:"7200"`
+	// Expected interval between prices in seconds
+	ExpectedInterval int64 `json:"expected_interval" example:"5"`
+	// Gap state (detected/repaired/failed)
+	State string `json:"state" example:"repaired"`
+	// Amount of prices saved by backfill of gap
+	Backfilled int `json:"backfilled" example:"48"`
+	// Error message if backfill of gap is failed
+	Error string `json:"error,omitempty" example:""`
+	// Unix timestamp of gap detection
+	DetectedAt int64 `json:"detected_at" example:"1754021400"`
+}
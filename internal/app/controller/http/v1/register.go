@@ -10,7 +10,22 @@ import (
 type CoinManageController interface {
 	AddObserve(ctx *fiber.Ctx) error
 	RemoveObserve(ctx *fiber.Ctx) error
+	SetCollectInterval(ctx *fiber.Ctx) error
 	GetPrice(ctx *fiber.Ctx) error
+	GetPriceHistory(ctx *fiber.Ctx) error
+}
+
+type BackfillController interface {
+	StartBackfill(ctx *fiber.Ctx) error
+	GetBackfillJob(ctx *fiber.Ctx) error
+}
+
+type GapController interface {
+	GetGaps(ctx *fiber.Ctx) error
+}
+
+type StatusController interface {
+	GetStatus(ctx *fiber.Ctx) error
 }
 
 // RegisterCoinManageEndpoints registers all endpoints for coin manage controller.
@@ -19,5 +34,27 @@ func RegisterCoinManageEndpoints(router fiber.Router, controller CoinManageContr
 
 	currencyPrefix.Post("/add", controller.AddObserve)
 	currencyPrefix.Delete("/remove", controller.RemoveObserve)
+	currencyPrefix.Put("/interval", controller.SetCollectInterval)
 	currencyPrefix.Get("/price", controller.GetPrice)
+	currencyPrefix.Get("/:coin/prices", controller.GetPriceHistory)
+}
+
+// RegisterBackfillEndpoints registers all endpoints for backfill controller.
+func RegisterBackfillEndpoints(router fiber.Router, controller BackfillController) {
+	currencyPrefix := router.Group("/currency")
+
+	currencyPrefix.Post("/backfill", controller.StartBackfill)
+	currencyPrefix.Get("/backfill/:id", controller.GetBackfillJob)
+}
+
+// RegisterGapEndpoints registers all endpoints for price gaps controller.
+func RegisterGapEndpoints(router fiber.Router, controller GapController) {
+	currencyPrefix := router.Group("/currency")
+
+	currencyPrefix.Get("/gaps", controller.GetGaps)
+}
+
+// RegisterStatusEndpoints registers all endpoints for service status controller.
+func RegisterStatusEndpoints(router fiber.Router, controller StatusController) {
+	router.Get("/status", controller.GetStatus)
 }
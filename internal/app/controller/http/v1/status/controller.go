@@ -0,0 +1,66 @@
+package status
+
+import (
+	fiber "github.com/gofiber/fiber/v2"
+
+	httpv1 "CryptocoinPrice/internal/app/controller/http/v1"
+	"CryptocoinPrice/internal/app/usecase"
+)
+
+var _ httpv1.StatusController = (*Controller)(nil)
+
+// Controller is a HTTP-controller for service status usecase.
+type Controller struct {
+	uc usecase.StatusUsecase
+}
+
+// NewController returns new service status controller.
+func NewController(uc usecase.StatusUsecase) *Controller {
+	return &Controller{
+		uc: uc,
+	}
+}
+
+// GetStatus returns service status.
+//
+//	@summary		Получение статуса сервиса
+//	@description	Получение статуса сервиса: состояние circuit breaker каждого провайдера цен.
+//	@description	Для реплики, собирающей цены, также возвращается, является ли она лидером,
+//	@description	и объём очереди цен, которые не удалось сохранить в БД.
+//	@router			/status [get]
+//	@id				get-status
+//	@tags			status
+//	@success		200	{object}	statusOutput
+func (c *Controller) GetStatus(ctx *fiber.Ctx) error {
+	status := c.uc.GetStatus()
+
+	output := statusOutput{
+		Providers: make([]providerStatusOutput, 0, len(status.Providers)),
+	}
+	if status.Leader != nil {
+		output.Leader = &leaderStatusOutput{
+			Instance:    status.Leader.Instance,
+			Leader:      status.Leader.Leader,
+			LeaderSince: status.Leader.LeaderSince,
+		}
+	}
+	if status.Spool != nil {
+		output.Spool = &spoolStatusOutput{
+			Batches:  status.Spool.Batches,
+			Prices:   status.Spool.Prices,
+			Size:     status.Spool.Size,
+			MaxSize:  status.Spool.MaxSize,
+			OldestAt: status.Spool.OldestAt,
+		}
+	}
+	for _, provider := range status.Providers {
+		output.Providers = append(output.Providers, providerStatusOutput{
+			Name:       provider.Name,
+			State:      provider.State,
+			Failures:   provider.Failures,
+			OpenedAt:   provider.OpenedAt,
+			RetryAfter: provider.RetryAfter,
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(output)
+}
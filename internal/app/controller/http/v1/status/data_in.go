@@ -0,0 +1,49 @@
+package status
+
+// @description Output with service status.
+type statusOutput struct {
+	// Price providers status
+	Providers []providerStatusOutput `json:"providers"`
+	// Leader election status of the replica (only if the replica collects prices)
+	Leader *leaderStatusOutput `json:"leader,omitempty"`
+	// Spool status of prices failed to save (only if the replica collects prices)
+	Spool *spoolStatusOutput `json:"spool,omitempty"`
+}
+
+// @description Price provider circuit breaker status.
+type providerStatusOutput struct {
+	// Provider name
+	Name string `json:"name" example:"coingecko"`
+	// Circuit breaker state (closed/open/half-open)
+	State string `json:"state" example:"open"`
+	// Amount of consecutive failures
+	Failures int `json:"failures" example:"5"`
+	// Unix timestamp of the last breaker opening (0 if never opened)
+	OpenedAt int64 `json:"opened_at" example:"1754045773"`
+	// Seconds until the next attempt to request provider (0 if not open)
+	RetryAfter int64 `json:"retry_after" example:"25"`
+}
+
+// @description Backlog depth of on-disk spool of prices failed to save into DB.
+type spoolStatusOutput struct {
+	// Amount of batches which are not saved yet
+	Batches int `json:"batches" example:"12"`
+	// Amount of prices which are not saved yet
+	Prices int `json:"prices" example:"36"`
+	// Size of batches which are not saved yet in bytes
+	Size int64 `json:"size" example:"10452"`
+	// Max spool size in bytes
+	MaxSize int64 `json:"max_size" example:"67108864"`
+	// Unix timestamp the oldest batch is spooled at (0 if spool is empty)
+	OldestAt int64 `json:"oldest_at" example:"1754045773"`
+}
+
+// @description Leader election status of the service replica.
+type leaderStatusOutput struct {
+	// Replica name (host name)
+	Instance string `json:"instance" example:"3f2a1c9b8d7e"`
+	// True if the replica is the leader collecting prices
+	Leader bool `json:"leader" example:"true"`
+	// Unix timestamp the replica became the leader at (0 if not the leader)
+	LeaderSince int64 `json:"leader_since" example:"1754045773"`
+}
@@ -0,0 +1,43 @@
+package entity
+
+const (
+	BackfillStateQueued  = "queued"  // backfill is waiting for the collector replica to run it
+	BackfillStateRunning = "running" // backfill is in progress
+	BackfillStateDone    = "done"    // all historical prices are saved
+	BackfillStateFailed  = "failed"  // backfill is stopped by error
+)
+
+// BackfillJob is a job of historical prices backfill for one coin.
+type BackfillJob struct {
+	// job uuid
+	ID string `gorm:"id;primaryKey;type:uuid"`
+	// uuid of backfilled coin
+	CoinID string `gorm:"coin_id;type:uuid"`
+	// coin short name
+	Symbol string `gorm:"symbol;not null"`
+	// start of backfilled window in unix format
+	From int64 `gorm:"column:from_timestamp;not null"`
+	// end of backfilled window in unix format
+	To int64 `gorm:"column:to_timestamp;not null"`
+	// job state (queued/running/done/failed)
+	State string `gorm:"state;not null"`
+	// amount of requests to provider (window chunks in each quote currency)
+	TotalSteps int `gorm:"total_steps;not null"`
+	// amount of done requests to provider
+	DoneSteps int `gorm:"done_steps;not null"`
+	// amount of saved prices
+	Saved int `gorm:"saved;not null"`
+	// amount of skipped prices which are already saved
+	Skipped int `gorm:"skipped;not null"`
+	// error message if job is failed
+	Error string `gorm:"error;not null"`
+	// job queue time in unix format
+	QueuedAt int64 `gorm:"queued_at;not null"`
+	// job start time in unix format (zero if job is queued)
+	StartedAt int64 `gorm:"started_at;not null"`
+	// job finish time in unix format (zero if job is not finished)
+	FinishedAt int64 `gorm:"finished_at;not null"`
+
+	// coin instance
+	Coin *Coin `gorm:"foreignKey:CoinID;->"`
+}
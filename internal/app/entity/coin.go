@@ -5,19 +5,52 @@ package entity
 type Coin struct {
 	// coin uuid
 	ID string `gorm:"id;primaryKey;type:uuid"`
-	// coin name
-	Symbol string `gorm:"symbol;not null;uniqueIndex"`
+	// coin name (many coins can share the same symbol)
+	Symbol string `gorm:"symbol;not null;index:idx_coins_symbol"`
+	// provider-specific canonical coin ID (CoinGecko coin ID), empty if unknown
+	ExternalID string `gorm:"external_id;not null;uniqueIndex:idx_coins_external_id,where:external_id <> ''"` // nolint:lll // gorm tag
+	// coin full name, empty if unknown
+	Name string `gorm:"name;not null"`
 	// true if coin is observed
 	Observed bool `gorm:"observed;not null"`
+	// amount of consecutive collections with failed coin prices
+	FailCount int `gorm:"fail_count;not null"`
+	// true if coin prices are failed too many collections in a row
+	Failing bool `gorm:"failing;not null"`
+	// interval between coin price collections in seconds (0 - default interval)
+	CollectInterval int64 `gorm:"collect_interval;not null"`
 }
 
 // CoinPartial is a coin object with all optional fields.
 type CoinPartial struct {
 	// coin name
 	Symbol *string `gorm:"symbol"`
+	// provider-specific canonical coin ID (CoinGecko coin ID)
+	ExternalID *string `gorm:"external_id"`
+	// coin full name
+	Name *string `gorm:"name"`
 	// true if coin is observed
 	Observed *bool `gorm:"observed"`
+	// interval between coin price collections in seconds (0 - default interval)
+	CollectInterval *int64 `gorm:"collect_interval"`
 }
 
 // CoinList is a slice of coins.
 type CoinList []Coin
+
+// CoinCandidate is a coin from API which matches requested symbol.
+// Many coins can share the same symbol so the candidate is used
+// to choose the needed one.
+type CoinCandidate struct {
+	// provider-specific canonical coin ID (CoinGecko coin ID)
+	ExternalID string
+	// coin symbol
+	Symbol string
+	// coin full name
+	Name string
+	// map of blockchain platform names to contract addresses
+	Platforms map[string]string
+}
+
+// CoinCandidateList is a slice of coin candidates.
+type CoinCandidateList []CoinCandidate
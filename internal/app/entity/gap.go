@@ -0,0 +1,38 @@
+package entity
+
+const (
+	PriceGapStateDetected = "detected" // gap is detected and is not repaired
+	PriceGapStateRepaired = "repaired" // prices of gap are backfilled
+	PriceGapStateFailed   = "failed"   // backfill of gap is failed
+)
+
+// PriceGap is a hole in collected coin prices where consecutive
+// prices are too far from each other for the expected interval.
+type PriceGap struct {
+	// gap record uuid
+	ID string `gorm:"id;primaryKey;type:uuid"`
+	// coin uuid
+	CoinID string `gorm:"coin_id;type:uuid"`
+	// quote currency of gap prices
+	Currency string `gorm:"currency;not null"`
+	// source timestamp of the last price before gap
+	FromTimestamp int64 `gorm:"from_timestamp;not null"`
+	// source timestamp of the first price after gap
+	ToTimestamp int64 `gorm:"to_timestamp;not null"`
+	// expected interval between coin prices in seconds
+	ExpectedInterval int64 `gorm:"expected_interval;not null"`
+	// gap state (detected/repaired/failed)
+	State string `gorm:"state;not null"`
+	// amount of prices saved by backfill of gap
+	Backfilled int `gorm:"backfilled;not null"`
+	// error message if backfill of gap is failed
+	Error string `gorm:"error;not null"`
+	// detection time in unix format
+	DetectedAt int64 `gorm:"detected_at;not null"`
+
+	// coin instance
+	Coin *Coin `gorm:"foreignKey:CoinID;->"`
+}
+
+// PriceGapList is a slice of price gaps.
+type PriceGapList []PriceGap
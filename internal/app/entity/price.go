@@ -1,5 +1,26 @@
 package entity
 
+import (
+	"github.com/shopspring/decimal"
+)
+
+const (
+	TimeAxisSource   = "source"   // search prices by source timestamp
+	TimeAxisIngested = "ingested" // search prices by ingestion timestamp
+)
+
+const (
+	LookupNearest = "nearest" // the nearest price
+	LookupBefore  = "before"  // the last price not later than timestamp (as-of)
+	LookupAfter   = "after"   // the first price not earlier than timestamp
+	LookupLinear  = "linear"  // price interpolated between surrounding prices
+)
+
+const (
+	OrderAsc  = "asc"  // prices from the oldest one
+	OrderDesc = "desc" // prices from the newest one
+)
+
 // Price is a coin price object
 type Price struct {
 	// price record uuid
@@ -7,25 +28,88 @@ type Price struct {
 	// coin uuid
 	CoinID string `gorm:"coin_id;type:uuid"`
 	// coin price
-	Price string `gorm:"price;not null"`
-	// created at timestamp
+	Price decimal.Decimal `gorm:"price;type:numeric;not null"`
+	// quote currency of the price (e.g. usd)
+	Currency string `gorm:"currency;not null"`
+	// source timestamp (time of the price last update at provider)
 	Timestamp int64 `gorm:"timestamp;not null"`
+	// ingestion timestamp (time the price is collected at)
+	IngestedAt int64 `gorm:"ingested_at;not null"`
+	// name of the price provider which supplied the price
+	Source string `gorm:"source;not null"`
 
 	// coin instance
 	Coin *Coin `gorm:"foreignKey:CoinID;->"`
+	// raw provider quotes the price is calculated from (for consensus price)
+	Quotes PriceQuoteList `gorm:"foreignKey:PriceID"`
 }
 
 // PriceList is a slice of coins' prices.
 type PriceList []Price
 
+// PriceQuote is a raw coin price quote from one provider.
+// It is stored to audit disagreements of providers.
+type PriceQuote struct {
+	// quote record uuid
+	ID string `gorm:"id;primaryKey;type:uuid"`
+	// price uuid the quote is related to
+	PriceID string `gorm:"price_id;type:uuid"`
+	// name of the price provider which supplied the quote
+	Source string `gorm:"source;not null"`
+	// coin price from provider
+	Price decimal.Decimal `gorm:"price;type:numeric;not null"`
+	// provider last update time in unix format
+	Timestamp int64 `gorm:"timestamp;not null"`
+	// true if quote deviates too much and is not used in price
+	Rejected bool `gorm:"rejected;not null"`
+}
+
+// PriceQuoteList is a slice of raw coin price quotes.
+type PriceQuoteList []PriceQuote
+
+// PriceCursor is a position of price in prices ordered by source
+// timestamp and ID to read the next prices after it (keyset pagination).
+type PriceCursor struct {
+	// source timestamp of the last read price
+	Timestamp int64
+	// uuid of the last read price
+	ID string
+}
+
+// LookupPrice is a coin price at requested timestamp found in lookup mode.
+type LookupPrice struct {
+	// coin price (interpolated in linear mode)
+	Price decimal.Decimal
+	// quote currency of the price (e.g. usd)
+	Currency string
+	// source timestamp of the price (if price is interpolated it is requested
+	// timestamp on source time axis and interpolated one on ingested time axis)
+	Timestamp int64
+	// ingestion timestamp of the price (if price is interpolated it is requested
+	// timestamp on ingested time axis and interpolated one on source time axis)
+	IngestedAt int64
+	// lookup mode the price is found in
+	Mode string
+	// saved prices the price is found by (two prices if price is interpolated)
+	Sources PriceList
+}
+
 // CoinPriceAPI ia a coin prise parsed from API.
 type CoinPriceAPI struct {
+	// uuid of requested coin (empty if coin is not saved yet)
+	CoinID string
 	// coin symbol
 	Symbol string
 	// coin price
-	Price float64
+	Price decimal.Decimal
+	// quote currency of the price (e.g. usd)
+	Currency string
 	// last update time in unix format
 	LastUpdate int64
+	// name of the price provider which supplied the price
+	Source string
+	// raw provider quotes the price is calculated from (for consensus price)
+	Quotes PriceQuoteList
 }
 
 // CoinPriceAPIList is a slice of coins' prices from API.
@@ -0,0 +1,54 @@
+package entity
+
+// Status is a service status.
+type Status struct {
+	// price providers status
+	Providers ProviderStatusList
+	// leader election status of the replica (nil if replica does not collect prices)
+	Leader *LeaderStatus
+	// spool status of prices failed to save (nil if replica does not collect prices)
+	Spool *SpoolStatus
+}
+
+// LeaderStatus is a leader election status of the service replica.
+// Only the leader replica runs background price collection.
+type LeaderStatus struct {
+	// replica name (host name)
+	Instance string
+	// true if the replica is the leader
+	Leader bool
+	// time the replica became the leader in unix format (zero if not the leader)
+	LeaderSince int64
+}
+
+// SpoolStatus is a backlog depth of on-disk spool
+// of collected prices which are failed to save into DB.
+type SpoolStatus struct {
+	// amount of batches which are not replayed yet
+	Batches int
+	// amount of prices which are not replayed yet
+	Prices int
+	// size of batches which are not replayed yet in bytes
+	Size int64
+	// max spool file size in bytes
+	MaxSize int64
+	// time the oldest batch is spooled at in unix format (zero if spool is empty)
+	OldestAt int64
+}
+
+// ProviderStatus is a price provider circuit breaker status.
+type ProviderStatus struct {
+	// provider name
+	Name string
+	// circuit breaker state (closed/open/half-open)
+	State string
+	// amount of consecutive failures
+	Failures int
+	// time of the last breaker opening in unix format (zero if never opened)
+	OpenedAt int64
+	// seconds until the next attempt to request provider (zero if not open)
+	RetryAfter int64
+}
+
+// ProviderStatusList is a slice of provider statuses.
+type ProviderStatusList []ProviderStatus
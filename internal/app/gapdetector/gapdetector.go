@@ -0,0 +1,108 @@
+// Package gapdetector provides service to detect gaps in collected
+// coin prices and repair them with historical prices.
+package gapdetector
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/repo"
+	repopg "CryptocoinPrice/internal/app/repo/pg"
+	"CryptocoinPrice/internal/app/usecase"
+)
+
+// Price gap detector. It periodically detects gaps in collected prices
+// and backfills detected gaps if automatic backfill is enabled.
+type GapDetector struct {
+	priceGapUC usecase.PriceGapUsecase
+	// interval to detect gaps
+	interval time.Duration
+	// true if detected gaps are backfilled
+	autoBackfill bool
+}
+
+// New returns new price gap detector instance.
+// Given historical prices API repo is used to backfill gaps
+// (it can be nil, then gaps are not backfilled).
+func New(cfg *config.Config, db *gorm.DB,
+	historyRepoAPI repo.PriceHistoryRepoAPI) *GapDetector {
+
+	// create repos
+	coinRepoPG := repopg.NewCoinRepoPG(db)
+	priceRepoDB := repopg.NewPriceRepoPG(db)
+	// create usecases
+	backfillUC := usecase.NewBackfillUC(coinRepoPG, priceRepoDB,
+		repopg.NewBackfillJobRepoPG(db), historyRepoAPI, cfg.App.QuoteCurrencies)
+	priceGapUC := usecase.NewPriceGapUC(coinRepoPG, priceRepoDB,
+		repopg.NewPriceGapRepoPG(db), backfillUC, cfg.App.QuoteCurrencies,
+		cfg.App.PriceCollectInterval, cfg.App.GapMinDuration, cfg.App.GapDetectWindow)
+
+	gapDetector := NewWithUsecase(cfg, priceGapUC)
+	if gapDetector.autoBackfill && historyRepoAPI == nil {
+		logrus.Warn("Historical prices provider is not configured. Gaps are not backfilled")
+		gapDetector.autoBackfill = false
+	}
+	return gapDetector
+}
+
+// NewWithUsecase returns new price gap detector instance with given usecase.
+func NewWithUsecase(cfg *config.Config, priceGapUC usecase.PriceGapUsecase) *GapDetector {
+	return &GapDetector{
+		priceGapUC:   priceGapUC,
+		interval:     cfg.App.GapDetectInterval,
+		autoBackfill: cfg.App.GapAutoBackfill,
+	}
+}
+
+// StartWithShutdown starts price gap detector and waits for
+// context is done for gracefully shutdown detector.
+// This method is blocking.
+func (d *GapDetector) StartWithShutdown(ctx context.Context) error {
+	logrus.Info("Start price gap detector")
+	defer logrus.Info("Price gap detector is shutdown")
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.detect(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// detect detects new gaps and backfills detected gaps if
+// automatic backfill is enabled.
+func (d *GapDetector) detect(ctx context.Context) {
+	gapList, err := d.priceGapUC.DetectGaps()
+	if err != nil {
+		logrus.Errorf("Detect price gaps: %v", err)
+		return
+	}
+	for _, gap := range gapList {
+		logrus.Warnf("Price gap is detected: coin %s/%s from %d to %d", gap.Coin.Symbol,
+			gap.Currency, gap.FromTimestamp, gap.ToTimestamp)
+	}
+
+	if !d.autoBackfill {
+		return
+	}
+	repaired, err := d.priceGapUC.RepairGaps(ctx)
+	if errors.Is(err, usecase.ErrUnavailable) {
+		logrus.Warnf("Skip repair price gaps: %v", err)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Repair price gaps: %v", err)
+	}
+	if repaired != 0 {
+		logrus.Infof("%d price gaps are repaired", repaired)
+	}
+}
@@ -0,0 +1,56 @@
+package gapdetector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/entity"
+)
+
+// stubPriceGapUC is a price gaps usecase stub which counts repairs.
+type stubPriceGapUC struct {
+	detected entity.PriceGapList
+	repairs  int
+}
+
+func (u *stubPriceGapUC) DetectGaps() (entity.PriceGapList, error) {
+	return u.detected, nil
+}
+
+func (u *stubPriceGapUC) RepairGaps(_ context.Context) (int, error) {
+	u.repairs++
+	return len(u.detected), nil
+}
+
+func (u *stubPriceGapUC) GetGaps(_, _, _, _ string, _ int) (entity.PriceGapList, error) {
+	return u.detected, nil
+}
+
+func TestGapDetector_Detect(t *testing.T) {
+	t.Log("Repair detected gaps only if automatic backfill is enabled")
+
+	detected := entity.PriceGapList{{
+		Coin:          &entity.Coin{Symbol: "btc"},
+		Currency:      "usd",
+		FromTimestamp: 1754013600,
+		ToTimestamp:   1754020800,
+	}}
+	for _, autoBackfill := range []bool{false, true} {
+		uc := &stubPriceGapUC{detected: detected}
+		gapDetector := NewWithUsecase(&config.Config{App: config.App{
+			GapDetectInterval: time.Minute,
+			GapAutoBackfill:   autoBackfill,
+		}}, uc)
+
+		gapDetector.detect(context.Background())
+		if autoBackfill {
+			require.Equal(t, 1, uc.repairs)
+		} else {
+			require.Zero(t, uc.repairs)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Package leader provides leader election between service replicas
+// so that background services are run by one replica only.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+	repopg "CryptocoinPrice/internal/app/repo/pg"
+)
+
+var _ repo.LeaderStatusAPI = (*Elector)(nil)
+
+const _unlockTimeout = 5 * time.Second // max time to release leader lock
+
+// Service run by the leader replica only.
+type Service interface {
+	StartWithShutdown(ctx context.Context) error
+}
+
+// Elector campaigns for leadership and runs leader services
+// while the replica holds leader lock.
+type Elector struct {
+	lockDB   repo.LeaderLockDB
+	services []Service
+	// replica name for logs and status
+	instance string
+	// interval to try to acquire leader lock
+	retryInterval time.Duration
+	// interval to check that leader lock is still held
+	checkInterval time.Duration
+
+	mu          sync.Mutex
+	leaderSince time.Time
+}
+
+// New returns new leader elector with PostgreSQL advisory lock.
+// Given services are started when the replica becomes the leader
+// and stopped when leadership is lost.
+func New(cfg *config.Config, db *gorm.DB, services ...Service) *Elector {
+	return NewWithLock(cfg, repopg.NewLeaderLockPG(db, cfg.App.LeaderLockKey), services...)
+}
+
+// NewWithLock returns new leader elector with given leader lock.
+func NewWithLock(cfg *config.Config, lockDB repo.LeaderLockDB,
+	services ...Service) *Elector {
+
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+	return &Elector{
+		lockDB:        lockDB,
+		services:      services,
+		instance:      instance,
+		retryInterval: cfg.App.LeaderRetryInterval,
+		checkInterval: cfg.App.LeaderCheckInterval,
+	}
+}
+
+// StartWithShutdown starts leader election and waits for context is done
+// for gracefully shutdown leader services and release leadership.
+// It returns error if one of leader services fell down.
+// This method is blocking.
+func (e *Elector) StartWithShutdown(ctx context.Context) error {
+	logrus.WithField("instance", e.instance).Info("Start leader election")
+	defer logrus.WithField("instance", e.instance).Info("Leader election is shutdown")
+
+	ticker := time.NewTicker(e.retryInterval)
+	defer ticker.Stop()
+	for {
+		locked, err := e.lockDB.TryLock(ctx)
+		if err != nil && ctx.Err() == nil {
+			logrus.WithField("instance", e.instance).Errorf("Acquire leader lock: %v", err)
+		}
+		if locked {
+			if err := e.lead(ctx); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// LeaderStatus returns leader election status of the replica.
+func (e *Elector) LeaderStatus() entity.LeaderStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	status := entity.LeaderStatus{Instance: e.instance}
+	if !e.leaderSince.IsZero() {
+		status.Leader, status.LeaderSince = true, e.leaderSince.Unix()
+	}
+	return status
+}
+
+// lead runs leader services until context is done, leader lock is lost
+// or one of services fell down. Then services are stopped and lock is released.
+func (e *Elector) lead(ctx context.Context) error {
+	e.setLeader(true)
+	log := logrus.WithField("instance", e.instance)
+	log.Info("Leadership is acquired. Start leader services")
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup // nolint:varnamelen // generally accepted name
+	serviceErr := make(chan error, len(e.services))
+	for _, service := range e.services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := service.StartWithShutdown(leaderCtx); err != nil {
+				serviceErr <- err
+			}
+		}()
+	}
+
+	var err error
+	ticker := time.NewTicker(e.checkInterval)
+	defer ticker.Stop()
+loop:
+	for {
+		select {
+		case <-ticker.C:
+			if checkErr := e.lockDB.Check(ctx); checkErr != nil && ctx.Err() == nil {
+				log.Errorf("Leadership is lost: %v. Stop leader services", checkErr)
+				break loop
+			}
+		case serviceErr := <-serviceErr:
+			err = fmt.Errorf("leader service: %w", serviceErr)
+			break loop
+		case <-ctx.Done():
+			break loop
+		}
+	}
+
+	// stop services before release lock to not run them on two replicas
+	cancel()
+	wg.Wait()
+	unlockCtx, unlockCancel := context.WithTimeout(context.Background(), _unlockTimeout)
+	defer unlockCancel()
+	if unlockErr := e.lockDB.Unlock(unlockCtx); unlockErr != nil {
+		log.Warnf("Release leader lock: %v", unlockErr)
+	}
+	e.setLeader(false)
+	log.Info("Leadership is released")
+	return err
+}
+
+// setLeader sets whether the replica is the leader.
+func (e *Elector) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.leaderSince = time.Time{}
+	if leader {
+		e.leaderSince = time.Now().UTC()
+	}
+}
@@ -0,0 +1,129 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/config"
+)
+
+const _testWait = 2 * time.Second // max time to wait for leadership change
+
+// stubLock is a leader lock stub shared by replicas. The holder
+// connection can be broken to simulate died leader.
+type stubLock struct {
+	mu     sync.Mutex
+	holder *stubLockConn
+}
+
+// stubLockConn is a leader lock of one replica.
+type stubLockConn struct {
+	lock   *stubLock
+	broken atomic.Bool
+}
+
+func (c *stubLockConn) TryLock(ctx context.Context) (bool, error) {
+	if err := c.Check(ctx); err != nil {
+		return false, err
+	}
+	c.lock.mu.Lock()
+	defer c.lock.mu.Unlock()
+
+	if c.lock.holder == nil {
+		c.lock.holder = c
+	}
+	return c.lock.holder == c, nil
+}
+
+func (c *stubLockConn) Check(context.Context) error {
+	if c.broken.Load() {
+		return errors.New("connection is broken")
+	}
+	return nil
+}
+
+func (c *stubLockConn) Unlock(context.Context) error {
+	c.lock.mu.Lock()
+	defer c.lock.mu.Unlock()
+
+	if c.lock.holder == c {
+		c.lock.holder = nil
+	}
+	return nil
+}
+
+// stubService is a leader service counting its running instances.
+type stubService struct {
+	running *atomic.Int32
+}
+
+func (s *stubService) StartWithShutdown(ctx context.Context) error {
+	s.running.Add(1)
+	defer s.running.Add(-1)
+	<-ctx.Done()
+	return nil
+}
+
+func TestElector_StartWithShutdown(t *testing.T) {
+	t.Log("Run leader services on one replica and fail over when the leader dies")
+
+	cfg := &config.Config{App: config.App{
+		LeaderRetryInterval: 10 * time.Millisecond,
+		LeaderCheckInterval: 10 * time.Millisecond,
+	}}
+	lock := &stubLock{}
+	running := &atomic.Int32{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conns := make([]*stubLockConn, 0, 2)
+	electors := make([]*Elector, 0, 2)
+	stopped := make(chan error, 2)
+	for range 2 {
+		conn := &stubLockConn{lock: lock}
+		elector := NewWithLock(cfg, conn, &stubService{running: running})
+		conns, electors = append(conns, conn), append(electors, elector)
+		go func() {
+			stopped <- elector.StartWithShutdown(ctx)
+		}()
+	}
+
+	// leaderIdx returns index of the only leader replica or -1
+	leaderIdx := func() int {
+		idx := -1
+		for i, elector := range electors {
+			if elector.LeaderStatus().Leader {
+				if idx != -1 {
+					return -1
+				}
+				idx = i
+			}
+		}
+		return idx
+	}
+
+	require.Eventually(t, func() bool {
+		return leaderIdx() != -1 && running.Load() == 1
+	}, _testWait, 5*time.Millisecond)
+	firstLeader := leaderIdx()
+	require.Positive(t, electors[firstLeader].LeaderStatus().LeaderSince)
+
+	// leader connection is broken so the other replica takes leadership
+	conns[firstLeader].broken.Store(true)
+	require.Eventually(t, func() bool {
+		idx := leaderIdx()
+		return idx != -1 && idx != firstLeader && running.Load() == 1
+	}, _testWait, 5*time.Millisecond)
+	require.Zero(t, electors[firstLeader].LeaderStatus().LeaderSince)
+
+	cancel()
+	require.NoError(t, <-stopped)
+	require.NoError(t, <-stopped)
+	require.Zero(t, running.Load())
+}
@@ -2,70 +2,195 @@ package pricecollector
 
 import (
 	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 
 	"CryptocoinPrice/config"
-	repocoingecko "CryptocoinPrice/internal/app/repo/coingecko"
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
 	repopg "CryptocoinPrice/internal/app/repo/pg"
 	"CryptocoinPrice/internal/app/usecase"
 )
 
-// Price collector.
+// Price collector. It collects prices of each observed coin with
+// its own interval grouping coins which are due at the same tick.
+// Collections do not overlap: ticks are skipped while the previous
+// collection is running and its coins are collected at the next tick.
 type PriceCollector struct {
 	priceCollectorUC usecase.PriceCollectorUsecase
-	tickerInterval   time.Duration
+	// interval between price collections of coin without its own interval
+	defaultInterval time.Duration
+	// interval to check which coins are due to collect their prices
+	tickInterval time.Duration
+	// max duration of one collection
+	timeout time.Duration
+	// max random delay of collection after tick
+	jitter time.Duration
+	// time of the last collection which received prices of each observed coin by its ID
+	collectedAt map[string]time.Time
 }
 
 // New returns new price collector instance.
-func New(cfg *config.Config, db *gorm.DB) *PriceCollector {
+// Given price API repo is used to get new prices for observed coins
+// and given spool keeps prices which are failed to save (they are
+// replayed by spool replayer).
+func New(cfg *config.Config, db *gorm.DB, priceRepoAPI repo.PriceRepoAPI,
+	spoolRepo repo.PriceSpoolRepo) *PriceCollector {
+
 	// create repos
 	coinRepoPG := repopg.NewCoinRepoPG(db)
 	priceRepoDB := repopg.NewPriceRepoPG(db)
-	priceRepoCoingecko := repocoingecko.NewPriceRepoCoingecko(cfg.App.CoingeckoAPIKey)
 	// create usecases
-	priceCollectorUC := usecase.NewPriceCollectorUC(coinRepoPG, priceRepoDB, priceRepoCoingecko)
+	priceCollectorUC := usecase.NewPriceCollectorUC(coinRepoPG, priceRepoDB, priceRepoAPI,
+		spoolRepo, cfg.App.QuoteCurrencies, cfg.App.CoinFailingThreshold)
+
+	return NewWithUsecase(cfg, priceCollectorUC)
+}
+
+// NewWithUsecase returns new price collector instance with given usecase.
+func NewWithUsecase(cfg *config.Config,
+	priceCollectorUC usecase.PriceCollectorUsecase) *PriceCollector {
 
 	return &PriceCollector{
 		priceCollectorUC: priceCollectorUC,
-		tickerInterval:   cfg.App.PriceCollectInterval,
+		defaultInterval:  cfg.App.PriceCollectInterval,
+		tickInterval:     cfg.App.PriceScheduleTick,
+		timeout:          cfg.App.PriceCollectTimeout,
+		jitter:           cfg.App.PriceCollectJitter,
+		collectedAt:      make(map[string]time.Time),
 	}
 }
 
 // StartWithShutdown starts price collector and waits for
 // context is done for gracefully shutdown collector.
+// Collection in progress is canceled on shutdown.
 // This method is blocking.
 func (p *PriceCollector) StartWithShutdown(ctx context.Context) error {
 	logrus.Info("Start price collector")
 	defer logrus.Info("Price collector is shutdown")
 
-	ticker := time.NewTicker(p.tickerInterval)
+	// running collection (buffer size is max amount of collections at a time)
+	running := make(chan struct{}, 1)
+	var wg sync.WaitGroup // nolint:varnamelen // generally accepted name
+	defer wg.Wait()
+
+	ticker := time.NewTicker(p.tickInterval)
 	defer ticker.Stop()
 	for {
 		select {
-		case <-ticker.C:
-			p.collect()
+		case now := <-ticker.C:
+			select {
+			case running <- struct{}{}:
+			default:
+				logrus.Warn("Skip price collector tick: previous collection is still running")
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-running }()
+				p.collect(ctx, now)
+			}()
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
-// collect collects new observed coin prices and saves them.
-func (p *PriceCollector) collect() {
+// collect collects new prices of observed coins due at given time and saves
+// them. Collection is delayed by random jitter and is bounded by timeout.
+// Prices received before timeout or cancel are saved. Saving is bounded
+// by timeout too and is canceled on shutdown (then prices are spooled).
+func (p *PriceCollector) collect(ctx context.Context, now time.Time) {
+	// spread requests to providers
+	if p.jitter > 0 {
+		select {
+		case <-time.After(rand.N(p.jitter)): // nolint:gosec // jitter
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	dueCoins := p.dueCoins(now)
+	// skip if no one coin is due
+	if len(dueCoins) == 0 {
+		return
+	}
 	// get new prices
-	newPrices, err := p.priceCollectorUC.GetNewObservedCoinPrices()
-	if err != nil {
+	collectCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	newPrices, received, err := p.priceCollectorUC.GetNewCoinPrices(collectCtx, dueCoins)
+	// coins whose prices are not received (e.g. failed or canceled)
+	// are due again at the next tick
+	p.markCollected(received, now)
+	// skip collection until API quota is renewed
+	// or providers circuit breakers are half-open
+	if errors.Is(err, repo.ErrQuotaExhausted) || errors.Is(err, repo.ErrCircuitOpen) {
+		logrus.Warnf("Skip background collect prices: %v", err)
+		return
+	}
+	switch {
+	case err == nil:
+	// collection is canceled on shutdown
+	case ctx.Err() != nil:
+		logrus.Infof("Background collect prices is canceled: %v", err)
+	case errors.Is(collectCtx.Err(), context.DeadlineExceeded):
+		logrus.Errorf("Background collect prices: timeout %s is exceeded: %v", p.timeout, err)
+	default:
 		logrus.Errorf("Background collect prices: %v", err)
 	}
-	// skip if no one coin is observed
+	// prices received before error (e.g. timeout) are saved too
+	// skip if no one price is new
 	if len(newPrices) == 0 {
 		return
 	}
-	// save new prices
-	if _, err := p.priceCollectorUC.SaveCoinPrices(newPrices); err != nil {
+	// save new prices (collection context may be already expired)
+	saveCtx, saveCancel := context.WithTimeout(ctx, p.timeout)
+	defer saveCancel()
+	if _, err := p.priceCollectorUC.SaveCoinPrices(saveCtx, newPrices); err != nil {
 		logrus.Errorf("Background save collected prices: %v", err)
 	}
 }
+
+// dueCoins returns observed coins whose collection interval is passed since
+// their last collection. Coins due in less than half of tick are returned
+// too to not shift them by the whole tick.
+func (p *PriceCollector) dueCoins(now time.Time) entity.CoinList {
+	observedCoins, err := p.priceCollectorUC.GetObservedCoins()
+	if err != nil {
+		logrus.Errorf("Schedule background collect prices: %v", err)
+		return nil
+	}
+
+	// coins which are not observed any more are forgotten
+	collectedAt := make(map[string]time.Time, len(observedCoins))
+	dueCoins := make(entity.CoinList, 0, len(observedCoins))
+	for _, coin := range observedCoins {
+		interval := p.defaultInterval
+		if coin.CollectInterval > 0 {
+			interval = time.Duration(coin.CollectInterval) * time.Second
+		}
+		lastCollected, found := p.collectedAt[coin.ID]
+		if found {
+			collectedAt[coin.ID] = lastCollected
+		}
+		if found && now.Add(p.tickInterval/2).Sub(lastCollected) < interval { // nolint:mnd // half
+			continue
+		}
+		dueCoins = append(dueCoins, coin)
+	}
+	p.collectedAt = collectedAt
+	return dueCoins
+}
+
+// markCollected marks given coins whose prices are received as collected at given time.
+func (p *PriceCollector) markCollected(coins entity.CoinList, collectedAt time.Time) {
+	for _, coin := range coins {
+		p.collectedAt[coin.ID] = collectedAt
+	}
+}
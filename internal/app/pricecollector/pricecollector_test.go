@@ -0,0 +1,216 @@
+package pricecollector
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/entity"
+)
+
+// stubPriceCollectorUC is a price collector usecase stub which records requested coins.
+// Prices of all requested coins apart of failing ones are received.
+// If blocking is set, getting new prices is blocked until context is done,
+// then partial prices are returned (as usecase does) if they are set.
+type stubPriceCollectorUC struct {
+	mu        sync.Mutex
+	coins     entity.CoinList
+	failing   map[string]bool
+	requested []entity.CoinList
+	blocking  bool
+	partial   entity.PriceList
+	saved     entity.PriceList
+	// errors of contexts of finished blocked requests
+	ctxErrs []error
+}
+
+func (u *stubPriceCollectorUC) GetObservedCoins() (entity.CoinList, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append(entity.CoinList{}, u.coins...), nil
+}
+
+func (u *stubPriceCollectorUC) GetNewCoinPrices(ctx context.Context,
+	coins entity.CoinList) (entity.PriceList, entity.CoinList, error) {
+
+	u.mu.Lock()
+	u.requested = append(u.requested, coins)
+	u.mu.Unlock()
+	if !u.blocking {
+		received := make(entity.CoinList, 0, len(coins))
+		for _, coin := range coins {
+			if !u.failing[coin.Symbol] {
+				received = append(received, coin)
+			}
+		}
+		return entity.PriceList{}, received, nil
+	}
+
+	<-ctx.Done()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.ctxErrs = append(u.ctxErrs, ctx.Err())
+	if u.partial != nil {
+		received := make(entity.CoinList, 0, len(coins))
+		for _, coin := range coins {
+			for _, price := range u.partial {
+				if price.CoinID == coin.ID {
+					received = append(received, coin)
+					break
+				}
+			}
+		}
+		return u.partial, received, nil
+	}
+	return nil, nil, ctx.Err()
+}
+
+// requestedSymbols returns symbols of requested coins of each collection.
+func (u *stubPriceCollectorUC) requestedSymbols() [][]string {
+	symbols := make([][]string, 0, len(u.requested))
+	for _, coins := range u.requested {
+		tickSymbols := make([]string, 0, len(coins))
+		for _, coin := range coins {
+			tickSymbols = append(tickSymbols, coin.Symbol)
+		}
+		symbols = append(symbols, tickSymbols)
+	}
+	return symbols
+}
+
+func (u *stubPriceCollectorUC) requestedCount() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.requested)
+}
+
+func (u *stubPriceCollectorUC) SaveCoinPrices(_ context.Context,
+	priceList entity.PriceList) (entity.PriceList, error) {
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.saved = append(u.saved, priceList...)
+	return priceList, nil
+}
+
+func TestPriceCollector_Collect(t *testing.T) {
+	t.Log("Collect prices of due coins grouped by tick")
+
+	uc := &stubPriceCollectorUC{coins: entity.CoinList{
+		{ID: "1", Symbol: "btc", CollectInterval: 2},
+		{ID: "2", Symbol: "eth"},
+		{ID: "3", Symbol: "ton", CollectInterval: 3},
+	}}
+	priceCollector := NewWithUsecase(&config.Config{App: config.App{
+		PriceCollectInterval: 5 * time.Second,
+		PriceScheduleTick:    time.Second,
+		PriceCollectTimeout:  time.Second,
+	}}, uc)
+
+	start := time.Unix(1754050754, 0)
+	for tick := range 7 {
+		// ticks are slightly late
+		delay := time.Duration(tick) * time.Millisecond
+		now := start.Add(time.Duration(tick)*time.Second + delay)
+		priceCollector.collect(context.Background(), now)
+	}
+
+	require.Equal(t, [][]string{
+		{"btc", "eth", "ton"}, // 0s
+		{"btc"},               // 2s
+		{"ton"},               // 3s
+		{"btc"},               // 4s
+		{"eth"},               // 5s
+		{"btc", "ton"},        // 6s
+	}, uc.requestedSymbols())
+}
+
+func TestPriceCollector_CollectFailed(t *testing.T) {
+	t.Log("Collect prices of coin at the next tick if its prices are not received")
+
+	uc := &stubPriceCollectorUC{
+		coins:   entity.CoinList{{ID: "1", Symbol: "btc"}, {ID: "2", Symbol: "eth"}},
+		failing: map[string]bool{"eth": true},
+	}
+	priceCollector := NewWithUsecase(&config.Config{App: config.App{
+		PriceCollectInterval: 5 * time.Second,
+		PriceScheduleTick:    time.Second,
+		PriceCollectTimeout:  time.Second,
+	}}, uc)
+
+	start := time.Unix(1754050754, 0)
+	for tick := range 3 {
+		if tick == 2 {
+			uc.failing = nil
+		}
+		priceCollector.collect(context.Background(), start.Add(time.Duration(tick)*time.Second))
+	}
+
+	require.Equal(t, [][]string{
+		{"btc", "eth"}, // 0s
+		{"eth"},        // 1s
+		{"eth"},        // 2s
+	}, uc.requestedSymbols())
+	// eth is collected at 2s
+	require.Equal(t, start.Add(2*time.Second), priceCollector.collectedAt["2"])
+}
+
+func TestPriceCollector_StartWithShutdown(t *testing.T) {
+	t.Log("Skip ticks while collection is running and cancel it on shutdown")
+
+	uc := &stubPriceCollectorUC{
+		coins:    entity.CoinList{{ID: "1", Symbol: "btc", CollectInterval: 1}},
+		blocking: true,
+	}
+	priceCollector := NewWithUsecase(&config.Config{App: config.App{
+		PriceCollectInterval: time.Second,
+		PriceScheduleTick:    10 * time.Millisecond,
+		PriceCollectTimeout:  time.Minute,
+		PriceCollectJitter:   5 * time.Millisecond,
+	}}, uc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- priceCollector.StartWithShutdown(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return uc.requestedCount() == 1
+	}, time.Second, 5*time.Millisecond)
+	// coin is due again but collection is still running
+	time.Sleep(1200 * time.Millisecond)
+	require.Equal(t, 1, uc.requestedCount())
+
+	cancel()
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.FailNow(t, "price collector is not shutdown")
+	}
+	require.Equal(t, []error{context.Canceled}, uc.ctxErrs)
+}
+
+func TestPriceCollector_CollectTimeout(t *testing.T) {
+	t.Log("Save prices received before collection timeout")
+
+	uc := &stubPriceCollectorUC{
+		coins:    entity.CoinList{{ID: "1", Symbol: "btc"}, {ID: "2", Symbol: "eth"}},
+		blocking: true,
+		partial:  entity.PriceList{{CoinID: "1", Currency: "usd", Timestamp: 1754050754}},
+	}
+	priceCollector := NewWithUsecase(&config.Config{App: config.App{
+		PriceCollectInterval: time.Second,
+		PriceScheduleTick:    time.Second,
+		PriceCollectTimeout:  10 * time.Millisecond,
+	}}, uc)
+
+	priceCollector.collect(context.Background(), time.Unix(1754050754, 0))
+	require.Equal(t, []error{context.DeadlineExceeded}, uc.ctxErrs)
+	require.Equal(t, uc.partial, uc.saved)
+}
@@ -0,0 +1,203 @@
+// Package pricestream provides service to collect coin prices
+// streamed by exchange over WebSocket.
+package pricestream
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+	repopg "CryptocoinPrice/internal/app/repo/pg"
+	"CryptocoinPrice/internal/app/usecase"
+)
+
+// Price stream collector.
+type PriceStream struct {
+	priceStreamUC usecase.PriceStreamUsecase
+	streamAPI     repo.PriceStreamAPI
+	currencies    []string
+	// interval to save the latest ticks
+	flushInterval time.Duration
+	// interval to refresh observed coins and resubscribe
+	resubscribeInterval time.Duration
+	// min and max time to wait before reconnect
+	reconnectMinWait time.Duration
+	reconnectMaxWait time.Duration
+}
+
+// New returns new price stream collector instance.
+// Given price stream API is used to stream prices of observed coins.
+func New(cfg *config.Config, db *gorm.DB, streamAPI repo.PriceStreamAPI) *PriceStream {
+	// create usecases
+	priceStreamUC := usecase.NewPriceStreamUC(repopg.NewCoinRepoPG(db), repopg.NewPriceRepoPG(db))
+	return NewWithUsecase(cfg, priceStreamUC, streamAPI)
+}
+
+// NewWithUsecase returns new price stream collector instance with given usecase.
+func NewWithUsecase(cfg *config.Config, priceStreamUC usecase.PriceStreamUsecase,
+	streamAPI repo.PriceStreamAPI) *PriceStream {
+
+	return &PriceStream{
+		priceStreamUC:       priceStreamUC,
+		streamAPI:           streamAPI,
+		currencies:          cfg.App.QuoteCurrencies,
+		flushInterval:       cfg.App.PriceStreamThrottle,
+		resubscribeInterval: cfg.App.PriceStreamResubscribeInterval,
+		reconnectMinWait:    cfg.App.PriceStreamReconnectMinWait,
+		reconnectMaxWait:    cfg.App.PriceStreamReconnectMaxWait,
+	}
+}
+
+// StartWithShutdown starts price stream collector and waits for
+// context is done for gracefully shutdown collector. Stream is
+// reconnected with exponential backoff if connection is lost.
+// This method is blocking.
+func (p *PriceStream) StartWithShutdown(ctx context.Context) error {
+	logrus.Info("Start price stream collector")
+	defer logrus.Info("Price stream collector is shutdown")
+
+	wait := p.reconnectMinWait
+	for {
+		received, err := p.stream(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		// reset backoff if connection was working
+		if received {
+			wait = p.reconnectMinWait
+		}
+		logrus.Warnf("Price stream is disconnected: %v. Reconnect in %s", err, wait)
+
+		select {
+		case <-time.After(wait):
+			wait = min(wait*2, p.reconnectMaxWait)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// stream connects to stream, subscribes to observed coins prices and saves
+// them until context is done or connection is lost. It returns true if at
+// least one price is received.
+func (p *PriceStream) stream(ctx context.Context) (received bool, err error) {
+	conn, err := p.streamAPI.Dial(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	logrus.Info("Price stream is connected")
+
+	// subscribed coins by symbol
+	subscribed := make(map[string]entity.Coin)
+	if err := p.resubscribe(conn, subscribed); err != nil {
+		return false, err
+	}
+
+	// read ticks until connection is closed
+	ticks := make(chan *entity.CoinPriceAPI)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			tick, err := conn.Read()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case ticks <- tick:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	flushTicker := time.NewTicker(p.flushInterval)
+	defer flushTicker.Stop()
+	resubscribeTicker := time.NewTicker(p.resubscribeInterval)
+	defer resubscribeTicker.Stop()
+	// save ticks received before disconnect
+	defer p.flush()
+
+	for {
+		select {
+		case tick := <-ticks:
+			received = true
+			p.priceStreamUC.AddTick(tick)
+		case <-flushTicker.C:
+			p.flush()
+		case <-resubscribeTicker.C:
+			if err := p.resubscribe(conn, subscribed); err != nil {
+				return received, err
+			}
+		case err := <-readErr:
+			return received, err
+		case <-ctx.Done():
+			return received, nil
+		}
+	}
+}
+
+// resubscribe subscribes to newly observed coins and unsubscribes from
+// coins which are not observed any more. Subscribed coins are updated.
+// If observed coins are not received the subscription is kept.
+func (p *PriceStream) resubscribe(conn repo.PriceStreamConn,
+	subscribed map[string]entity.Coin) error {
+
+	observedCoins, err := p.priceStreamUC.GetObservedCoins()
+	if err != nil {
+		logrus.Errorf("Price stream resubscribe: %v", err)
+		return nil
+	}
+
+	observed := make(map[string]struct{}, len(observedCoins))
+	added := make(entity.CoinList, 0)
+	for _, coin := range observedCoins {
+		observed[coin.Symbol] = struct{}{}
+		if _, found := subscribed[coin.Symbol]; !found {
+			added = append(added, coin)
+		}
+	}
+	removed := make(entity.CoinList, 0)
+	for symbol, coin := range subscribed {
+		if _, found := observed[symbol]; !found {
+			removed = append(removed, coin)
+		}
+	}
+
+	if err := conn.Unsubscribe(removed, p.currencies); err != nil {
+		return fmt.Errorf("unsubscribe: %w", err)
+	}
+	for _, coin := range removed {
+		delete(subscribed, coin.Symbol)
+	}
+	if err := conn.Subscribe(added, p.currencies); err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+	for _, coin := range added {
+		subscribed[coin.Symbol] = coin
+	}
+	if len(added) != 0 || len(removed) != 0 {
+		logrus.Infof("Price stream is resubscribed: %d coins added, %d coins removed",
+			len(added), len(removed))
+	}
+	return nil
+}
+
+// flush saves the latest received ticks. Saving is bounded by flush interval
+// and is not canceled on shutdown to save ticks received before it.
+func (p *PriceStream) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.flushInterval)
+	defer cancel()
+	if _, err := p.priceStreamUC.FlushTicks(ctx); err != nil {
+		logrus.Errorf("Save streamed prices: %v", err)
+	}
+}
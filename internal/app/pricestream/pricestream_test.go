@@ -0,0 +1,183 @@
+package pricestream
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo/binance"
+)
+
+const _testWait = 2 * time.Second // max time to wait for stream event
+
+// streamRequest is a (un)subscribe request received by stream stand-in.
+type streamRequest struct {
+	// number of connection the request is received by
+	conn   int32
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int      `json:"id"`
+}
+
+// stubPriceStreamUC is a price stream usecase stub with mutable observed coins.
+type stubPriceStreamUC struct {
+	mu      sync.Mutex
+	coins   entity.CoinList
+	flushed entity.CoinPriceAPIList
+	pending entity.CoinPriceAPIList
+}
+
+func (u *stubPriceStreamUC) GetObservedCoins() (entity.CoinList, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append(entity.CoinList{}, u.coins...), nil
+}
+
+func (u *stubPriceStreamUC) AddTick(tick *entity.CoinPriceAPI) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.pending = append(u.pending, *tick)
+}
+
+func (u *stubPriceStreamUC) FlushTicks(_ context.Context) (entity.PriceList, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.flushed = append(u.flushed, u.pending...)
+	u.pending = nil
+	return entity.PriceList{}, nil
+}
+
+func (u *stubPriceStreamUC) setCoins(coins entity.CoinList) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.coins = coins
+}
+
+func (u *stubPriceStreamUC) flushedTicks() entity.CoinPriceAPIList {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append(entity.CoinPriceAPIList{}, u.flushed...)
+}
+
+func TestPriceStream_StartWithShutdown(t *testing.T) {
+	t.Log("Stream prices, reconnect after disconnect and resubscribe on observed coins change")
+
+	server, requests := newStreamServer(t)
+	uc := &stubPriceStreamUC{coins: entity.CoinList{{ID: "1", Symbol: "btc"}}}
+	cfg := &config.Config{App: config.App{
+		QuoteCurrencies:                []string{"usd"},
+		PriceStreamThrottle:            10 * time.Millisecond,
+		PriceStreamResubscribeInterval: 20 * time.Millisecond,
+		PriceStreamReconnectMinWait:    10 * time.Millisecond,
+		PriceStreamReconnectMaxWait:    50 * time.Millisecond,
+	}}
+	streamURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	priceStream := NewWithUsecase(cfg, uc, binance.NewPriceStreamBinance(streamURL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- priceStream.StartWithShutdown(ctx)
+	}()
+
+	// first connection is dropped by server after the first tick
+	req := nextRequest(t, requests)
+	require.Equal(t, streamRequest{conn: 1, Method: "SUBSCRIBE",
+		Params: []string{"btcusdt@miniTicker"}, ID: 1}, req)
+	// coin is subscribed again after reconnect
+	req = nextRequest(t, requests)
+	require.Equal(t, int32(2), req.conn)
+	require.Equal(t, []string{"btcusdt@miniTicker"}, req.Params)
+
+	// observed coins are changed
+	uc.setCoins(entity.CoinList{{ID: "2", Symbol: "eth"}})
+	req = nextRequest(t, requests)
+	require.Equal(t, "UNSUBSCRIBE", req.Method)
+	require.Equal(t, []string{"btcusdt@miniTicker"}, req.Params)
+	req = nextRequest(t, requests)
+	require.Equal(t, "SUBSCRIBE", req.Method)
+	require.Equal(t, []string{"ethusdt@miniTicker"}, req.Params)
+
+	require.Eventually(t, func() bool {
+		return len(uc.flushedTicks()) == 3
+	}, _testWait, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-stopped)
+
+	ticks := uc.flushedTicks()
+	require.Equal(t, entity.CoinPriceAPI{Symbol: "btc", Price: decimal.RequireFromString("115380.01"), Currency: "usd",
+		LastUpdate: 1754050754, Source: binance.ProviderName}, ticks[0])
+	require.Equal(t, "eth", ticks[2].Symbol)
+	t.Logf("Streamed ticks: %+v", ticks)
+}
+
+// newStreamServer returns Binance WebSocket streams stand-in and channel
+// with received (un)subscribe requests. The server sends a tick for each
+// subscribed stream and drops the first connection after its ticks.
+func newStreamServer(t *testing.T) (*httptest.Server, <-chan streamRequest) {
+	t.Helper()
+
+	prices := map[string]string{"BTCUSDT": "115380.01", "ETHUSDT": "3647.54"}
+	upgrader := websocket.Upgrader{}
+	requests := make(chan streamRequest, 10) // nolint:mnd // buffer for requests
+	conns := &atomic.Int32{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		connNumber := conns.Add(1)
+
+		for {
+			var req streamRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			req.conn = connNumber
+			requests <- req
+			_ = conn.WriteJSON(map[string]any{"result": nil, "id": req.ID})
+			if req.Method != "SUBSCRIBE" {
+				continue
+			}
+
+			for _, param := range req.Params {
+				pair := strings.ToUpper(strings.TrimSuffix(param, "@miniTicker"))
+				_ = conn.WriteMessage(websocket.TextMessage, fmt.Appendf(nil,
+					`{"e":"24hrMiniTicker","E":1754050754123,"s":%q,"c":%q}`,
+					pair, prices[pair]))
+			}
+			if connNumber == 1 {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// nextRequest returns next (un)subscribe request received by stream stand-in.
+func nextRequest(t *testing.T, requests <-chan streamRequest) streamRequest {
+	t.Helper()
+
+	select {
+	case req := <-requests:
+		return req
+	case <-time.After(_testWait):
+		require.FailNow(t, "stream request is not received")
+		return streamRequest{}
+	}
+}
@@ -0,0 +1,218 @@
+// Package binance contains Binance API repos implementations for entities.
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	resty "github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ repo.PriceRepoAPI = (*PriceRepoBinance)(nil)
+
+const (
+	ProviderName   = "binance"                 // provider name used in config
+	DefaultBaseURL = "https://api.binance.com" // Binance REST API base URL
+
+	_requestTimeout = 2 * time.Second        // timeout for requests to API
+	_retryCount     = 3                      // amount of retries attempts in error cases
+	_retryInitTime  = 500 * time.Millisecond // time between first request and first retry
+	_retryMaxTime   = 2 * time.Second        // max time between request and retry
+
+	_tickerPricePath = "/api/v3/ticker/price" // path of the symbol price ticker endpoint
+)
+
+// _quoteAssets maps quote currencies into Binance quote assets if they differ.
+// Binance has no USD spot pairs so USDT is used instead.
+var _quoteAssets = map[string]string{
+	"usd": "USDT",
+}
+
+// tickerPrice is a raw symbol price ticker from API.
+type tickerPrice struct {
+	// trading pair (e.g. BTCUSDT)
+	Symbol string `json:"symbol"`
+	// last price as a decimal string
+	Price string `json:"price"`
+}
+
+type PriceRepoBinance struct {
+	client *resty.Client
+}
+
+// NewPriceRepoBinance returns new Binance API repo instance for price entity.
+// The baseURL is a Binance REST API base URL (see DefaultBaseURL).
+func NewPriceRepoBinance(baseURL string) *PriceRepoBinance {
+	// init HTTP-client with retry params
+	restyClient := resty.New().
+		SetBaseURL(baseURL).
+		SetTimeout(_requestTimeout).
+		SetRetryCount(_retryCount).
+		SetRetryWaitTime(_retryInitTime).
+		SetRetryMaxWaitTime(_retryMaxTime)
+
+	return &PriceRepoBinance{
+		client: restyClient,
+	}
+}
+
+// OneCoinPrice sends request to API for
+// one coin price and returns it. The coin is requested by its symbol.
+// The price is in the given quote currency (USD is treated as USDT).
+// API response looks like:
+//
+//	{
+//	  "symbol": "BTCUSDT",
+//	  "price": "115380.01000000"
+//	}
+func (r *PriceRepoBinance) OneCoinPrice(ctx context.Context, coin *entity.Coin,
+	currency string) (*entity.CoinPriceAPI, error) {
+
+	rawData := &tickerPrice{}
+
+	// do request to REST API and parse JSON-response into result
+	resp, err := r.client.R().
+		SetContext(ctx).
+		SetResult(rawData).
+		SetHeader("Accept", "application/json").
+		SetQueryParam("symbol", tradingPair(coin.Symbol, currency)).
+		Get(_tickerPricePath)
+	if err != nil {
+		return nil, fmt.Errorf("request to api: %w", err)
+	}
+	// unknown trading pair
+	if resp.StatusCode() == http.StatusBadRequest {
+		return nil, fmt.Errorf("%w: coin %s in %s is not found",
+			repo.ErrValidateData, coin.Symbol, currency)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("request to api: unexpected status %s", resp.Status())
+	}
+
+	// parse coin data into struct
+	coinData, err := parseTickerPrice(rawData, coin, currency)
+	if err != nil {
+		return nil, fmt.Errorf("parse coin data: %w", err)
+	}
+	return coinData, nil
+}
+
+// ManyCoinPrices sends request to API for
+// many coins' prices and returns them. The coins are requested by its symbols.
+// Each coin price is returned in each of given quote currencies.
+// Coins sharing the same symbol are reported as not found
+// because their trading pair can not be bound to one of them.
+// It requests all tickers because Binance rejects the whole
+// request if at least one of the given trading pairs is unknown.
+// API response looks like:
+//
+//	[
+//	  {
+//	    "symbol": "BTCUSDT",
+//	    "price": "115380.01000000"
+//	  },
+//	  {
+//	    "symbol": "ETHUSDT",
+//	    "price": "3647.54000000"
+//	  }
+//	]
+func (r *PriceRepoBinance) ManyCoinPrices(ctx context.Context, coins entity.CoinList,
+	currencies []string) (entity.CoinPriceAPIList, error) {
+
+	var rawData []tickerPrice
+
+	// do request to REST API and parse JSON-response into result
+	resp, err := r.client.R().
+		SetContext(ctx).
+		SetResult(&rawData).
+		SetHeader("Accept", "application/json").
+		Get(_tickerPricePath)
+	if err != nil {
+		return nil, fmt.Errorf("request to api: %w", err)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("request to api: unexpected status %s", resp.Status())
+	}
+
+	// index tickers by trading pair
+	tickers := make(map[string]*tickerPrice, len(rawData))
+	for i := range rawData {
+		tickers[rawData[i].Symbol] = &rawData[i]
+	}
+
+	pricesAmount := len(coins) * len(currencies)
+	coinPricesList := make(entity.CoinPriceAPIList, 0, pricesAmount)
+	errList := make([]*repo.CoinPriceError, 0)
+	// trading pair of symbol shared by many coins is not bound to one of them
+	sharedSymbols := repo.SharedSymbols(coins)
+	// parse each coin in each currency
+	for i := range coins {
+		coin := &coins[i]
+		for _, currency := range currencies {
+			coinErr := &repo.CoinPriceError{
+				CoinID: coin.ID, Symbol: coin.Symbol, Currency: currency,
+			}
+			if _, shared := sharedSymbols[coin.Symbol]; shared {
+				coinErr.Err = fmt.Errorf("%w: coin symbol is shared by many coins",
+					repo.ErrValidateData)
+				errList = append(errList, coinErr)
+				continue
+			}
+			ticker, found := tickers[tradingPair(coin.Symbol, currency)]
+			if !found {
+				coinErr.Err = fmt.Errorf("%w: coin is not found", repo.ErrValidateData)
+				errList = append(errList, coinErr)
+				continue
+			}
+			coinData, err := parseTickerPrice(ticker, coin, currency)
+			if err != nil {
+				coinErr.Err = err
+				errList = append(errList, coinErr)
+				continue
+			}
+			coinPricesList = append(coinPricesList, *coinData)
+		}
+	}
+
+	logrus.Infof("Get coin prices from %s: %d/%d", ProviderName,
+		pricesAmount-len(errList), pricesAmount)
+	return coinPricesList, repo.NewCoinPricesError(errList)
+}
+
+// tradingPair returns Binance trading pair for given coin symbol and quote currency.
+func tradingPair(symbol, currency string) string {
+	quoteAsset, found := _quoteAssets[currency]
+	if !found {
+		quoteAsset = strings.ToUpper(currency)
+	}
+	return strings.ToUpper(symbol) + quoteAsset
+}
+
+// parseTickerPrice parses raw ticker from API into coin price struct.
+// Binance does not return last update time for ticker
+// so the current time is used.
+func parseTickerPrice(ticker *tickerPrice, coin *entity.Coin,
+	currency string) (*entity.CoinPriceAPI, error) {
+
+	price, err := decimal.NewFromString(ticker.Price)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid coin price: coin data - %+v",
+			repo.ErrMalformedData, *ticker)
+	}
+	return &entity.CoinPriceAPI{
+		CoinID:     coin.ID,
+		Symbol:     coin.Symbol,
+		Price:      price,
+		Currency:   currency,
+		LastUpdate: time.Now().UTC().Unix(),
+		Source:     ProviderName,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+// newTestServer returns Binance API stand-in with BTC and ETH tickers.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("symbol") {
+		case "":
+			_, _ = w.Write([]byte(`[
+				{"symbol": "BTCUSDT", "price": "115380.01000000"},
+				{"symbol": "ETHUSDT", "price": "3647.54000000"},
+				{"symbol": "ETHBTC", "price": "0.03160000"}
+			]`))
+		case "BTCUSDT":
+			_, _ = w.Write([]byte(`{"symbol": "BTCUSDT", "price": "115380.01000000"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code": -1121, "msg": "Invalid symbol."}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPriceRepoBinance_OneCoinPrice(t *testing.T) {
+	t.Log("Get coin price from API")
+
+	priceRepo := NewPriceRepoBinance(newTestServer(t).URL)
+	coinPrice, err := priceRepo.OneCoinPrice(context.Background(), &entity.Coin{Symbol: "btc"}, "usd")
+	require.NoError(t, err)
+	require.Equal(t, "btc", coinPrice.Symbol)
+	require.Equal(t, "usd", coinPrice.Currency)
+	require.Equal(t, "115380.01", coinPrice.Price.String())
+
+	t.Logf("Coin price: %+v", coinPrice)
+}
+
+func TestPriceRepoBinance_OneCoinPriceUnexisting(t *testing.T) {
+	t.Log("Get unexisting coin price from API")
+
+	priceRepo := NewPriceRepoBinance(newTestServer(t).URL)
+	_, err := priceRepo.OneCoinPrice(context.Background(), &entity.Coin{Symbol: "unexisting"}, "usd")
+	require.ErrorIs(t, err, repo.ErrValidateData)
+}
+
+func TestPriceRepoBinance_ManyCoinPrices(t *testing.T) {
+	t.Log("Get coins' prices from API with one unexisting coin")
+
+	priceRepo := NewPriceRepoBinance(newTestServer(t).URL)
+	coinPricesList, err := priceRepo.ManyCoinPrices(context.Background(),
+		entity.CoinList{{Symbol: "btc"}, {Symbol: "eth"}, {Symbol: "unexisting"}}, []string{"usd"})
+	require.Error(t, err)
+	require.Len(t, coinPricesList, 2)
+	require.Equal(t, "eth", coinPricesList[1].Symbol)
+	require.Equal(t, "3647.54", coinPricesList[1].Price.String())
+
+	t.Logf("Coins' prices: %+v", coinPricesList)
+}
+
+func TestPriceRepoBinance_ManyCoinPricesCurrencies(t *testing.T) {
+	t.Log("Get coin prices in many quote currencies from API")
+
+	priceRepo := NewPriceRepoBinance(newTestServer(t).URL)
+	coinPricesList, err := priceRepo.ManyCoinPrices(context.Background(),
+		entity.CoinList{{Symbol: "eth"}}, []string{"usd", "btc"})
+	require.NoError(t, err)
+	require.Len(t, coinPricesList, 2)
+	require.Equal(t, "btc", coinPricesList[1].Currency)
+	require.Equal(t, "0.0316", coinPricesList[1].Price.String())
+}
+
+func TestPriceRepoBinance_ManyCoinPricesSharedSymbol(t *testing.T) {
+	t.Log("Get prices of coins sharing the same symbol from API")
+
+	priceRepo := NewPriceRepoBinance(newTestServer(t).URL)
+	coinPricesList, err := priceRepo.ManyCoinPrices(context.Background(), entity.CoinList{
+		{ID: "btc-id", Symbol: "btc"},
+		{ID: "eth-id", Symbol: "eth", ExternalID: "ethereum"},
+		{ID: "other-eth-id", Symbol: "eth", ExternalID: "other-eth"},
+	}, []string{"usd"})
+	require.Len(t, coinPricesList, 1)
+	require.Equal(t, "btc-id", coinPricesList[0].CoinID)
+
+	var pricesErr *repo.CoinPricesError
+	require.ErrorAs(t, err, &pricesErr)
+	require.Len(t, pricesErr.Errs, 2)
+	require.Equal(t, "other-eth-id", pricesErr.Errs[1].CoinID)
+	require.ErrorIs(t, pricesErr.Errs[1], repo.ErrValidateData)
+}
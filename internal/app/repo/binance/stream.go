@@ -0,0 +1,233 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var (
+	_ repo.PriceStreamAPI  = (*PriceStreamBinance)(nil)
+	_ repo.PriceStreamConn = (*priceStreamConn)(nil)
+)
+
+const (
+	DefaultStreamURL = "wss://stream.binance.com:9443/ws" // Binance WebSocket streams URL
+
+	_streamDialTimeout = 10 * time.Second // timeout for WebSocket handshake
+	_streamWriteWait   = 5 * time.Second  // timeout for writing message to stream
+	// max time without messages from stream (Binance pings every 3 minutes)
+	_streamReadTimeout = 5 * time.Minute
+
+	_miniTickerSuffix = "@miniTicker"    // suffix of the mini ticker stream name
+	_miniTickerEvent  = "24hrMiniTicker" // event type of the mini ticker stream
+
+	_methodSubscribe   = "SUBSCRIBE"   // method of request to subscribe streams
+	_methodUnsubscribe = "UNSUBSCRIBE" // method of request to unsubscribe streams
+)
+
+// streamRequest is a raw request to subscribe or unsubscribe streams.
+type streamRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int      `json:"id"`
+}
+
+// streamMessage is a raw stream message. It is a mini ticker event
+// or a response to (un)subscribe request.
+type streamMessage struct {
+	// event type (empty for responses)
+	Event string `json:"e"`
+	// event time in unix milliseconds
+	EventTime int64 `json:"E"`
+	// trading pair (e.g. BTCUSDT)
+	Symbol string `json:"s"`
+	// last price as a decimal string
+	Close string `json:"c"`
+	// response error
+	Error *struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"error"`
+}
+
+// streamPair is a coin symbol and quote currency of the trading pair.
+type streamPair struct {
+	symbol   string
+	currency string
+}
+
+type PriceStreamBinance struct {
+	url    string
+	dialer *websocket.Dialer
+}
+
+// NewPriceStreamBinance returns new Binance price stream instance.
+// The url is a Binance WebSocket streams URL (see DefaultStreamURL).
+func NewPriceStreamBinance(url string) *PriceStreamBinance {
+	return &PriceStreamBinance{
+		url: url,
+		dialer: &websocket.Dialer{
+			Proxy:            websocket.DefaultDialer.Proxy,
+			HandshakeTimeout: _streamDialTimeout,
+		},
+	}
+}
+
+// Dial opens new connection to the mini ticker streams.
+// Coin prices are streamed after subscription.
+func (s *PriceStreamBinance) Dial(ctx context.Context) (repo.PriceStreamConn, error) {
+	conn, _, err := s.dialer.DialContext(ctx, s.url, nil) // nolint:bodyclose // closed by dialer
+	if err != nil {
+		return nil, fmt.Errorf("dial stream: %w", err)
+	}
+
+	streamConn := &priceStreamConn{
+		conn:  conn,
+		pairs: make(map[string]streamPair),
+	}
+	// prolong read deadline on every ping from server
+	_ = conn.SetReadDeadline(time.Now().Add(_streamReadTimeout))
+	conn.SetPingHandler(func(data string) error {
+		_ = conn.SetReadDeadline(time.Now().Add(_streamReadTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data),
+			time.Now().Add(_streamWriteWait))
+	})
+	return streamConn, nil
+}
+
+// priceStreamConn is a connection to Binance mini ticker streams.
+type priceStreamConn struct {
+	conn *websocket.Conn
+
+	mu sync.Mutex
+	// subscribed trading pairs (e.g. BTCUSDT)
+	pairs map[string]streamPair
+	// last request ID
+	requestID int
+}
+
+// Subscribe subscribes to prices of given coins in each of given
+// quote currencies (USD is treated as USDT).
+func (c *priceStreamConn) Subscribe(coins entity.CoinList, currencies []string) error {
+	return c.request(_methodSubscribe, coins, currencies)
+}
+
+// Unsubscribe unsubscribes from prices of given coins in each of given quote currencies.
+func (c *priceStreamConn) Unsubscribe(coins entity.CoinList, currencies []string) error {
+	return c.request(_methodUnsubscribe, coins, currencies)
+}
+
+// Read blocks until the next coin price is received. Responses to
+// (un)subscribe requests and prices of unsubscribed pairs are skipped.
+// Stream message looks like:
+//
+//	{
+//	  "e": "24hrMiniTicker",
+//	  "E": 1754050754123,
+//	  "s": "BTCUSDT",
+//	  "c": "115380.01000000",
+//	  "o": "114818.00000000",
+//	  "h": "116000.00000000",
+//	  "l": "114500.00000000",
+//	  "v": "10293.12400000",
+//	  "q": "1187620394.19270000"
+//	}
+func (c *priceStreamConn) Read() (*entity.CoinPriceAPI, error) {
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return nil, fmt.Errorf("read stream: %w", err)
+		}
+		_ = c.conn.SetReadDeadline(time.Now().Add(_streamReadTimeout))
+
+		var message streamMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			return nil, fmt.Errorf("%w: invalid stream message: %s", repo.ErrMalformedData, data)
+		}
+		if message.Error != nil {
+			logrus.Warnf("Binance stream request is failed: %d %s",
+				message.Error.Code, message.Error.Msg)
+			continue
+		}
+		if message.Event != _miniTickerEvent {
+			continue
+		}
+
+		c.mu.Lock()
+		pair, found := c.pairs[message.Symbol]
+		c.mu.Unlock()
+		// price of just unsubscribed pair
+		if !found {
+			continue
+		}
+		price, err := decimal.NewFromString(message.Close)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid coin price: stream message - %s",
+				repo.ErrMalformedData, data)
+		}
+		return &entity.CoinPriceAPI{
+			Symbol:     pair.symbol,
+			Price:      price,
+			Currency:   pair.currency,
+			LastUpdate: message.EventTime / int64(time.Second/time.Millisecond),
+			Source:     ProviderName,
+		}, nil
+	}
+}
+
+// Close closes connection. Blocked read returns error.
+func (c *priceStreamConn) Close() error {
+	return c.conn.Close()
+}
+
+// request sends (un)subscribe request for streams of given coins
+// in each of given quote currencies and updates subscribed pairs.
+func (c *priceStreamConn) request(method string, coins entity.CoinList,
+	currencies []string) error {
+
+	if len(coins) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requestID++
+	req := streamRequest{
+		Method: method,
+		Params: make([]string, 0, len(coins)*len(currencies)),
+		ID:     c.requestID,
+	}
+	pairs := make(map[string]streamPair, len(coins)*len(currencies))
+	for _, coin := range coins {
+		for _, currency := range currencies {
+			pair := tradingPair(coin.Symbol, currency)
+			req.Params = append(req.Params, strings.ToLower(pair)+_miniTickerSuffix)
+			pairs[pair] = streamPair{symbol: coin.Symbol, currency: currency}
+		}
+	}
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(_streamWriteWait))
+	if err := c.conn.WriteJSON(req); err != nil {
+		return fmt.Errorf("%s streams: %w", strings.ToLower(method), err)
+	}
+	for pair, streamPair := range pairs {
+		if method == _methodSubscribe {
+			c.pairs[pair] = streamPair
+		} else {
+			delete(c.pairs, pair)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package coingecko
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// chunk is a part of coin keys requested with the same query param in one request.
+type chunk struct {
+	// query param (ids or symbols)
+	param string
+	// coin IDs or symbols
+	keys []string
+}
+
+// chunkResult is a result of request for one chunk.
+type chunkResult struct {
+	chunk   chunk
+	rawData rawCoinsData
+	err     error
+}
+
+// splitChunks splits coin keys of each query param into chunks of given max size.
+func splitChunks(coinKeys map[string][]string, size int) []chunk {
+	chunks := make([]chunk, 0, len(coinKeys))
+	// sort params to keep requests order stable
+	for _, param := range slices.Sorted(maps.Keys(coinKeys)) {
+		for keys := range slices.Chunk(coinKeys[param], size) {
+			chunks = append(chunks, chunk{param: param, keys: keys})
+		}
+	}
+	return chunks
+}
+
+// fetchChunks requests prices of all chunks concurrently with bounded amount
+// of workers and merges received coins data. Errors of failed chunks are
+// returned for each coin key of chunk. If all chunks are failed only
+// the error of the first chunk is returned. If context is done the
+// chunks in flight are canceled and the rest ones are failed.
+func (r *PriceRepoCoingecko) fetchChunks(ctx context.Context, chunks []chunk,
+	currencies []string) (rawCoinsData, map[string]error, error) {
+
+	jobs := make(chan chunk, len(chunks))
+	for _, job := range chunks {
+		jobs <- job
+	}
+	close(jobs)
+
+	results := make(chan chunkResult, len(chunks))
+	var wg sync.WaitGroup // nolint:varnamelen // generally accepted name
+	for range min(r.batchWorkers, len(chunks)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				rawData, err := r.simplePrice(ctx, job.param, job.keys, currencies)
+				results <- chunkResult{chunk: job, rawData: rawData, err: err}
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	// merge chunks data
+	rawData := make(rawCoinsData)
+	keyErrs := make(map[string]error)
+	var firstErr error
+	failed := 0
+	for result := range results {
+		if result.err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			for _, key := range result.chunk.keys {
+				keyErrs[key] = result.err
+			}
+			continue
+		}
+		maps.Copy(rawData, result.rawData)
+	}
+
+	if failed == len(chunks) && firstErr != nil {
+		return nil, nil, fmt.Errorf("all %d chunks are failed: %w", failed, firstErr)
+	}
+	return rawData, keyErrs, nil
+}
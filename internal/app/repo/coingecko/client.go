@@ -0,0 +1,158 @@
+package coingecko
+
+import (
+	"net/http"
+	"time"
+
+	resty "github.com/go-resty/resty/v2"
+)
+
+const (
+	PlanDemo = "demo" // free demo API plan
+	PlanPro  = "pro"  // paid pro API plan
+
+	DemoBaseURL = "https://api.coingecko.com/api/v3"     // demo API base URL
+	ProBaseURL  = "https://pro-api.coingecko.com/api/v3" // pro API base URL
+
+	_demoAPIKeyHeader = "x-cg-demo-api-key" // header with API key for demo plan
+	_proAPIKeyHeader  = "x-cg-pro-api-key"  // header with API key for pro plan
+
+	_defaultRequestTimeout = 2 * time.Second        // timeout for requests to API
+	_defaultRetryCount     = 3                      // amount of retries attempts in error cases
+	_defaultRetryInitTime  = 500 * time.Millisecond // time between first request and first retry
+	_defaultRetryMaxTime   = 2 * time.Second        // max time between request and retry
+	_defaultBatchSize      = 100                    // max amount of coins in one request
+	_defaultBatchWorkers   = 4                      // max amount of concurrent requests
+)
+
+// Provides HTTP-client and requests settings for API repos.
+type clientSettings struct {
+	plan           string
+	baseURL        string
+	requestTimeout time.Duration
+	retryCount     int
+	retryInitTime  time.Duration
+	retryMaxTime   time.Duration
+	limiter        *Limiter
+	batchSize      int
+	batchWorkers   int
+	transport      http.RoundTripper
+}
+
+// Type for options for API repos initializing.
+type Option func(*clientSettings)
+
+// newSettings returns API repo settings.
+// Options can be set with "WithSmth" funcs.
+func newSettings(options ...Option) *clientSettings {
+	settings := &clientSettings{
+		plan:           PlanDemo,
+		requestTimeout: _defaultRequestTimeout,
+		retryCount:     _defaultRetryCount,
+		retryInitTime:  _defaultRetryInitTime,
+		retryMaxTime:   _defaultRetryMaxTime,
+		batchSize:      _defaultBatchSize,
+		batchWorkers:   _defaultBatchWorkers,
+	}
+
+	// apply all options to customize settings
+	for _, opt := range options {
+		opt(settings)
+	}
+	return settings
+}
+
+// newClient returns new HTTP-client for API with given API key and settings.
+func newClient(apiKey string, settings *clientSettings) *resty.Client {
+
+	// choose API host and header for API key by plan
+	baseURL, apiKeyHeader := DemoBaseURL, _demoAPIKeyHeader
+	if settings.plan == PlanPro {
+		baseURL, apiKeyHeader = ProBaseURL, _proAPIKeyHeader
+	}
+	// custom base URL (e.g. mock server or proxy) overrides plan host
+	if settings.baseURL != "" {
+		baseURL = settings.baseURL
+	}
+
+	// init HTTP-client with retry params
+	client := resty.New().
+		SetBaseURL(baseURL).
+		SetHeader("Accept", "application/json").
+		SetHeader(apiKeyHeader, apiKey).
+		SetTimeout(settings.requestTimeout).
+		SetRetryCount(settings.retryCount).
+		SetRetryWaitTime(settings.retryInitTime).
+		SetRetryMaxWaitTime(settings.retryMaxTime)
+	// custom transport (e.g. responses recorder or replayer)
+	if settings.transport != nil {
+		client.SetTransport(settings.transport)
+	}
+	// every request attempt (including retries) is limited and accounted
+	if settings.limiter != nil {
+		client.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+			return settings.limiter.Take(req.Context())
+		})
+	}
+	return client
+}
+
+// Set API plan (demo or pro). It selects API host and header for API key.
+// Optional. Demo by default.
+func WithPlan(plan string) Option {
+	return func(s *clientSettings) {
+		s.plan = plan
+	}
+}
+
+// Set custom API base URL (e.g. mock server or proxy). It overrides
+// plan host but not plan header for API key. Optional.
+func WithBaseURL(baseURL string) Option {
+	return func(s *clientSettings) {
+		s.baseURL = baseURL
+	}
+}
+
+// Set timeout for requests to API. Optional. 2s by default.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(s *clientSettings) {
+		s.requestTimeout = timeout
+	}
+}
+
+// Set retry policy: amount of retries attempts, time between first request
+// and first retry and max time between request and retry.
+// Optional. 3 retries from 500ms to 2s by default.
+func WithRetry(count int, initTime, maxTime time.Duration) Option {
+	return func(s *clientSettings) {
+		s.retryCount = count
+		s.retryInitTime = initTime
+		s.retryMaxTime = maxTime
+	}
+}
+
+// Set API calls limiter. The same limiter must be set for all
+// API repos using the same API key. Optional. Unlimited by default.
+func WithLimiter(limiter *Limiter) Option {
+	return func(s *clientSettings) {
+		s.limiter = limiter
+	}
+}
+
+// Set batching of many coins' prices requests: max amount of coins
+// in one request and max amount of concurrent requests.
+// Optional. 100 coins and 4 requests by default.
+func WithBatching(size, workers int) Option {
+	return func(s *clientSettings) {
+		s.batchSize = size
+		s.batchWorkers = workers
+	}
+}
+
+// Set HTTP transport for requests to API (e.g. to record API responses
+// or to replay recorded ones). Optional. Default HTTP transport by default.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(s *clientSettings) {
+		s.transport = transport
+	}
+}
@@ -0,0 +1,114 @@
+package coingecko
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	resty "github.com/go-resty/resty/v2"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ repo.CoinRepoAPI = (*CoinRepoCoingecko)(nil)
+
+const (
+	_coinListTTL     = time.Hour        // time to keep the coin list in cache
+	_coinListPath    = "/coins/list"    // path of the coin list endpoint
+	_coinListTimeout = 30 * time.Second // min timeout for request of the huge coin list
+)
+
+// rawCoin is a raw coin from API coin list.
+type rawCoin struct {
+	// CoinGecko coin ID
+	ID string `json:"id"`
+	// coin symbol
+	Symbol string `json:"symbol"`
+	// coin full name
+	Name string `json:"name"`
+	// map of blockchain platform names to contract addresses
+	Platforms map[string]string `json:"platforms"`
+}
+
+type CoinRepoCoingecko struct {
+	client *resty.Client
+
+	mu        sync.Mutex
+	coins     []rawCoin
+	fetchedAt time.Time
+}
+
+// NewCoinRepoCoingecko returns new Coingecko API repo instance for coin entity.
+// API host, timeout and retry policy can be set with "WithSmth" options.
+// Request timeout is not less than 30 seconds because the coin list is huge.
+func NewCoinRepoCoingecko(apiKey string, options ...Option) *CoinRepoCoingecko {
+	settings := newSettings(options...)
+	settings.requestTimeout = max(settings.requestTimeout, _coinListTimeout)
+	return &CoinRepoCoingecko{
+		client: newClient(apiKey, settings),
+	}
+}
+
+// SearchBySymbol returns all coins with given symbol from API coin list.
+// The coin list is huge so it is cached for an hour.
+func (r *CoinRepoCoingecko) SearchBySymbol(symbol string) (entity.CoinCandidateList, error) {
+	coins, err := r.coinList()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(entity.CoinCandidateList, 0)
+	for _, coin := range coins {
+		if !strings.EqualFold(coin.Symbol, symbol) {
+			continue
+		}
+		candidates = append(candidates, entity.CoinCandidate{
+			ExternalID: coin.ID,
+			Symbol:     symbol,
+			Name:       coin.Name,
+			Platforms:  coin.Platforms,
+		})
+	}
+	return candidates, nil
+}
+
+// coinList returns cached coin list or requests it from API if cache is expired.
+// API response looks like:
+//
+//	[
+//	  {
+//	    "id": "uniswap",
+//	    "symbol": "uni",
+//	    "name": "Uniswap",
+//	    "platforms": {
+//	      "ethereum": "0x1f9840a85d5af5bf1d1762f925bdaddc4201f984"
+//	    }
+//	  }
+//	]
+func (r *CoinRepoCoingecko) coinList() ([]rawCoin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.coins != nil && time.Since(r.fetchedAt) < _coinListTTL {
+		return r.coins, nil
+	}
+
+	var rawData []rawCoin
+	// do request to REST API and parse JSON-response into result
+	resp, err := r.client.R().
+		SetResult(&rawData).
+		SetQueryParam("include_platform", "true").
+		Get(_coinListPath)
+	if err != nil {
+		return nil, fmt.Errorf("request to api: %w", err)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("request to api: unexpected status %s", resp.Status())
+	}
+
+	r.coins = rawData
+	r.fetchedAt = time.Now()
+	return r.coins, nil
+}
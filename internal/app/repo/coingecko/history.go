@@ -0,0 +1,94 @@
+package coingecko
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	resty "github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ repo.PriceHistoryRepoAPI = (*PriceHistoryRepoCoingecko)(nil)
+
+const _marketChartRangePath = "/coins/{id}/market_chart/range" // path of the market chart endpoint
+
+// rawMarketChart is a raw response from API market chart endpoint.
+type rawMarketChart struct {
+	// pairs of time in unix milliseconds and price
+	Prices [][2]decimal.Decimal `json:"prices"`
+}
+
+type PriceHistoryRepoCoingecko struct {
+	client *resty.Client
+}
+
+// NewPriceHistoryRepoCoingecko returns new Coingecko API repo instance
+// for historical prices. API host, timeout, retry policy and calls limiter
+// can be set with "WithSmth" options.
+func NewPriceHistoryRepoCoingecko(apiKey string, options ...Option) *PriceHistoryRepoCoingecko {
+	return &PriceHistoryRepoCoingecko{
+		client: newClient(apiKey, newSettings(options...)),
+	}
+}
+
+// PriceHistory returns coin prices in given quote currency from given
+// unix timestamp to given one in ascending time order. API selects data
+// granularity by the window size: 5 minutes for 1 day, hourly up to
+// 90 days and daily for wider windows. CoinGecko ID is required.
+// Request to API is canceled if context is done.
+// API response looks like:
+//
+//	{
+//	  "prices": [
+//	    [1754006400000, 115380.12],
+//	    [1754010000000, 115412.48]
+//	  ]
+//	}
+func (r *PriceHistoryRepoCoingecko) PriceHistory(ctx context.Context, coin *entity.Coin,
+	currency string, from, to int64) (entity.CoinPriceAPIList, error) {
+
+	if coin.ExternalID == "" {
+		return nil, fmt.Errorf("%w: coin %s has no coingecko id", repo.ErrValidateData, coin.Symbol)
+	}
+
+	var rawData rawMarketChart
+	// do request to REST API and parse JSON-response into result
+	resp, err := r.client.R().
+		SetContext(ctx).
+		SetResult(&rawData).
+		SetPathParam("id", coin.ExternalID).
+		SetQueryParam("vs_currency", currency).
+		SetQueryParam("from", strconv.FormatInt(from, 10)).
+		SetQueryParam("to", strconv.FormatInt(to, 10)).
+		Get(_marketChartRangePath)
+	if err != nil {
+		return nil, fmt.Errorf("request to api: %w", err)
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: coin %s is not found", repo.ErrValidateData, coin.ExternalID)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("request to api: unexpected status %s", resp.Status())
+	}
+
+	coinPrices := make(entity.CoinPriceAPIList, 0, len(rawData.Prices))
+	for _, point := range rawData.Prices {
+		if !point[1].IsPositive() {
+			return nil, fmt.Errorf("%w: invalid price %s", repo.ErrMalformedData, point[1])
+		}
+		coinPrices = append(coinPrices, entity.CoinPriceAPI{
+			Symbol:     coin.Symbol,
+			Price:      point[1],
+			Currency:   currency,
+			LastUpdate: point[0].IntPart() / int64(time.Second/time.Millisecond),
+			Source:     ProviderName,
+		})
+	}
+	return coinPrices, nil
+}
@@ -0,0 +1,132 @@
+package coingecko
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"CryptocoinPrice/internal/app/repo"
+)
+
+const (
+	_maxRateLimitWait   = 10 * time.Second    // max time to wait for free call in rate limit
+	_quotaWarnThreshold = 0.8                 // part of monthly quota to warn about its usage
+	_longestMonth       = 31 * 24 * time.Hour // duration of the longest calendar month
+)
+
+// Limiter limits calls to API with token bucket (per-minute rate limit)
+// and accounts calls against monthly quota. It must be shared by all
+// API repos using the same API key.
+type Limiter struct {
+	bucket *rate.Limiter
+	// max amount of calls per calendar month (zero - unlimited)
+	monthlyQuota int
+
+	mu sync.Mutex
+	// first day of month calls are accounted for
+	month time.Time
+	// amount of calls used in month
+	used int
+}
+
+// NewLimiter returns new API calls limiter. The callsPerMinute is a rate limit
+// (zero - unlimited) and the monthlyQuota is a max amount of calls per
+// calendar month in UTC (zero - unlimited).
+func NewLimiter(callsPerMinute, monthlyQuota int) *Limiter {
+	bucket := rate.NewLimiter(rate.Inf, 0)
+	if callsPerMinute > 0 {
+		// bucket is full at the start to allow burst of calls
+		bucket = rate.NewLimiter(rate.Every(time.Minute/time.Duration(callsPerMinute)),
+			callsPerMinute)
+	}
+	return &Limiter{
+		bucket:       bucket,
+		monthlyQuota: monthlyQuota,
+		month:        monthStart(time.Now()),
+	}
+}
+
+// MinCallInterval returns min interval between regular calls to API
+// which does not exhaust given monthly quota before the end of the longest
+// calendar month (zero if quota is unlimited).
+func MinCallInterval(monthlyQuota int) time.Duration {
+	if monthlyQuota <= 0 {
+		return 0
+	}
+	return _longestMonth / time.Duration(monthlyQuota)
+}
+
+// Take waits for free call in rate limit and accounts it against monthly quota.
+// It returns quota exhausted error if monthly quota is used up.
+// Waiting is stopped if given context is done.
+func (l *Limiter) Take(ctx context.Context) error {
+	if err := l.checkQuota(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, _maxRateLimitWait)
+	defer cancel()
+	if err := l.bucket.Wait(ctx); err != nil {
+		return fmt.Errorf("wait for rate limit: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rollMonth()
+	if l.usedUp() {
+		return l.exhaustedErr()
+	}
+	l.used++
+	// warn once when quota usage is close to the limit
+	if l.monthlyQuota > 0 && l.used == int(float64(l.monthlyQuota)*_quotaWarnThreshold) {
+		logrus.Warnf("CoinGecko API monthly quota usage: %d/%d calls", l.used, l.monthlyQuota)
+	}
+	if l.used == l.monthlyQuota {
+		logrus.Errorf("CoinGecko API monthly quota is exhausted: %d/%d calls",
+			l.used, l.monthlyQuota)
+	}
+	return nil
+}
+
+// checkQuota returns quota exhausted error if monthly quota is used up.
+func (l *Limiter) checkQuota() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rollMonth()
+	if l.usedUp() {
+		return l.exhaustedErr()
+	}
+	return nil
+}
+
+// usedUp returns true if monthly quota is used up. It must be called under mutex.
+func (l *Limiter) usedUp() bool {
+	return l.monthlyQuota > 0 && l.used >= l.monthlyQuota
+}
+
+// rollMonth resets used calls if calendar month is changed.
+// It must be called under mutex.
+func (l *Limiter) rollMonth() {
+	if month := monthStart(time.Now()); month.After(l.month) {
+		l.month, l.used = month, 0
+	}
+}
+
+// exhaustedErr returns quota exhausted error with next month start.
+// It must be called under mutex.
+func (l *Limiter) exhaustedErr() error {
+	return fmt.Errorf("%w: %d calls per month are used until %s", repo.ErrQuotaExhausted,
+		l.monthlyQuota, l.month.AddDate(0, 1, 0).Format(time.DateOnly))
+}
+
+// monthStart returns start of calendar month of given time in UTC.
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -2,11 +2,15 @@
 package coingecko
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
-	"time"
+	"sync"
 
 	resty "github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 
 	"CryptocoinPrice/internal/app/entity"
@@ -16,42 +20,59 @@ import (
 var _ repo.PriceRepoAPI = (*PriceRepoCoingecko)(nil)
 
 const (
-	_requestTimeout = 2 * time.Second        // timeout for requests to API
-	_retryCount     = 3                      // amount of retries attempts in error cases
-	_retryInitTime  = 500 * time.Millisecond // time between first request and first retry
-	_retryMaxTime   = 2 * time.Second        // max time between request and retry
+	ProviderName = "coingecko" // provider name used in config
+
+	_simplePricePath = "/simple/price" // path of the simple price endpoint
 
-	_coinDataPriceKey      = "usd"             // key for coin price in coin data map
 	_coinDataLastUpdateKey = "last_updated_at" // key for coin last update time in coin data map
+
+	_queryParamIDs     = "ids"     // query param to request coins by CoinGecko IDs
+	_queryParamSymbols = "symbols" // query param to request coins by symbols
 )
 
 // rawCoinsData is a raw response from API. It's a map (with keys - coin names)
-// of maps with string-any key-values (coin data).
-type rawCoinsData map[string]map[string]any
+// of maps with string-number key-values (coin data). Numbers are kept as is
+// to parse prices without loss of precision.
+type rawCoinsData map[string]map[string]json.Number
+
+// priceCacheKey is a pair of coin key (CoinGecko ID or symbol)
+// and quote currency to identify cached price.
+type priceCacheKey struct {
+	key      string
+	currency string
+}
 
 type PriceRepoCoingecko struct {
-	apiKey string
 	client *resty.Client
+	// max amount of coins in one request
+	batchSize int
+	// max amount of concurrent requests
+	batchWorkers int
+
+	mu sync.Mutex
+	// last received coin prices to serve lookups if API quota is exhausted
+	cache map[priceCacheKey]entity.CoinPriceAPI
 }
 
 // NewPriceRepoCoingecko returns new Coingecko API repo instance for price entity.
-func NewPriceRepoCoingecko(apiKey string) *PriceRepoCoingecko {
-	// init HTTP-client with retry params
-	restyClient := resty.New().
-		SetTimeout(_requestTimeout).
-		SetRetryCount(_retryCount).
-		SetRetryWaitTime(_retryInitTime).
-		SetRetryMaxWaitTime(_retryMaxTime)
-
+// API host, timeout, retry policy, calls limiter and batching
+// can be set with "WithSmth" options.
+func NewPriceRepoCoingecko(apiKey string, options ...Option) *PriceRepoCoingecko {
+	settings := newSettings(options...)
 	return &PriceRepoCoingecko{
-		apiKey: apiKey,
-		client: restyClient,
+		client:       newClient(apiKey, settings),
+		batchSize:    settings.batchSize,
+		batchWorkers: settings.batchWorkers,
+		cache:        make(map[priceCacheKey]entity.CoinPriceAPI),
 	}
 }
 
 // OneCoinPrice sends request to API for
-// one coin (with given symbol) price and returns it.
-// The price is in USD.
+// one coin price and returns it.
+// The coin is requested by its CoinGecko ID if it is
+// presented and by its symbol otherwise.
+// The price is in the given quote currency (e.g. "usd").
+// If API quota is exhausted the last received coin price is returned.
 // API response looks like:
 //
 //	{
@@ -60,96 +81,156 @@ func NewPriceRepoCoingecko(apiKey string) *PriceRepoCoingecko {
 //	    "last_updated_at": 1754050754
 //	  }
 //	}
-func (r *PriceRepoCoingecko) OneCoinPrice(symbol string) (*entity.CoinPriceAPI, error) {
-	// map of coins each of which are map with coin data
-	var rawData rawCoinsData
+func (r *PriceRepoCoingecko) OneCoinPrice(ctx context.Context, coin *entity.Coin,
+	currency string) (*entity.CoinPriceAPI, error) {
 
-	// do request to REST API and parse JSON-response into result
-	_, err := r.client.R().
-		SetResult(&rawData).
-		SetHeader("Accept", "application/json").
-		SetHeader("x-cg-demo-api-key", r.apiKey).
-		SetQueryParam("vs_currencies", "usd").
-		SetQueryParam("include_last_updated_at", "true").
-		SetQueryParam("symbols", symbol).
-		Get("https://api.coingecko.com/api/v3/simple/price")
+	param, key := coinQuery(coin)
+	rawData, err := r.simplePrice(ctx, param, []string{key}, []string{currency})
+	// serve cached price if quota is exhausted
+	if errors.Is(err, repo.ErrQuotaExhausted) {
+		if coinData, found := r.cachedPrice(key, currency); found {
+			logrus.Warnf("Serve cached coin %s price in %s: %v", coin.Symbol, currency, err)
+			return coinData, nil
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("request to api: %w", err)
+		return nil, err
 	}
 
 	// parse coin data into struct
-	coinData, err := parseCoinData(rawData, symbol)
+	coinData, err := parseCoinData(rawData, key, coin, currency)
 	if err != nil {
 		return nil, fmt.Errorf("parse coin data: %w", err)
 	}
+	r.cachePrice(key, *coinData)
 	return coinData, nil
 }
 
-// ManyCoinPrices sends request to API for
-// many coins' (with given symbols) prices and returns them.
-// The prices is in USD.
+// ManyCoinPrices sends requests to API for
+// many coins' prices and returns them.
+// Coins with CoinGecko ID are requested by IDs and the
+// others are requested by symbols in the separate requests.
+// Coins are split into chunks requested concurrently.
+// Each coin price is returned in each of given quote currencies.
+// Coins with not received prices (including coins of failed chunks)
+// are reported in the returned coin prices error.
+// If API quota is exhausted no one price is returned.
+func (r *PriceRepoCoingecko) ManyCoinPrices(ctx context.Context, coins entity.CoinList,
+	currencies []string) (entity.CoinPriceAPIList, error) {
+
+	// coin price errors
+	errList := make([]*repo.CoinPriceError, 0)
+
+	// split coins by query param
+	coinKeys := make(map[string][]string, 2) // nolint:mnd // ids and symbols
+	for i := range coins {
+		param, key := coinQuery(&coins[i])
+		coinKeys[param] = append(coinKeys[param], key)
+	}
+	// map of coins each of which are map with coin data
+	rawData, keyErrs, err := r.fetchChunks(ctx, splitChunks(coinKeys, r.batchSize), currencies)
+	if err != nil {
+		return nil, err
+	}
+
+	// init coin prices slice
+	pricesAmount := len(coins) * len(currencies)
+	coinPricesList := make(entity.CoinPriceAPIList, 0, pricesAmount)
+
+	// parse each coin in each currency
+	for i := range coins {
+		_, key := coinQuery(&coins[i])
+		for _, currency := range currencies {
+			coinErr := &repo.CoinPriceError{
+				CoinID: coins[i].ID, Symbol: coins[i].Symbol, Currency: currency,
+			}
+			// if coin chunk request is failed
+			if keyErr, found := keyErrs[key]; found {
+				coinErr.Err = fmt.Errorf("request chunk: %w", keyErr)
+				errList = append(errList, coinErr)
+				continue
+			}
+			coinData, err := parseCoinData(rawData, key, &coins[i], currency)
+			if err != nil {
+				coinErr.Err = err
+				errList = append(errList, coinErr)
+				continue
+			}
+			coinPricesList = append(coinPricesList, *coinData)
+			r.cachePrice(key, *coinData)
+		}
+	}
+
+	logrus.Infof("Get coin prices: %d/%d", pricesAmount-len(errList), pricesAmount)
+	return coinPricesList, repo.NewCoinPricesError(errList)
+}
+
+// simplePrice sends request to API for prices of coins
+// with given keys (IDs or symbols, depending on param)
+// in given quote currencies.
 // API response looks like:
 //
 //	{
 //	  "btc": {
 //	    "usd": 115380,
+//	    "eur": 99642,
 //	    "last_updated_at": 1754050754
 //	  },
 //	  "eth": {
 //	    "usd": 3647.54,
+//	    "eur": 3150.02,
 //	    "last_updated_at": 1754050755
 //	  }
 //	}
-func (r *PriceRepoCoingecko) ManyCoinPrices(symbols []string) (entity.CoinPriceAPIList, error) {
-	var (
-		// map of coins each of which are map with coin data
-		rawData rawCoinsData
-		// coin parsing errors
-		errList = make([]error, 0)
-	)
+func (r *PriceRepoCoingecko) simplePrice(ctx context.Context, param string,
+	keys, currencies []string) (rawCoinsData, error) {
+
+	// map of coins each of which are map with coin data
+	var rawData rawCoinsData
 
 	// do request to REST API and parse JSON-response into result
-	_, err := r.client.R().
+	resp, err := r.client.R().
+		SetContext(ctx).
 		SetResult(&rawData).
-		SetHeader("Accept", "application/json").
-		SetHeader("x-cg-demo-api-key", r.apiKey).
-		SetQueryParam("vs_currencies", "usd").
+		SetQueryParam("vs_currencies", strings.Join(currencies, ",")).
 		SetQueryParam("include_last_updated_at", "true").
-		SetQueryParam("symbols", strings.Join(symbols, ",")).
-		Get("https://api.coingecko.com/api/v3/simple/price")
+		SetQueryParam(param, strings.Join(keys, ",")).
+		Get(_simplePricePath)
 	if err != nil {
 		return nil, fmt.Errorf("request to api: %w", err)
 	}
-
-	// init coin prices slice
-	symbolsAmount := len(symbols)
-	coinPricesList := make(entity.CoinPriceAPIList, 0, symbolsAmount)
-
-	var coinData *entity.CoinPriceAPI
-	// parse each coin
-	for _, symbol := range symbols {
-		coinData, err = parseCoinData(rawData, symbol)
-		if err != nil {
-			errList = append(errList, err)
-		}
-		coinPricesList = append(coinPricesList, *coinData)
+	if resp.IsError() {
+		return nil, fmt.Errorf("request to api: unexpected status %s", resp.Status())
 	}
+	return rawData, nil
+}
 
-	errsAmount := len(errList)
-	logrus.Infof("Get coin prices: %d/%d", symbolsAmount-errsAmount, symbolsAmount)
-	// if no one parsing error is occurred
-	if errsAmount == 0 {
-		return coinPricesList, nil
-	}
+// cachePrice saves given price of coin with given key (CoinGecko ID or symbol)
+// into cache replacing the previous one.
+func (r *PriceRepoCoingecko) cachePrice(key string, coinPrice entity.CoinPriceAPI) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cache[priceCacheKey{key: key, currency: coinPrice.Currency}] = coinPrice
+}
+
+// cachedPrice returns cached price of coin with given key (CoinGecko ID
+// or symbol) in given currency.
+func (r *PriceRepoCoingecko) cachedPrice(key, currency string) (*entity.CoinPriceAPI, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// collect err slice into one error
-	var errBuilder strings.Builder
-	errBuilder.WriteString(errList[0].Error())
-	for _, parseErr := range errList[1:] {
-		errBuilder.WriteString(" && ")
-		errBuilder.WriteString(parseErr.Error())
+	coinPrice, found := r.cache[priceCacheKey{key: key, currency: currency}]
+	return &coinPrice, found
+}
+
+// coinQuery returns query param name and its value to request given coin.
+// It is CoinGecko ID if it is presented and coin symbol otherwise.
+func coinQuery(coin *entity.Coin) (param, key string) {
+	if coin.ExternalID != "" {
+		return _queryParamIDs, coin.ExternalID
 	}
-	return coinPricesList, fmt.Errorf("parse coins data: %s", errBuilder.String())
+	return _queryParamSymbols, coin.Symbol
 }
 
 // parseCoinData parses specific coin data from raw map with coins from API.
@@ -166,32 +247,38 @@ func (r *PriceRepoCoingecko) ManyCoinPrices(symbols []string) (entity.CoinPriceA
 //	  }
 //	}
 //
-// So, given rawCoinsData must be a map (with keys - coin names)
-// of maps with string-any key-values (coin data).
-// Given symbol value is the name of needed coin to parse.
-func parseCoinData(rawCoinsData map[string]map[string]any,
-	symbol string) (*entity.CoinPriceAPI, error) {
+// So, given rawCoinsData must be a map (with keys - coin IDs or symbols)
+// of maps with string-number key-values (coin data).
+// Given key value is the key of needed coin to parse, coin is the requested
+// coin for result and currency is the key of needed price in coin data.
+func parseCoinData(rawData rawCoinsData, key string, coin *entity.Coin,
+	currency string) (*entity.CoinPriceAPI, error) {
 
-	coinData, found := rawCoinsData[symbol]
+	coinData, found := rawData[key]
 	// if coin data is not found in result
 	if !found {
-		return nil, fmt.Errorf("%w: coin %s is not found", repo.ErrValidateData, symbol)
+		return nil, fmt.Errorf("%w: coin %s is not found", repo.ErrValidateData, key)
 	}
 
-	var ok bool // nolint:varnamelen // generally accepted variable name
-	coinPriceObj := &entity.CoinPriceAPI{Symbol: symbol}
-	// parse coin price
-	coinPriceObj.Price, ok = coinData[_coinDataPriceKey].(float64)
-	if !ok {
-		return nil, fmt.Errorf("invalid coin price: coin data - %v", coinData)
+	var err error
+	coinPriceObj := &entity.CoinPriceAPI{
+		CoinID:   coin.ID,
+		Symbol:   coin.Symbol,
+		Currency: currency,
+		Source:   ProviderName,
 	}
-	// parse coin last update time (by default, numbers deserialized into float64)
-	floatCoinLastUpdate, ok := coinData[_coinDataLastUpdateKey].(float64)
-	if !ok {
-		return nil, fmt.Errorf("invalid coin last update time: coin data - %v", coinData)
+	// parse coin price (missing price is empty number)
+	coinPriceObj.Price, err = decimal.NewFromString(coinData[currency].String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid coin price in %s: coin data - %v",
+			repo.ErrMalformedData, currency, coinData)
+	}
+	// parse coin last update time
+	coinPriceObj.LastUpdate, err = coinData[_coinDataLastUpdateKey].Int64()
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid coin last update time: coin data - %v",
+			repo.ErrMalformedData, coinData)
 	}
-	// cast to integer
-	coinPriceObj.LastUpdate = int64(floatCoinLastUpdate)
 
 	return coinPriceObj, nil
 }
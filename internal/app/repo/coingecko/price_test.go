@@ -1,29 +1,42 @@
 package coingecko
 
 import (
-	"log"
+	"context"
+	"net/http"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
-	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+	"CryptocoinPrice/internal/app/repo/fake"
 )
 
+// dir with recorded API responses
+const _testFixturesDir = "testdata"
+
 var (
-	_testPriceRepo *PriceRepoCoingecko
+	_testPriceRepo   *PriceRepoCoingecko
+	_testHistoryRepo *PriceHistoryRepoCoingecko
 
-	_testCoinSymbols = []string{"btc", "eth", "ton"}
+	_testCoins = entity.CoinList{
+		{Symbol: "btc"}, {Symbol: "eth"}, {ExternalID: "the-open-network", Symbol: "ton"},
+	}
+	_testCurrencies = []string{"usd", "eur"}
 )
 
+// TestMain replays recorded API responses. Set COINGECKO_MODE=record
+// (with COINGECKO_API_KEY) to record them again from the real API.
 func TestMain(m *testing.M) {
-	// load config
-	cfg, err := config.New()
-	if err != nil {
-		log.Fatalf("parse config: %v", err)
+	transport := http.RoundTripper(fake.NewReplayer(_testFixturesDir))
+	if os.Getenv("COINGECKO_MODE") == "record" {
+		transport = fake.NewRecorder(_testFixturesDir, nil)
 	}
-	// create repo
-	_testPriceRepo = NewPriceRepoCoingecko(cfg.App.CoingeckoAPIKey)
+	// create repos
+	apiKey := os.Getenv("COINGECKO_API_KEY")
+	_testPriceRepo = NewPriceRepoCoingecko(apiKey, WithTransport(transport))
+	_testHistoryRepo = NewPriceHistoryRepoCoingecko(apiKey, WithTransport(transport))
 
 	// run tests
 	os.Exit(m.Run())
@@ -32,8 +45,12 @@ func TestMain(m *testing.M) {
 func TestCoinRepoCoingecko_OneCoinPrice(t *testing.T) {
 	t.Log("Get coin price from API")
 
-	coinPrice, err := _testPriceRepo.OneCoinPrice(_testCoinSymbols[0])
+	coinPrice, err := _testPriceRepo.OneCoinPrice(context.Background(), &_testCoins[0], _testCurrencies[0])
 	require.NoError(t, err)
+	require.Equal(t, "btc", coinPrice.Symbol)
+	require.Equal(t, _testCurrencies[0], coinPrice.Currency)
+	require.True(t, coinPrice.Price.IsPositive())
+	require.Positive(t, coinPrice.LastUpdate)
 
 	t.Logf("Coin price: %+v", coinPrice)
 }
@@ -41,8 +58,32 @@ func TestCoinRepoCoingecko_OneCoinPrice(t *testing.T) {
 func TestCoinRepoCoingecko_ManyCoinPrices(t *testing.T) {
 	t.Log("Get coins' prices from API")
 
-	coinPricesList, err := _testPriceRepo.ManyCoinPrices(_testCoinSymbols)
+	coinPricesList, err := _testPriceRepo.ManyCoinPrices(context.Background(), _testCoins, _testCurrencies)
 	require.NoError(t, err)
+	require.Len(t, coinPricesList, len(_testCoins)*len(_testCurrencies))
 
 	t.Logf("Coins' prices: %+v", coinPricesList)
 }
+
+func TestPriceHistoryRepoCoingecko_PriceHistory(t *testing.T) {
+	t.Log("Get coin price history from API")
+
+	coin := &entity.Coin{ExternalID: "bitcoin", Symbol: "btc"}
+	coinPrices, err := _testHistoryRepo.PriceHistory(context.Background(), coin, "usd", 1754006400, 1754013600)
+	require.NoError(t, err)
+	require.NotEmpty(t, coinPrices)
+	for _, coinPrice := range coinPrices {
+		require.GreaterOrEqual(t, coinPrice.LastUpdate, int64(1754006400))
+		require.LessOrEqual(t, coinPrice.LastUpdate, int64(1754013600))
+	}
+
+	t.Logf("Coin price history: %+v", coinPrices)
+}
+
+func TestPriceHistoryRepoCoingecko_PriceHistoryUnexisting(t *testing.T) {
+	t.Log("Get price history of unexisting coin from API")
+
+	coin := &entity.Coin{ExternalID: "unexisting", Symbol: "xyz"}
+	_, err := _testHistoryRepo.PriceHistory(context.Background(), coin, "usd", 1754006400, 1754013600)
+	require.ErrorIs(t, err, repo.ErrValidateData)
+}
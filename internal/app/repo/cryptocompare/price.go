@@ -0,0 +1,220 @@
+// Package cryptocompare contains CryptoCompare API repos implementations for entities.
+package cryptocompare
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	resty "github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ repo.PriceRepoAPI = (*PriceRepoCryptocompare)(nil)
+
+const (
+	ProviderName   = "cryptocompare"                     // provider name used in config
+	DefaultBaseURL = "https://min-api.cryptocompare.com" // CryptoCompare API base URL
+
+	_requestTimeout = 2 * time.Second        // timeout for requests to API
+	_retryCount     = 3                      // amount of retries attempts in error cases
+	_retryInitTime  = 500 * time.Millisecond // time between first request and first retry
+	_retryMaxTime   = 2 * time.Second        // max time between request and retry
+
+	_priceMultiFullPath = "/data/pricemultifull" // path of the full price matrix endpoint
+	_responseError      = "Error"                // value of "Response" field for failed requests
+)
+
+// rawPriceData is a raw response from API.
+type rawPriceData struct {
+	// "Error" if request is failed
+	Response string `json:"Response"`
+	// error message if request is failed
+	Message string `json:"Message"`
+	// map (with keys - upper-cased coin symbols) of maps
+	// (with keys - quote currencies) with coin data
+	Raw map[string]map[string]rawCoinData `json:"RAW"`
+}
+
+// rawCoinData is a raw coin data for one quote currency.
+type rawCoinData struct {
+	// last price
+	Price *decimal.Decimal `json:"PRICE"`
+	// last update time in unix format
+	LastUpdate int64 `json:"LASTUPDATE"`
+}
+
+type PriceRepoCryptocompare struct {
+	apiKey string
+	client *resty.Client
+}
+
+// NewPriceRepoCryptocompare returns new CryptoCompare API repo instance for price entity.
+// The baseURL is a CryptoCompare API base URL (see DefaultBaseURL).
+// The apiKey is optional: without it requests are sent anonymously.
+func NewPriceRepoCryptocompare(baseURL, apiKey string) *PriceRepoCryptocompare {
+	// init HTTP-client with retry params
+	restyClient := resty.New().
+		SetBaseURL(baseURL).
+		SetTimeout(_requestTimeout).
+		SetRetryCount(_retryCount).
+		SetRetryWaitTime(_retryInitTime).
+		SetRetryMaxWaitTime(_retryMaxTime)
+
+	return &PriceRepoCryptocompare{
+		apiKey: apiKey,
+		client: restyClient,
+	}
+}
+
+// OneCoinPrice sends request to API for
+// one coin price and returns it. The coin is requested by its symbol.
+// The price is in the given quote currency (e.g. "usd").
+func (r *PriceRepoCryptocompare) OneCoinPrice(ctx context.Context, coin *entity.Coin,
+	currency string) (*entity.CoinPriceAPI, error) {
+
+	rawData, err := r.priceMultiFull(ctx, []string{coin.Symbol}, []string{currency})
+	if err != nil {
+		return nil, err
+	}
+
+	// parse coin data into struct
+	coinData, err := parseCoinData(rawData, coin, currency)
+	if err != nil {
+		return nil, fmt.Errorf("parse coin data: %w", err)
+	}
+	return coinData, nil
+}
+
+// ManyCoinPrices sends request to API for
+// many coins' prices and returns them. The coins are requested by its symbols.
+// Each coin price is returned in each of given quote currencies.
+// Coins sharing the same symbol are not requested and reported as not
+// found because their prices can not be bound to one of them.
+func (r *PriceRepoCryptocompare) ManyCoinPrices(ctx context.Context, coins entity.CoinList,
+	currencies []string) (entity.CoinPriceAPIList, error) {
+
+	sharedSymbols := repo.SharedSymbols(coins)
+	symbols := make([]string, 0, len(coins))
+	for _, coin := range coins {
+		if _, shared := sharedSymbols[coin.Symbol]; !shared {
+			symbols = append(symbols, coin.Symbol)
+		}
+	}
+	rawData := &rawPriceData{}
+	if len(symbols) != 0 {
+		var err error
+		rawData, err = r.priceMultiFull(ctx, symbols, currencies)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pricesAmount := len(coins) * len(currencies)
+	coinPricesList := make(entity.CoinPriceAPIList, 0, pricesAmount)
+	errList := make([]*repo.CoinPriceError, 0)
+	// parse each coin in each currency
+	for i := range coins {
+		coin := &coins[i]
+		for _, currency := range currencies {
+			coinErr := &repo.CoinPriceError{
+				CoinID: coin.ID, Symbol: coin.Symbol, Currency: currency,
+			}
+			if _, shared := sharedSymbols[coin.Symbol]; shared {
+				coinErr.Err = fmt.Errorf("%w: coin symbol is shared by many coins",
+					repo.ErrValidateData)
+				errList = append(errList, coinErr)
+				continue
+			}
+			coinData, err := parseCoinData(rawData, coin, currency)
+			if err != nil {
+				coinErr.Err = err
+				errList = append(errList, coinErr)
+				continue
+			}
+			coinPricesList = append(coinPricesList, *coinData)
+		}
+	}
+
+	logrus.Infof("Get coin prices from %s: %d/%d", ProviderName,
+		pricesAmount-len(errList), pricesAmount)
+	return coinPricesList, repo.NewCoinPricesError(errList)
+}
+
+// priceMultiFull sends request to API for full price data
+// of given coins in given quote currencies.
+// API response looks like:
+//
+//	{
+//	  "RAW": {
+//	    "BTC": {
+//	      "USD": {
+//	        "PRICE": 115380,
+//	        "LASTUPDATE": 1754050754
+//	      }
+//	    }
+//	  }
+//	}
+//
+// If all given coins are unknown API returns response with
+// "Response" field equal to "Error". Unknown coins are omitted otherwise.
+func (r *PriceRepoCryptocompare) priceMultiFull(ctx context.Context, symbols,
+	currencies []string) (*rawPriceData, error) {
+
+	rawData := &rawPriceData{}
+
+	req := r.client.R().
+		SetContext(ctx).
+		SetResult(rawData).
+		SetHeader("Accept", "application/json").
+		SetQueryParam("fsyms", strings.ToUpper(strings.Join(symbols, ","))).
+		SetQueryParam("tsyms", strings.ToUpper(strings.Join(currencies, ",")))
+	if r.apiKey != "" {
+		req.SetHeader("Authorization", "Apikey "+r.apiKey)
+	}
+
+	// do request to REST API and parse JSON-response into result
+	resp, err := req.Get(_priceMultiFullPath)
+	if err != nil {
+		return nil, fmt.Errorf("request to api: %w", err)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("request to api: unexpected status %s", resp.Status())
+	}
+	// all coins are unknown or request is invalid
+	if rawData.Response == _responseError {
+		return nil, fmt.Errorf("%w: %s", repo.ErrValidateData, rawData.Message)
+	}
+	return rawData, nil
+}
+
+// parseCoinData parses specific coin data from raw API response.
+// Given coin is the needed coin to parse (by its symbol)
+// and currency is the quote currency of needed price.
+func parseCoinData(rawData *rawPriceData, coin *entity.Coin,
+	currency string) (*entity.CoinPriceAPI, error) {
+
+	coinData, found := rawData.Raw[strings.ToUpper(coin.Symbol)][strings.ToUpper(currency)]
+	// if coin data is not found in result
+	if !found {
+		return nil, fmt.Errorf("%w: coin %s in %s is not found",
+			repo.ErrValidateData, coin.Symbol, currency)
+	}
+	if coinData.Price == nil {
+		return nil, fmt.Errorf("%w: invalid coin price: coin data - %+v",
+			repo.ErrMalformedData, coinData)
+	}
+	return &entity.CoinPriceAPI{
+		CoinID:     coin.ID,
+		Symbol:     coin.Symbol,
+		Price:      *coinData.Price,
+		Currency:   currency,
+		LastUpdate: coinData.LastUpdate,
+		Source:     ProviderName,
+	}, nil
+}
@@ -0,0 +1,99 @@
+package cryptocompare
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+const _testAPIKey = "test-key"
+
+// newTestServer returns CryptoCompare API stand-in with BTC and ETH prices.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Apikey "+_testAPIKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Query().Get("fsyms") {
+		case "UNEXISTING":
+			_, _ = w.Write([]byte(`{
+				"Response": "Error",
+				"Message": "cccagg_or_exchange market does not exist for this coin pair"
+			}`))
+		default:
+			_, _ = w.Write([]byte(`{"RAW": {
+				"BTC": {
+					"USD": {"PRICE": 115380, "LASTUPDATE": 1754050754},
+					"EUR": {"PRICE": 99642, "LASTUPDATE": 1754050754}
+				},
+				"ETH": {"USD": {"PRICE": 3647.54, "LASTUPDATE": 1754050755}}
+			}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPriceRepoCryptocompare_OneCoinPrice(t *testing.T) {
+	t.Log("Get coin price from API")
+
+	priceRepo := NewPriceRepoCryptocompare(newTestServer(t).URL, _testAPIKey)
+	coinPrice, err := priceRepo.OneCoinPrice(context.Background(), &entity.Coin{Symbol: "btc"}, "usd")
+	require.NoError(t, err)
+	require.Equal(t, "btc", coinPrice.Symbol)
+	require.Equal(t, "115380", coinPrice.Price.String())
+	require.Equal(t, int64(1754050754), coinPrice.LastUpdate)
+
+	t.Logf("Coin price: %+v", coinPrice)
+}
+
+func TestPriceRepoCryptocompare_OneCoinPriceUnexisting(t *testing.T) {
+	t.Log("Get unexisting coin price from API")
+
+	priceRepo := NewPriceRepoCryptocompare(newTestServer(t).URL, _testAPIKey)
+	_, err := priceRepo.OneCoinPrice(context.Background(), &entity.Coin{Symbol: "unexisting"}, "usd")
+	require.ErrorIs(t, err, repo.ErrValidateData)
+}
+
+func TestPriceRepoCryptocompare_ManyCoinPrices(t *testing.T) {
+	t.Log("Get coins' prices from API with one unexisting coin")
+
+	priceRepo := NewPriceRepoCryptocompare(newTestServer(t).URL, _testAPIKey)
+	coinPricesList, err := priceRepo.ManyCoinPrices(context.Background(),
+		entity.CoinList{{Symbol: "btc"}, {Symbol: "eth"}, {Symbol: "ton"}}, []string{"usd", "eur"})
+	require.Error(t, err)
+	require.Len(t, coinPricesList, 3)
+	require.Equal(t, "eur", coinPricesList[1].Currency)
+	require.Equal(t, "eth", coinPricesList[2].Symbol)
+
+	t.Logf("Coins' prices: %+v", coinPricesList)
+}
+
+func TestPriceRepoCryptocompare_ManyCoinPricesSharedSymbol(t *testing.T) {
+	t.Log("Get prices of coins sharing the same symbol from API")
+
+	priceRepo := NewPriceRepoCryptocompare(newTestServer(t).URL, _testAPIKey)
+	coinPricesList, err := priceRepo.ManyCoinPrices(context.Background(), entity.CoinList{
+		{ID: "btc-id", Symbol: "btc"},
+		{ID: "eth-id", Symbol: "eth", ExternalID: "ethereum"},
+		{ID: "other-eth-id", Symbol: "eth", ExternalID: "other-eth"},
+	}, []string{"usd"})
+	require.Len(t, coinPricesList, 1)
+	require.Equal(t, "btc-id", coinPricesList[0].CoinID)
+
+	var pricesErr *repo.CoinPricesError
+	require.ErrorAs(t, err, &pricesErr)
+	require.Len(t, pricesErr.Errs, 2)
+	require.Equal(t, "eth-id", pricesErr.Errs[0].CoinID)
+	require.ErrorIs(t, pricesErr.Errs[0], repo.ErrValidateData)
+}
@@ -0,0 +1,166 @@
+// Package fake contains fake price API repos and HTTP transports
+// to develop and test the app offline: recorder and replayer of API
+// responses and deterministic random-walk price generator.
+package fake
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	_ http.RoundTripper = (*Recorder)(nil)
+	_ http.RoundTripper = (*Replayer)(nil)
+)
+
+const (
+	_fixtureHashLen  = 12    // length of request hash in fixture file name
+	_fixtureFileMode = 0o644 // permissions of fixture files
+	_fixtureDirMode  = 0o755 // permissions of fixtures dir
+)
+
+// fixture is a recorded API response.
+type fixture struct {
+	// request method, path and query (to find fixture manually)
+	Request string `json:"request"`
+	// response status code
+	Status int `json:"status"`
+	// response JSON body
+	Body json.RawMessage `json:"body"`
+}
+
+// Recorder is a HTTP transport which saves API responses into fixture
+// files in given dir to replay them later. Fixtures are identified by
+// request method, path and query, so request headers (e.g. with API
+// key) and host are not recorded.
+type Recorder struct {
+	dir  string
+	next http.RoundTripper
+}
+
+// NewRecorder returns new API responses recorder which sends requests
+// with the next transport (http.DefaultTransport if nil) and saves
+// responses into given dir.
+func NewRecorder(dir string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, next: next}
+}
+
+// RoundTrip sends request and saves its JSON response into fixture file.
+// Responses with not JSON body are not saved.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// read body and restore it for the caller
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if !json.Valid(body) {
+		logrus.Warnf("Skip recording not JSON response to %s", requestString(req))
+		return resp, nil
+	}
+	if err := r.save(req, resp.StatusCode, body); err != nil {
+		logrus.Errorf("Record response to %s: %v", requestString(req), err)
+	}
+	return resp, nil
+}
+
+// save writes response into fixture file of given request.
+func (r *Recorder) save(req *http.Request, status int, body []byte) error {
+	data, err := json.MarshalIndent(fixture{
+		Request: requestString(req),
+		Status:  status,
+		Body:    body,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal fixture: %w", err)
+	}
+	if err := os.MkdirAll(r.dir, _fixtureDirMode); err != nil {
+		return fmt.Errorf("create fixtures dir: %w", err)
+	}
+	return os.WriteFile(filepath.Join(r.dir, FixtureName(req)), data, _fixtureFileMode)
+}
+
+// Replayer is a HTTP transport which serves API responses from fixture
+// files in given dir without requests to API. Requests without
+// recorded response are answered with 404 status.
+type Replayer struct {
+	dir string
+}
+
+// NewReplayer returns new API responses replayer serving fixtures from given dir.
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir: dir}
+}
+
+// RoundTrip returns recorded response to the request.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	data, err := os.ReadFile(filepath.Join(r.dir, FixtureName(req)))
+	if errors.Is(err, os.ErrNotExist) {
+		logrus.Warnf("Response to %s is not recorded in %s", requestString(req), r.dir)
+		return newResponse(req, http.StatusNotFound,
+			[]byte(`{"error":"response is not recorded"}`)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+
+	var recorded fixture
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("parse fixture: %w", err)
+	}
+	return newResponse(req, recorded.Status, recorded.Body), nil
+}
+
+// FixtureName returns fixture file name for given request. It consists of
+// request path and hash of request method, path and query (with sorted params).
+// Fixture name does not depend on request host.
+func FixtureName(req *http.Request) string {
+	hash := sha256.Sum256([]byte(requestString(req)))
+	slug := strings.ReplaceAll(strings.Trim(req.URL.Path, "/"), "/", "_")
+	return slug + "-" + hex.EncodeToString(hash[:])[:_fixtureHashLen] + ".json"
+}
+
+// requestString returns request method, path and query with sorted params.
+func requestString(req *http.Request) string {
+	request := req.Method + " " + req.URL.Path
+	if query := req.URL.Query().Encode(); query != "" {
+		request += "?" + query
+	}
+	return request
+}
+
+// newResponse returns JSON response to given request.
+func newResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
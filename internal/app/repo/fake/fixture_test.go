@@ -0,0 +1,43 @@
+package fake
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayer_RoundTrip(t *testing.T) {
+	t.Log("Replay API response recorded by recorder")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"btc":{"usd":115380}}`))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	// record response
+	recordClient := &http.Client{Transport: NewRecorder(dir, nil)}
+	resp, err := recordClient.Get(server.URL + "/simple/price?vs_currencies=usd&symbols=btc")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// replay response by request with the other host and params order
+	replayClient := &http.Client{Transport: NewReplayer(dir)}
+	resp, err = replayClient.Get("http://api.invalid/simple/price?symbols=btc&vs_currencies=usd")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `{"btc":{"usd":115380}}`, string(body))
+
+	// not recorded response
+	resp, err = replayClient.Get("http://api.invalid/simple/price?symbols=eth&vs_currencies=usd")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,121 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ repo.PriceRepoAPI = (*PriceRepoRandomWalk)(nil)
+
+const (
+	RandomWalkProviderName = "randomwalk" // provider name used in config
+
+	_randomWalkVolatility = 0.005 // standard deviation of relative price change per step
+	_randomWalkMaxOrder   = 5     // max order of magnitude of start price (1 to 100000)
+	_randomWalkPrecision  = 8     // amount of significant digits of price
+)
+
+// walkKey is a pair of coin symbol and quote currency to identify random walk.
+type walkKey struct {
+	symbol   string
+	currency string
+}
+
+// walk is a random walk of one coin price in one quote currency.
+type walk struct {
+	rnd   *rand.Rand
+	price float64
+}
+
+type PriceRepoRandomWalk struct {
+	seed uint64
+
+	mu    sync.Mutex
+	walks map[walkKey]*walk
+}
+
+// NewPriceRepoRandomWalk returns new fake price API repo generating coin prices
+// with random walk. Each coin price in each quote currency walks independently,
+// so the sequence of its prices depends on the seed only.
+func NewPriceRepoRandomWalk(seed uint64) *PriceRepoRandomWalk {
+	return &PriceRepoRandomWalk{
+		seed:  seed,
+		walks: make(map[walkKey]*walk),
+	}
+}
+
+// OneCoinPrice returns the next coin price in the given quote currency.
+func (r *PriceRepoRandomWalk) OneCoinPrice(_ context.Context, coin *entity.Coin,
+	currency string) (*entity.CoinPriceAPI, error) {
+
+	if coin.Symbol == "" {
+		return nil, fmt.Errorf("%w: empty coin symbol", repo.ErrValidateData)
+	}
+	coinPrice := r.nextPrice(coin.Symbol, currency, time.Now().UTC().Unix())
+	coinPrice.CoinID = coin.ID
+	return coinPrice, nil
+}
+
+// ManyCoinPrices returns the next price of each coin in each of given quote currencies.
+func (r *PriceRepoRandomWalk) ManyCoinPrices(_ context.Context, coins entity.CoinList,
+	currencies []string) (entity.CoinPriceAPIList, error) {
+
+	lastUpdate := time.Now().UTC().Unix()
+	coinPricesList := make(entity.CoinPriceAPIList, 0, len(coins)*len(currencies))
+	for _, coin := range coins {
+		for _, currency := range currencies {
+			coinPrice := r.nextPrice(coin.Symbol, currency, lastUpdate)
+			coinPrice.CoinID = coin.ID
+			coinPricesList = append(coinPricesList, *coinPrice)
+		}
+	}
+	return coinPricesList, nil
+}
+
+// nextPrice makes step of the coin price walk in the quote currency
+// and returns the new price.
+func (r *PriceRepoRandomWalk) nextPrice(symbol, currency string,
+	lastUpdate int64) *entity.CoinPriceAPI {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := walkKey{symbol: symbol, currency: currency}
+	coinWalk, found := r.walks[key]
+	if !found {
+		// walk is seeded with the seed and the coin in the currency
+		hash := fnv.New64a()
+		_, _ = hash.Write([]byte(symbol + "/" + currency))
+		rnd := rand.New(rand.NewPCG(r.seed, hash.Sum64())) // nolint:gosec // fake prices
+		coinWalk = &walk{
+			rnd:   rnd,
+			price: math.Pow(10, rnd.Float64()*_randomWalkMaxOrder), // nolint:mnd // decimal
+		}
+		r.walks[key] = coinWalk
+	}
+	coinWalk.price *= math.Exp(coinWalk.rnd.NormFloat64() * _randomWalkVolatility)
+
+	return &entity.CoinPriceAPI{
+		Symbol:     symbol,
+		Price:      roundPrice(coinWalk.price),
+		Currency:   currency,
+		LastUpdate: lastUpdate,
+		Source:     RandomWalkProviderName,
+	}
+}
+
+// roundPrice returns price rounded to fixed amount of significant digits.
+func roundPrice(price float64) decimal.Decimal {
+	places := _randomWalkPrecision - 1 - int32(math.Floor(math.Log10(price)))
+	return decimal.NewFromFloat(price).Round(places)
+}
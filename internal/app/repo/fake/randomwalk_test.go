@@ -0,0 +1,35 @@
+package fake
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+)
+
+func TestPriceRepoRandomWalk_ManyCoinPrices(t *testing.T) {
+	t.Log("Generate the same prices with the same seed")
+
+	coins := entity.CoinList{{Symbol: "btc"}, {Symbol: "eth"}}
+	currencies := []string{"usd", "eur"}
+	prices := func(seed uint64) []string {
+		priceRepo := NewPriceRepoRandomWalk(seed)
+		generated := make([]string, 0)
+		for range 3 {
+			coinPrices, err := priceRepo.ManyCoinPrices(context.Background(), coins, currencies)
+			require.NoError(t, err)
+			require.Len(t, coinPrices, len(coins)*len(currencies))
+			for _, coinPrice := range coinPrices {
+				require.True(t, coinPrice.Price.IsPositive())
+				require.Equal(t, RandomWalkProviderName, coinPrice.Source)
+				generated = append(generated, coinPrice.Price.String())
+			}
+		}
+		return generated
+	}
+
+	require.Equal(t, prices(42), prices(42))
+	require.NotEqual(t, prices(42), prices(43))
+}
@@ -0,0 +1,150 @@
+package pg
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ repo.BackfillJobRepoDB = (*BackfillJobRepoPG)(nil)
+
+type BackfillJobRepoPG struct {
+	dbStorage *gorm.DB
+}
+
+// NewBackfillJobRepoPG returns new PostgreSQL repo DB instance for backfill job entity.
+func NewBackfillJobRepoPG(dbStorage *gorm.DB) *BackfillJobRepoPG {
+	return &BackfillJobRepoPG{
+		dbStorage: dbStorage,
+	}
+}
+
+// CreateActive saves new queued or running backfill job into DB.
+// If job of the same coin is already queued or running it is returned instead.
+// All fields must be presented apart of ID. ID is autogenerated.
+func (r *BackfillJobRepoPG) CreateActive(job *entity.BackfillJob) (*entity.BackfillJob, error) {
+	job.ID = uuid.NewString()
+
+	activeJob := &entity.BackfillJob{}
+	err := r.dbStorage.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+		if result.Error != nil {
+			return result.Error
+		}
+		// if job is created
+		if result.RowsAffected != 0 {
+			activeJob = job
+			return nil
+		}
+		return tx.Where("coin_id = ? AND state IN ?", job.CoinID,
+			[]string{entity.BackfillStateQueued, entity.BackfillStateRunning}).
+			First(activeJob).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return activeJob, nil
+}
+
+// GetByID returns backfill job with given ID.
+// If job is not found it returns not found error.
+func (r *BackfillJobRepoPG) GetByID(jobID string) (*entity.BackfillJob, error) {
+	job := &entity.BackfillJob{}
+
+	err := r.dbStorage.Where("id = ?", jobID).Limit(1).Find(job).Error
+	if err != nil {
+		return nil, err
+	}
+	// if record is not found
+	if job.ID == "" {
+		return nil, repo.ErrNotFound
+	}
+	return job, nil
+}
+
+// ClaimQueued marks the oldest queued backfill job as running since given
+// time and returns it with coin instance.
+// If there are no queued jobs it returns not found error.
+func (r *BackfillJobRepoPG) ClaimQueued(startedAt int64) (*entity.BackfillJob, error) {
+	job := &entity.BackfillJob{}
+
+	err := r.dbStorage.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("state = ?", entity.BackfillStateQueued).
+			Order("queued_at").
+			First(job).Error
+		if err != nil {
+			return err
+		}
+		job.State, job.StartedAt = entity.BackfillStateRunning, startedAt
+		return tx.Model(&entity.BackfillJob{}).
+			Where("id = ?", job.ID).
+			Updates(map[string]any{
+				"state":      job.State,
+				"started_at": job.StartedAt,
+			}).Error
+	})
+	// if record is not found
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// get job coin
+	if err := r.dbStorage.Where("id = ?", job.CoinID).First(&job.Coin).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Update updates state, progress, error message and
+// start and finish times of backfill job with given ID.
+func (r *BackfillJobRepoPG) Update(job *entity.BackfillJob) error {
+	return r.dbStorage.Model(&entity.BackfillJob{}).
+		Where("id = ?", job.ID).
+		Updates(map[string]any{
+			"state":       job.State,
+			"done_steps":  job.DoneSteps,
+			"saved":       job.Saved,
+			"skipped":     job.Skipped,
+			"error":       job.Error,
+			"started_at":  job.StartedAt,
+			"finished_at": job.FinishedAt,
+		}).Error
+}
+
+// RequeueRunning marks all running backfill jobs as queued to run them
+// from the beginning. It returns amount of requeued jobs.
+func (r *BackfillJobRepoPG) RequeueRunning() (int, error) {
+	result := r.dbStorage.Model(&entity.BackfillJob{}).
+		Where("state = ?", entity.BackfillStateRunning).
+		Updates(map[string]any{
+			"state":      entity.BackfillStateQueued,
+			"done_steps": 0,
+			"saved":      0,
+			"skipped":    0,
+			"started_at": 0,
+		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return int(result.RowsAffected), nil
+}
+
+// DeleteFinishedBefore deletes backfill jobs finished before given time.
+// It returns amount of deleted jobs.
+func (r *BackfillJobRepoPG) DeleteFinishedBefore(finishedAt int64) (int, error) {
+	result := r.dbStorage.
+		Where("finished_at <> 0 AND finished_at < ?", finishedAt).
+		Delete(&entity.BackfillJob{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return int(result.RowsAffected), nil
+}
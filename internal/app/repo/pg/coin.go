@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"CryptocoinPrice/internal/app/entity"
 	"CryptocoinPrice/internal/app/repo"
@@ -25,13 +26,11 @@ func NewCoinRepoPG(dbStorage *gorm.DB) *CoinRepoPG {
 }
 
 // Create creates new coin. It is observed by default.
-func (r *CoinRepoPG) Create(symbol string) (*entity.Coin, error) {
-	// init coin for creating
-	coin := &entity.Coin{
-		ID:       uuid.NewString(),
-		Symbol:   symbol,
-		Observed: true,
-	}
+// All fields must be presented apart of ID and Observed.
+// ID is autogenerated.
+func (r *CoinRepoPG) Create(coin *entity.Coin) (*entity.Coin, error) {
+	coin.ID = uuid.NewString()
+	coin.Observed = true
 	// create record
 	if err := r.dbStorage.Create(coin).Error; err != nil {
 		return nil, err
@@ -39,20 +38,23 @@ func (r *CoinRepoPG) Create(symbol string) (*entity.Coin, error) {
 	return coin, nil
 }
 
-// GetBySymbol returns coin by given symbol.
+// GetBySymbol returns coins with given symbol ordered by canonical ID
+// (many coins can share the same symbol).
 // If symbol is not found it returns not found error
-func (r *CoinRepoPG) GetBySymbol(symbol string) (*entity.Coin, error) {
-	coin := &entity.Coin{Symbol: symbol}
+func (r *CoinRepoPG) GetBySymbol(symbol string) (entity.CoinList, error) {
+	coinList := entity.CoinList{}
 
-	err := r.dbStorage.Where(coin).First(coin).Error
-	// if record is not found
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, repo.ErrNotFound
-	}
+	err := r.dbStorage.Where(&entity.Coin{Symbol: symbol}).
+		Order("external_id").
+		Find(&coinList).Error
 	if err != nil {
 		return nil, err
 	}
-	return coin, nil
+	// if records are not found
+	if len(coinList) == 0 {
+		return nil, repo.ErrNotFound
+	}
+	return coinList, nil
 }
 
 // Update updates coin.
@@ -82,3 +84,43 @@ func (r *CoinRepoPG) GetObserved() (entity.CoinList, error) {
 	}
 	return coinList, nil
 }
+
+// AddFailure increments failures counter of coins with given IDs and marks
+// coin as failing if its counter reaches threshold.
+// It returns coins which are marked as failing by this call.
+func (r *CoinRepoPG) AddFailure(coinIDs []string, threshold int) (entity.CoinList, error) {
+	if len(coinIDs) == 0 {
+		return entity.CoinList{}, nil
+	}
+
+	coinList := entity.CoinList{}
+	err := r.dbStorage.Model(&coinList).
+		Clauses(clause.Returning{}).
+		Where("id IN ?", coinIDs).
+		Updates(map[string]any{
+			"fail_count": gorm.Expr("fail_count + 1"),
+			"failing":    gorm.Expr("fail_count + 1 >= ?", threshold),
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// select coins which reached threshold right now
+	markedCoins := make(entity.CoinList, 0)
+	for _, coin := range coinList {
+		if coin.FailCount == threshold {
+			markedCoins = append(markedCoins, coin)
+		}
+	}
+	return markedCoins, nil
+}
+
+// ResetFailures resets failures counter and failing mark of coins with given IDs.
+func (r *CoinRepoPG) ResetFailures(coinIDs []string) error {
+	if len(coinIDs) == 0 {
+		return nil
+	}
+	return r.dbStorage.Model(&entity.Coin{}).
+		Where("id IN ?", coinIDs).
+		Updates(map[string]any{"fail_count": 0, "failing": false}).Error
+}
@@ -1,12 +1,15 @@
 package pg
 
 import (
+	"context"
 	"log"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"CryptocoinPrice/config"
 	"CryptocoinPrice/internal/app/entity"
@@ -15,8 +18,10 @@ import (
 )
 
 var (
+	_testDB        *gorm.DB
 	_testCoinRepo  *CoinRepoPG
 	_testPriceRepo *PriceRepoPG
+	_testGapRepo   *PriceGapRepoPG
 	_testCoinUUID  string
 
 	_testCoinSymbol = "btc"
@@ -36,17 +41,32 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		log.Fatalf("get db connection: %v", err)
 	}
+	_testDB = dbStorage
 	_testCoinRepo = NewCoinRepoPG(dbStorage)
 	_testPriceRepo = NewPriceRepoPG(dbStorage)
+	_testGapRepo = NewPriceGapRepoPG(dbStorage)
 
 	// run tests
 	os.Exit(m.Run())
 }
 
+// getTestCoin returns the test coin by its symbol.
+func getTestCoin() (*entity.Coin, error) {
+	coinList, err := _testCoinRepo.GetBySymbol(_testCoinSymbol)
+	if err != nil {
+		return nil, err
+	}
+	return &coinList[0], nil
+}
+
 func TestCoinRepoPG_Create(t *testing.T) {
 	t.Log("Create new coin")
 
-	coin, err := _testCoinRepo.Create(_testCoinSymbol)
+	coin, err := _testCoinRepo.Create(&entity.Coin{
+		Symbol:     _testCoinSymbol,
+		ExternalID: "bitcoin",
+		Name:       "Bitcoin",
+	})
 	require.NoError(t, err)
 
 	_testCoinUUID = coin.ID
@@ -56,10 +76,21 @@ func TestCoinRepoPG_Create(t *testing.T) {
 func TestCoinRepoPG_GetBySymbol(t *testing.T) {
 	t.Log("Get coin by symbol")
 
-	coin, err := _testCoinRepo.GetBySymbol(_testCoinSymbol)
+	coinList, err := _testCoinRepo.GetBySymbol(_testCoinSymbol)
 	require.NoError(t, err)
+	require.Len(t, coinList, 1)
 
-	t.Logf("Gotten coin: %+v", coin)
+	t.Logf("Gotten coin: %+v", coinList[0])
+}
+
+func TestCoinRepoPG_CreateSameExternalID(t *testing.T) {
+	t.Log("Create coin with canonical ID of existing coin")
+
+	_, err := _testCoinRepo.Create(&entity.Coin{
+		Symbol:     "wbtc",
+		ExternalID: "bitcoin",
+	})
+	require.Error(t, err)
 }
 
 func TestCoinRepoPG_GetBySymbolUnexisting(t *testing.T) {
@@ -84,16 +115,19 @@ func TestCoinRepoPG_Update(t *testing.T) {
 	t.Log("Update coin")
 
 	observed := false
+	var collectInterval int64 = 60
 	updateValues := &entity.CoinPartial{
-		Observed: &observed,
+		Observed:        &observed,
+		CollectInterval: &collectInterval,
 	}
 
 	err := _testCoinRepo.Update(_testCoinUUID, updateValues)
 	require.NoError(t, err)
 
 	t.Log("Get updated coin")
-	updatedCoin, err := _testCoinRepo.GetBySymbol(_testCoinSymbol)
+	updatedCoin, err := getTestCoin()
 	require.NoError(t, err)
+	require.Equal(t, collectInterval, updatedCoin.CollectInterval)
 	t.Logf("Updated coin: %+v", updatedCoin)
 }
 
@@ -107,29 +141,256 @@ func TestCoinRepoPG_GetObservedAfterUpdate(t *testing.T) {
 	require.Equal(t, entity.CoinList{}, coinList)
 }
 
+func TestCoinRepoPG_AddFailure(t *testing.T) {
+	t.Log("Mark coin as failing after consecutive failures")
+
+	markedCoins, err := _testCoinRepo.AddFailure([]string{_testCoinUUID}, 2)
+	require.NoError(t, err)
+	require.Empty(t, markedCoins)
+
+	markedCoins, err = _testCoinRepo.AddFailure([]string{_testCoinUUID}, 2)
+	require.NoError(t, err)
+	require.Len(t, markedCoins, 1)
+	require.True(t, markedCoins[0].Failing)
+	t.Logf("Marked coins: %+v", markedCoins)
+}
+
+func TestCoinRepoPG_ResetFailures(t *testing.T) {
+	t.Log("Reset coin failures")
+
+	err := _testCoinRepo.ResetFailures([]string{_testCoinUUID})
+	require.NoError(t, err)
+
+	coin, err := getTestCoin()
+	require.NoError(t, err)
+	require.Zero(t, coin.FailCount)
+	require.False(t, coin.Failing)
+}
+
 func TestPriceRepoPG_Create(t *testing.T) {
 	t.Log("Create new price")
 
 	// get coin
-	coin, err := _testCoinRepo.GetBySymbol(_testCoinSymbol)
+	coin, err := getTestCoin()
 	require.NoError(t, err)
 
 	// create price for gotten coin
-	price, err := _testPriceRepo.Create(coin, 114818, time.Now().UTC().Unix())
+	price, err := _testPriceRepo.Create(&entity.Price{
+		CoinID:     coin.ID,
+		Price:      decimal.RequireFromString("114818"),
+		Currency:   "usd",
+		Timestamp:  time.Now().UTC().Unix(),
+		IngestedAt: time.Now().UTC().Unix(),
+		Source:     "coingecko",
+		Coin:       coin,
+	})
 	require.NoError(t, err)
 	t.Logf("New price: %+v", price)
 }
 
-func TestPriceRepoPG_GetNearestTimestamp(t *testing.T) {
-	t.Log("Get price with nearest timestamp")
+func TestPriceRepoPG_GetSurrounding(t *testing.T) {
+	t.Log("Get prices surrounding timestamp")
 
 	// get coin
-	coin, err := _testCoinRepo.GetBySymbol(_testCoinSymbol)
+	coin, err := getTestCoin()
 	require.NoError(t, err)
 
 	var timestamp int64 = 1754045822
-	// get price for coin at given timestamp
-	price, err := _testPriceRepo.GetNearestTimestamp(coin, timestamp)
+	// the only price is created after the timestamp
+	before, after, err := _testPriceRepo.GetSurrounding(coin, "usd", timestamp,
+		entity.TimeAxisSource, 0)
+	require.NoError(t, err)
+	require.Nil(t, before)
+	require.NotNil(t, after)
+	t.Logf("Gotten price after: %+v", after)
+
+	t.Log("Get prices surrounding ingestion timestamp")
+	_, after, err = _testPriceRepo.GetSurrounding(coin, "usd", timestamp,
+		entity.TimeAxisIngested, 0)
+	require.NoError(t, err)
+	require.NotNil(t, after)
+
+	t.Log("Get no prices farther than max distance")
+	before, after, err = _testPriceRepo.GetSurrounding(coin, "usd", timestamp,
+		entity.TimeAxisSource, 60)
+	require.NoError(t, err)
+	require.Nil(t, before)
+	require.Nil(t, after)
+}
+
+func TestPriceRepoPG_GetTimestamps(t *testing.T) {
+	t.Log("Get saved price timestamps of coin in window")
+
+	// get coin
+	coin, err := getTestCoin()
+	require.NoError(t, err)
+
+	timestamps, err := _testPriceRepo.GetTimestamps(coin.ID, "usd", 0, time.Now().Unix())
+	require.NoError(t, err)
+	require.Len(t, timestamps, 1)
+	t.Logf("Saved timestamps: %v", timestamps)
+}
+
+func TestPriceRepoPG_GetLatestTimestamps(t *testing.T) {
+	t.Log("Get latest price timestamps of coins")
+
+	// get coin
+	coin, err := getTestCoin()
+	require.NoError(t, err)
+
+	priceList, err := _testPriceRepo.GetLatestTimestamps([]string{coin.ID})
+	require.NoError(t, err)
+	require.Len(t, priceList, 1)
+	require.Equal(t, "usd", priceList[0].Currency)
+	require.Equal(t, "coingecko", priceList[0].Source)
+	t.Logf("Latest prices: %+v", priceList)
+}
+
+func TestPriceRepoPG_CreateManyDuplicates(t *testing.T) {
+	t.Log("Create many prices skipping already saved ones")
+
+	// get coin
+	coin, err := getTestCoin()
+	require.NoError(t, err)
+
+	newPriceList := func() entity.PriceList {
+		return entity.PriceList{{
+			CoinID:     coin.ID,
+			Price:      decimal.RequireFromString("115380.12"),
+			Currency:   "usd",
+			Timestamp:  1754006400,
+			IngestedAt: time.Now().UTC().Unix(),
+			Source:     "consensus",
+			Quotes: entity.PriceQuoteList{
+				{Source: "coingecko", Price: decimal.RequireFromString("115380.12"),
+					Timestamp: 1754006400},
+			},
+		}}
+	}
+	priceList, err := _testPriceRepo.CreateMany(context.Background(), newPriceList())
+	require.NoError(t, err)
+	require.Len(t, priceList, 1)
+
+	// the same price is saved again (e.g. replayed)
+	priceList, err = _testPriceRepo.CreateMany(context.Background(), newPriceList())
+	require.NoError(t, err)
+	require.Empty(t, priceList)
+}
+
+func TestPriceRepoPG_GetRange(t *testing.T) {
+	t.Log("Get prices in time range page by page")
+
+	// get coin
+	coin, err := getTestCoin()
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Unix()
+	priceList, err := _testPriceRepo.GetRange(coin.ID, "usd", 0, now, entity.OrderAsc, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, priceList, 1)
+	require.Equal(t, int64(1754006400), priceList[0].Timestamp)
+
+	// the next page after the first price
+	cursor := &entity.PriceCursor{Timestamp: priceList[0].Timestamp, ID: priceList[0].ID}
+	priceList, err = _testPriceRepo.GetRange(coin.ID, "usd", 0, now, entity.OrderAsc, cursor, 10)
+	require.NoError(t, err)
+	require.Len(t, priceList, 1)
+	require.Greater(t, priceList[0].Timestamp, cursor.Timestamp)
+
+	t.Log("Get prices in time range from the newest one")
+	priceList, err = _testPriceRepo.GetRange(coin.ID, "usd", 0, now, entity.OrderDesc, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, priceList, 2)
+	require.Greater(t, priceList[0].Timestamp, priceList[1].Timestamp)
+}
+
+func TestPriceRepoPG_CreateManyBatches(t *testing.T) {
+	t.Log("Create many prices with quotes exceeding limit of query params")
+
+	// get coin
+	coin, err := getTestCoin()
+	require.NoError(t, err)
+
+	// prices in other currency to not affect other tests
+	priceList := make(entity.PriceList, 0, 10*_batchSize)
+	for i := range int64(10 * _batchSize) {
+		priceList = append(priceList, entity.Price{
+			CoinID:     coin.ID,
+			Price:      decimal.RequireFromString("115380.12"),
+			Currency:   "eur",
+			Timestamp:  1700000000 + i*3600,
+			IngestedAt: time.Now().UTC().Unix(),
+			Source:     "consensus",
+			Quotes: entity.PriceQuoteList{
+				{Source: "coingecko", Price: decimal.RequireFromString("115380.12"),
+					Timestamp: 1700000000 + i*3600},
+			},
+		})
+	}
+	savedList, err := _testPriceRepo.CreateMany(context.Background(), priceList)
+	require.NoError(t, err)
+	require.Len(t, savedList, 10*_batchSize)
+}
+
+func TestPriceGapRepoPG(t *testing.T) {
+	t.Log("Create price gaps skipping already saved ones")
+
+	newGap := func() entity.PriceGap {
+		return entity.PriceGap{
+			CoinID:           _testCoinUUID,
+			Currency:         "usd",
+			FromTimestamp:    1754013600,
+			ToTimestamp:      1754020800,
+			ExpectedInterval: 5,
+			State:            entity.PriceGapStateDetected,
+			DetectedAt:       time.Now().UTC().Unix(),
+		}
+	}
+	savedList, err := _testGapRepo.CreateMany(entity.PriceGapList{newGap()})
+	require.NoError(t, err)
+	require.Len(t, savedList, 1)
+	savedList, err = _testGapRepo.CreateMany(entity.PriceGapList{newGap()})
+	require.NoError(t, err)
+	require.Empty(t, savedList)
+
+	gapList, err := _testGapRepo.GetOverlapping(_testCoinUUID, "usd", 1754016000, 1754016000)
+	require.NoError(t, err)
+	require.Len(t, gapList, 1)
+
+	t.Log("Update gap state")
+	gap := gapList[0]
+	gap.State, gap.Backfilled = entity.PriceGapStateRepaired, 24
+	require.NoError(t, _testGapRepo.Update(&gap))
+
+	gapList, err = _testGapRepo.GetMany(_testCoinUUID, "", entity.PriceGapStateRepaired, 10)
+	require.NoError(t, err)
+	require.Len(t, gapList, 1)
+	require.Equal(t, 24, gapList[0].Backfilled)
+	require.Equal(t, _testCoinSymbol, gapList[0].Coin.Symbol)
+	t.Logf("Repaired gaps: %+v", gapList)
+}
+
+func TestLeaderLockPG(t *testing.T) {
+	t.Log("Acquire leader lock by one holder only")
+
+	ctx := context.Background()
+	firstLock := NewLeaderLockPG(_testDB, 1)
+	secondLock := NewLeaderLockPG(_testDB, 1)
+
+	locked, err := firstLock.TryLock(ctx)
+	require.NoError(t, err)
+	require.True(t, locked)
+	require.NoError(t, firstLock.Check(ctx))
+
+	locked, err = secondLock.TryLock(ctx)
+	require.NoError(t, err)
+	require.False(t, locked)
+	require.Error(t, secondLock.Check(ctx))
+
+	t.Log("Acquire released leader lock")
+	require.NoError(t, firstLock.Unlock(ctx))
+	locked, err = secondLock.TryLock(ctx)
 	require.NoError(t, err)
-	t.Logf("Gotten price: %+v", price)
+	require.True(t, locked)
+	require.NoError(t, secondLock.Unlock(ctx))
 }
@@ -0,0 +1,127 @@
+package pg
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ repo.PriceGapRepoDB = (*PriceGapRepoPG)(nil)
+
+type PriceGapRepoPG struct {
+	dbStorage *gorm.DB
+}
+
+// NewPriceGapRepoPG returns new PostgreSQL repo DB instance for price gap entity.
+func NewPriceGapRepoPG(dbStorage *gorm.DB) *PriceGapRepoPG {
+	return &PriceGapRepoPG{
+		dbStorage: dbStorage,
+	}
+}
+
+// CreateMany saves new price gaps into DB and returns saved ones. Gaps which
+// are already saved (with the same coin, currency and start) are skipped.
+// All fields must be presented apart of ID. ID is autogenerated.
+func (r *PriceGapRepoPG) CreateMany(gapList entity.PriceGapList) (entity.PriceGapList, error) {
+	if len(gapList) == 0 {
+		return gapList, nil
+	}
+	// generate uuids
+	gapIDs := make([]string, 0, len(gapList))
+	for i := range gapList {
+		gapList[i].ID = uuid.NewString()
+		gapIDs = append(gapIDs, gapList[i].ID)
+	}
+
+	savedList := make(entity.PriceGapList, 0, len(gapList))
+	err := r.dbStorage.Transaction(func(tx *gorm.DB) error {
+		// save gaps skipping duplicates
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			CreateInBatches(&gapList, _batchSize).Error
+		if err != nil {
+			return err
+		}
+		// get ids of gaps which are not skipped
+		saved := make(map[string]struct{}, len(gapIDs))
+		for batchIDs := range slices.Chunk(gapIDs, _batchSize) {
+			savedIDs := make([]string, 0, len(batchIDs))
+			err = tx.Model(&entity.PriceGap{}).Where("id IN ?", batchIDs).
+				Pluck("id", &savedIDs).Error
+			if err != nil {
+				return err
+			}
+			for _, id := range savedIDs {
+				saved[id] = struct{}{}
+			}
+		}
+
+		for _, gap := range gapList {
+			if _, found := saved[gap.ID]; found {
+				savedList = append(savedList, gap)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return savedList, nil
+}
+
+// GetOverlapping returns price gaps of given coin in given quote currency
+// overlapping window from given timestamp to given one inclusive.
+func (r *PriceGapRepoPG) GetOverlapping(coinID, currency string,
+	from, to int64) (entity.PriceGapList, error) {
+
+	gapList := entity.PriceGapList{}
+	err := r.dbStorage.
+		Where("coin_id = ? AND currency = ? AND to_timestamp >= ? AND from_timestamp <= ?",
+			coinID, currency, from, to).
+		Find(&gapList).Error
+	if err != nil {
+		return nil, err
+	}
+	return gapList, nil
+}
+
+// GetMany returns price gaps with coin instances from the newest gap to
+// the oldest one. Gaps are filtered by coin ID, quote currency and state
+// if they are not empty. Limit is max amount of returned gaps.
+func (r *PriceGapRepoPG) GetMany(coinID, currency, state string,
+	limit int) (entity.PriceGapList, error) {
+
+	query := r.dbStorage.Preload("Coin")
+	if coinID != "" {
+		query = query.Where("coin_id = ?", coinID)
+	}
+	if currency != "" {
+		query = query.Where("currency = ?", currency)
+	}
+	if state != "" {
+		query = query.Where("state = ?", state)
+	}
+
+	gapList := entity.PriceGapList{}
+	err := query.Order("from_timestamp DESC").Limit(limit).Find(&gapList).Error
+	if err != nil {
+		return nil, err
+	}
+	return gapList, nil
+}
+
+// Update updates state, amount of backfilled prices
+// and error message of price gap with given ID.
+func (r *PriceGapRepoPG) Update(gap *entity.PriceGap) error {
+	return r.dbStorage.Model(&entity.PriceGap{}).
+		Where("id = ?", gap.ID).
+		Updates(map[string]any{
+			"state":      gap.State,
+			"backfilled": gap.Backfilled,
+			"error":      gap.Error,
+		}).Error
+}
@@ -0,0 +1,88 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ repo.LeaderLockDB = (*LeaderLockPG)(nil)
+
+// LeaderLockPG is a leader lock based on PostgreSQL session-level advisory
+// lock. The lock is held by a dedicated DB connection, so it is released by
+// DB as soon as the connection of the died holder is closed.
+// It is not safe for concurrent use.
+type LeaderLockPG struct {
+	dbStorage *gorm.DB
+	key       int64
+	// connection holding the lock (nil if lock is not held)
+	conn *sql.Conn
+}
+
+// NewLeaderLockPG returns new PostgreSQL leader lock with given advisory lock key.
+func NewLeaderLockPG(dbStorage *gorm.DB, key int64) *LeaderLockPG {
+	return &LeaderLockPG{
+		dbStorage: dbStorage,
+		key:       key,
+	}
+}
+
+// TryLock tries to acquire the lock without waiting.
+// It returns true if lock is acquired or already held.
+func (r *LeaderLockPG) TryLock(ctx context.Context) (bool, error) {
+	if r.conn != nil {
+		return true, nil
+	}
+
+	sqlDB, err := r.dbStorage.DB()
+	if err != nil {
+		return false, fmt.Errorf("get sql db: %w", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("get connection: %w", err)
+	}
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", r.key).Scan(&locked)
+	if err != nil || !locked {
+		conn.Close()
+		return false, err
+	}
+	r.conn = conn
+	return true, nil
+}
+
+// Check checks that connection holding the lock is alive.
+func (r *LeaderLockPG) Check(ctx context.Context) error {
+	if r.conn == nil {
+		return errors.New("lock is not held")
+	}
+	return r.conn.PingContext(ctx)
+}
+
+// Unlock releases the lock and closes its connection.
+func (r *LeaderLockPG) Unlock(ctx context.Context) error {
+	if r.conn == nil {
+		return nil
+	}
+	defer func() {
+		r.conn.Close()
+		r.conn = nil
+	}()
+
+	_, err := r.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", r.key)
+	if err != nil {
+		// discard connection instead of returning it into pool
+		// so the lock is released with its session
+		_ = r.conn.Raw(func(any) error { return driver.ErrBadConn })
+		return fmt.Errorf("unlock: %w", err)
+	}
+	return nil
+}
@@ -1,11 +1,15 @@
 package pg
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"CryptocoinPrice/internal/app/entity"
 	"CryptocoinPrice/internal/app/repo"
@@ -13,6 +17,39 @@ import (
 
 var _ repo.PriceRepoDB = (*PriceRepoPG)(nil)
 
+// columns of prices table for each time axis
+var _timeAxisColumns = map[string]string{
+	entity.TimeAxisSource:   "timestamp",
+	entity.TimeAxisIngested: "ingested_at",
+}
+
+// columns of prices table unique index identifying price
+var _priceUniqueColumns = []clause.Column{
+	{Name: "coin_id"}, {Name: "currency"}, {Name: "timestamp"}, {Name: "source"},
+}
+
+// orders of prices by source timestamp and ID with row comparison
+// operator to read prices after cursor
+var _orders = map[string]struct {
+	orderBy, operator string
+}{
+	entity.OrderAsc:  {orderBy: "timestamp, id", operator: ">"},
+	entity.OrderDesc: {orderBy: "timestamp DESC, id DESC", operator: "<"},
+}
+
+// max amount of rows inserted or selected by IDs with one query
+// (to not exceed limit of query params of PostgreSQL)
+const _batchSize = 1000
+
+// classes of SQLSTATE codes of errors of DB availability
+var _unavailableStateClasses = []string{
+	"08", // connection exception
+	"40", // transaction rollback (e.g. deadlock)
+	"53", // insufficient resources
+	"57", // operator intervention (e.g. DB shutdown)
+	"58", // system error
+}
+
 type PriceRepoPG struct {
 	dbStorage *gorm.DB
 }
@@ -25,57 +62,219 @@ func NewPriceRepoPG(dbStorage *gorm.DB) *PriceRepoPG {
 }
 
 // Create creates new price.
-// Coin ID must be presented in the given coin instance.
-// Also this coin instance passes into the price instance.
-func (r *PriceRepoPG) Create(coin *entity.Coin,
-	price float64, timestamp int64) (*entity.Price, error) {
-
-	priceObj := &entity.Price{
-		ID:        uuid.NewString(),
-		CoinID:    coin.ID,
-		Price:     fmt.Sprint(price),
-		Timestamp: timestamp,
-		Coin:      coin,
-	}
-	if err := r.dbStorage.Create(priceObj).Error; err != nil {
+// All fields must be presented apart of ID. ID is autogenerated.
+// Coin ID must be presented in the given price instance.
+func (r *PriceRepoPG) Create(price *entity.Price) (*entity.Price, error) {
+	generatePriceIDs(price)
+	if err := r.dbStorage.Create(price).Error; err != nil {
 		return nil, err
 	}
-	return priceObj, nil
+	return price, nil
 }
 
-// CreateMany saves new prices into DB.
+// CreateMany saves new prices into DB. Prices which are already saved
+// (with the same coin, currency, source and source timestamp) are skipped
+// with its quotes, so saving of the same prices again (e.g. replay or
+// backfill) is idempotent. It returns only newly saved prices.
+// If DB rejects prices themselves (not because it is unavailable)
+// rejected data error is returned. Saving is canceled if context is done.
+// Prices and quotes are saved in batches to save many prices (e.g. backfill).
 // All fields must be presented apart of ID. ID is autogenerated.
-func (r *PriceRepoPG) CreateMany(priceList entity.PriceList) (entity.PriceList, error) {
+func (r *PriceRepoPG) CreateMany(ctx context.Context,
+	priceList entity.PriceList) (entity.PriceList, error) {
+
+	if len(priceList) == 0 {
+		return priceList, nil
+	}
 	// generate uuids
+	priceIDs := make([]string, 0, len(priceList))
 	for i := range priceList {
-		priceList[i].ID = uuid.NewString()
+		generatePriceIDs(&priceList[i])
+		priceIDs = append(priceIDs, priceList[i].ID)
 	}
-	// save prices
-	if err := r.dbStorage.Create(&priceList).Error; err != nil {
-		return nil, err
+
+	savedList := make(entity.PriceList, 0, len(priceList))
+	err := r.dbStorage.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// save prices skipping duplicates
+		err := tx.Omit(clause.Associations).
+			Clauses(clause.OnConflict{Columns: _priceUniqueColumns, DoNothing: true}).
+			CreateInBatches(&priceList, _batchSize).Error
+		if err != nil {
+			return err
+		}
+		// get ids of prices which are not skipped
+		saved := make(map[string]struct{}, len(priceIDs))
+		for batchIDs := range slices.Chunk(priceIDs, _batchSize) {
+			savedIDs := make([]string, 0, len(batchIDs))
+			err = tx.Model(&entity.Price{}).Where("id IN ?", batchIDs).
+				Pluck("id", &savedIDs).Error
+			if err != nil {
+				return err
+			}
+			for _, id := range savedIDs {
+				saved[id] = struct{}{}
+			}
+		}
+
+		// save quotes of saved prices only
+		quoteList := make(entity.PriceQuoteList, 0)
+		for _, price := range priceList {
+			if _, found := saved[price.ID]; !found {
+				continue
+			}
+			savedList = append(savedList, price)
+			for _, quote := range price.Quotes {
+				quote.PriceID = price.ID
+				quoteList = append(quoteList, quote)
+			}
+		}
+		if len(quoteList) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&quoteList, _batchSize).Error
+	})
+	if err != nil {
+		return nil, wrapRejected(err)
 	}
-	return priceList, nil
+	return savedList, nil
 }
 
-// GetNearestTimestamp returns price for given coin at the
-// given timestamp or the nearest timestamp from the given timestamp.
+// GetSurrounding returns prices for given coin in given quote currency
+// surrounding the given timestamp: the last price not later than the
+// timestamp and the first price not earlier than it (the same price if
+// it is at the timestamp). Time axis (source or ingestion timestamp) is
+// used to search prices. Both prices are searched by index. If max distance
+// is positive prices farther from the timestamp (in seconds) are not searched.
+// Not found price is nil.
 // Coin ID must be presented in the given coin instance.
-// Also this coin instance passes into the price instance.
-func (r *PriceRepoPG) GetNearestTimestamp(coin *entity.Coin,
-	timestamp int64) (*entity.Price, error) {
+// Also this coin instance passes into the price instances.
+func (r *PriceRepoPG) GetSurrounding(coin *entity.Coin, currency string, timestamp int64,
+	timeAxis string, maxDistance int64) (before, after *entity.Price, err error) {
 
-	price := &entity.Price{Coin: coin}
-	err := r.dbStorage.Raw(`
-		SELECT * FROM prices WHERE coin_id = ? ORDER BY ABS(timestamp - ?) LIMIT 1`,
-		coin.ID, timestamp).
-		Scan(price).Error
+	column, found := _timeAxisColumns[timeAxis]
+	if !found {
+		return nil, nil, fmt.Errorf("%w: unknown time axis %q", repo.ErrValidateData, timeAxis)
+	}
+	from, to := int64(math.MinInt64), int64(math.MaxInt64)
+	if maxDistance > 0 {
+		from, to = timestamp-maxDistance, timestamp+maxDistance
+	}
 
+	before, err = r.getFirst(coin, currency, column+" DESC",
+		fmt.Sprintf("%s BETWEEN ? AND ?", column), from, timestamp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get price before: %w", err)
+	}
+	after, err = r.getFirst(coin, currency, column,
+		fmt.Sprintf("%s BETWEEN ? AND ?", column), timestamp, to)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get price after: %w", err)
+	}
+	return before, after, nil
+}
+
+// getFirst returns the first price for given coin in given quote currency
+// matching given condition in given order. Price is nil if it is not found.
+func (r *PriceRepoPG) getFirst(coin *entity.Coin, currency, order string,
+	condition string, args ...any) (*entity.Price, error) {
+
+	priceList := make(entity.PriceList, 0, 1)
+	err := r.dbStorage.
+		Where("coin_id = ? AND currency = ?", coin.ID, currency).
+		Where(condition, args...).
+		Order(order).
+		Limit(1).
+		Find(&priceList).Error
+	if err != nil {
+		return nil, err
+	}
 	// if record is not found
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, repo.ErrNotFound
+	if len(priceList) == 0 {
+		return nil, nil // nolint:nilnil // price is optional
+	}
+	priceList[0].Coin = coin
+	return &priceList[0], nil
+}
+
+// GetRange returns prices of given coin in given quote currency with source
+// timestamp from given timestamp to given one inclusive ordered by source
+// timestamp and ID in given order. If cursor is given only prices after it
+// in given order are returned. At most limit prices are returned.
+func (r *PriceRepoPG) GetRange(coinID, currency string, from, to int64, order string,
+	after *entity.PriceCursor, limit int) (entity.PriceList, error) {
+
+	ordering, found := _orders[order]
+	if !found {
+		return nil, fmt.Errorf("%w: unknown order %q", repo.ErrValidateData, order)
+	}
+
+	query := r.dbStorage.
+		Where("coin_id = ? AND currency = ? AND timestamp BETWEEN ? AND ?",
+			coinID, currency, from, to)
+	if after != nil {
+		query = query.Where(fmt.Sprintf("(timestamp, id) %s (?, ?)", ordering.operator),
+			after.Timestamp, after.ID)
 	}
+	priceList := make(entity.PriceList, 0, limit)
+	err := query.Order(ordering.orderBy).Limit(limit).Find(&priceList).Error
 	if err != nil {
 		return nil, err
 	}
-	return price, nil
+	return priceList, nil
+}
+
+// GetLatestTimestamps returns latest source timestamp of prices of given
+// coins in each quote currency from each source. Only coin ID, currency,
+// source and timestamp are filled in returned prices.
+func (r *PriceRepoPG) GetLatestTimestamps(coinIDs []string) (entity.PriceList, error) {
+	priceList := make(entity.PriceList, 0)
+	if len(coinIDs) == 0 {
+		return priceList, nil
+	}
+
+	err := r.dbStorage.Raw(`
+		SELECT coin_id, currency, source, MAX(timestamp) AS timestamp FROM prices
+		WHERE coin_id IN ? GROUP BY coin_id, currency, source`,
+		coinIDs).
+		Scan(&priceList).Error
+	if err != nil {
+		return nil, err
+	}
+	return priceList, nil
+}
+
+// GetTimestamps returns source timestamps of saved prices of given coin
+// in given quote currency from given timestamp to given one inclusive.
+func (r *PriceRepoPG) GetTimestamps(coinID, currency string, from, to int64) ([]int64, error) {
+	timestamps := make([]int64, 0)
+	err := r.dbStorage.Model(&entity.Price{}).
+		Where("coin_id = ? AND currency = ? AND timestamp BETWEEN ? AND ?",
+			coinID, currency, from, to).
+		Pluck("timestamp", &timestamps).Error
+	if err != nil {
+		return nil, err
+	}
+	return timestamps, nil
+}
+
+// wrapRejected wraps given DB error with rejected data error if DB rejects
+// saved data itself, so saving the same data again is failed too. Errors of
+// connection and DB availability (which may be temporary) are returned as is.
+func wrapRejected(err error) error {
+	var stateErr interface{ SQLState() string }
+	if !errors.As(err, &stateErr) || len(stateErr.SQLState()) < 2 {
+		return err
+	}
+	if slices.Contains(_unavailableStateClasses, stateErr.SQLState()[:2]) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", repo.ErrRejectedData, err)
+}
+
+// generatePriceIDs generates uuids for price and its quotes.
+func generatePriceIDs(price *entity.Price) {
+	price.ID = uuid.NewString()
+	for i := range price.Quotes {
+		price.Quotes[i].ID = uuid.NewString()
+	}
 }
@@ -0,0 +1,193 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ repo.PriceRepoAPI = (*CircuitBreaker)(nil)
+
+const (
+	StateClosed   = "closed"    // provider is requested as usual
+	StateOpen     = "open"      // provider is not requested until cool-down is over
+	StateHalfOpen = "half-open" // one trial request to provider is allowed
+)
+
+// CircuitBreaker is a price API repo decorator. It opens after the
+// given amount of consecutive provider failures and fails fast with
+// circuit open error without requests to provider. After cool-down
+// it half-opens and lets one trial request through: its success
+// closes the breaker and its failure opens the breaker again.
+type CircuitBreaker struct {
+	provider NamedPriceRepoAPI
+	// amount of consecutive failures to open breaker
+	maxFailures int
+	// time to keep breaker open before trial request
+	coolDown time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	// true if trial request is in progress in half-open state
+	trial bool
+}
+
+// NewCircuitBreaker returns new closed circuit breaker for given provider.
+func NewCircuitBreaker(provider NamedPriceRepoAPI, maxFailures int,
+	coolDown time.Duration) *CircuitBreaker {
+
+	return &CircuitBreaker{
+		provider:    provider,
+		maxFailures: maxFailures,
+		coolDown:    coolDown,
+		state:       StateClosed,
+	}
+}
+
+// OneCoinPrice returns coin price from provider if breaker is not open.
+// Request canceled by context is not considered as provider failure.
+func (b *CircuitBreaker) OneCoinPrice(ctx context.Context, coin *entity.Coin,
+	currency string) (*entity.CoinPriceAPI, error) {
+
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	coinPrice, err := b.provider.OneCoinPrice(ctx, coin, currency)
+	if err != nil && ctx.Err() != nil {
+		b.abort()
+		return nil, err
+	}
+	b.done(isProviderFailure(err))
+	return coinPrice, err
+}
+
+// ManyCoinPrices returns coins' prices from provider if breaker is not open.
+// Partial result and request canceled by context are not considered
+// as provider failure.
+func (b *CircuitBreaker) ManyCoinPrices(ctx context.Context, coins entity.CoinList,
+	currencies []string) (entity.CoinPriceAPIList, error) {
+
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	coinPrices, err := b.provider.ManyCoinPrices(ctx, coins, currencies)
+	if len(coinPrices) == 0 && err != nil && ctx.Err() != nil {
+		b.abort()
+		return nil, err
+	}
+	b.done(len(coinPrices) == 0 && isProviderFailure(err))
+	return coinPrices, err
+}
+
+// Status returns current breaker status.
+func (b *CircuitBreaker) Status() entity.ProviderStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := entity.ProviderStatus{
+		Name:     b.provider.Name,
+		State:    b.currentState(),
+		Failures: b.failures,
+	}
+	if !b.openedAt.IsZero() {
+		status.OpenedAt = b.openedAt.Unix()
+	}
+	if status.State == StateOpen {
+		status.RetryAfter = int64(b.retryAfter().Seconds())
+	}
+	return status
+}
+
+// allow returns circuit open error if request to provider is not allowed.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState() {
+	case StateOpen:
+		return &repo.CircuitOpenError{Provider: b.provider.Name, RetryAfter: b.retryAfter()}
+	case StateHalfOpen:
+		// only one trial request at a time
+		if b.trial {
+			return &repo.CircuitOpenError{Provider: b.provider.Name, RetryAfter: time.Second}
+		}
+		if b.state != StateHalfOpen {
+			b.state = StateHalfOpen
+			logrus.Infof("Circuit breaker of %s provider is half-open: trial request",
+				b.provider.Name)
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// abort finishes request to provider without result keeping breaker state.
+func (b *CircuitBreaker) abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+// done records result of request to provider and changes breaker state.
+func (b *CircuitBreaker) done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	trial := b.trial
+	b.trial = false
+
+	if !failed {
+		if b.state != StateClosed {
+			logrus.Infof("Circuit breaker of %s provider is closed", b.provider.Name)
+		}
+		b.state, b.failures = StateClosed, 0
+		return
+	}
+
+	b.failures++
+	if trial || b.failures >= b.maxFailures {
+		b.state, b.openedAt = StateOpen, time.Now()
+		logrus.Warnf("Circuit breaker of %s provider is open for %s after %d failures",
+			b.provider.Name, b.coolDown, b.failures)
+	}
+}
+
+// currentState returns breaker state considering cool-down.
+// It must be called under mutex.
+func (b *CircuitBreaker) currentState() string {
+	if b.state == StateOpen && b.retryAfter() <= 0 {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// retryAfter returns time until the end of cool-down.
+// It must be called under mutex.
+func (b *CircuitBreaker) retryAfter() time.Duration {
+	return b.coolDown - time.Since(b.openedAt)
+}
+
+// isProviderFailure returns true if error means provider failure.
+// Invalid coins and exhausted local quota are not provider failures.
+// Coin prices error is provider failure if one of its coin price errors is.
+func isProviderFailure(err error) bool {
+	var pricesErr *repo.CoinPricesError
+	if errors.As(err, &pricesErr) {
+		return slices.ContainsFunc(pricesErr.Errs, func(coinErr *repo.CoinPriceError) bool {
+			return isProviderFailure(coinErr)
+		})
+	}
+	return err != nil &&
+		!errors.Is(err, repo.ErrValidateData) &&
+		!errors.Is(err, repo.ErrQuotaExhausted)
+}
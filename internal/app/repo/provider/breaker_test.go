@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+const _testCoolDown = 50 * time.Millisecond // cool-down of test circuit breakers
+
+// newTestBreaker returns circuit breaker of given stub
+// opening after 2 consecutive failures.
+func newTestBreaker(stub *stubPriceRepoAPI) *CircuitBreaker {
+	return NewCircuitBreaker(NamedPriceRepoAPI{PriceRepoAPI: stub, Name: stub.name},
+		2, _testCoolDown) // nolint:mnd // amount of failures
+}
+
+func TestCircuitBreaker_OpenAndRecover(t *testing.T) {
+	t.Log("Open breaker after consecutive failures and close it after trial request")
+
+	stub := &stubPriceRepoAPI{
+		name: "primary", prices: map[string]float64{"btc": 100}, err: errors.New("timeout"),
+	}
+	breaker := newTestBreaker(stub)
+	coin := &entity.Coin{Symbol: "btc"}
+
+	for range 2 {
+		_, err := breaker.OneCoinPrice(context.Background(), coin, "usd")
+		require.NotErrorIs(t, err, repo.ErrCircuitOpen)
+	}
+	require.Equal(t, StateOpen, breaker.Status().State)
+
+	// fail fast without request to provider
+	stub.err = nil
+	_, err := breaker.OneCoinPrice(context.Background(), coin, "usd")
+	var openErr *repo.CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+	require.Positive(t, openErr.RetryAfter)
+	t.Logf("Expected error: %v", err)
+
+	// trial request after cool-down
+	time.Sleep(_testCoolDown)
+	require.Equal(t, StateHalfOpen, breaker.Status().State)
+	_, err = breaker.OneCoinPrice(context.Background(), coin, "usd")
+	require.NoError(t, err)
+	require.Equal(t, StateClosed, breaker.Status().State)
+	require.Zero(t, breaker.Status().Failures)
+}
+
+func TestCircuitBreaker_TrialFailed(t *testing.T) {
+	t.Log("Open breaker again if trial request is failed")
+
+	stub := &stubPriceRepoAPI{name: "primary", err: errors.New("timeout")}
+	breaker := newTestBreaker(stub)
+
+	for range 2 {
+		_, _ = breaker.ManyCoinPrices(context.Background(), entity.CoinList{{Symbol: "btc"}}, []string{"usd"})
+	}
+	time.Sleep(_testCoolDown)
+	_, err := breaker.ManyCoinPrices(context.Background(), entity.CoinList{{Symbol: "btc"}}, []string{"usd"})
+	require.NotErrorIs(t, err, repo.ErrCircuitOpen)
+	require.Equal(t, StateOpen, breaker.Status().State)
+}
+
+func TestCircuitBreaker_InvalidCoin(t *testing.T) {
+	t.Log("Keep breaker closed if coin is invalid")
+
+	stub := &stubPriceRepoAPI{name: "primary", prices: map[string]float64{}}
+	breaker := newTestBreaker(stub)
+
+	for range 3 {
+		_, err := breaker.OneCoinPrice(context.Background(), &entity.Coin{Symbol: "unexisting"}, "usd")
+		require.ErrorIs(t, err, repo.ErrValidateData)
+	}
+	require.Equal(t, StateClosed, breaker.Status().State)
+}
+
+func TestCircuitBreaker_InvalidCoins(t *testing.T) {
+	t.Log("Keep breaker closed if all requested coins are invalid")
+
+	stub := &stubPriceRepoAPI{name: "primary", prices: map[string]float64{}}
+	breaker := newTestBreaker(stub)
+	coins := entity.CoinList{{Symbol: "unexisting"}, {Symbol: "unknown"}}
+
+	for range 3 {
+		coinPrices, err := breaker.ManyCoinPrices(context.Background(), coins, []string{"usd"})
+		require.Empty(t, coinPrices)
+		require.ErrorIs(t, err, repo.ErrValidateData)
+	}
+	require.Equal(t, StateClosed, breaker.Status().State)
+	require.Zero(t, breaker.Status().Failures)
+}
+
+func TestCircuitBreaker_CoinProviderFailure(t *testing.T) {
+	t.Log("Count failure if one of coin prices is failed by provider")
+
+	pricesErr := repo.NewCoinPricesError([]*repo.CoinPriceError{
+		{Symbol: "unexisting", Currency: "usd", Err: repo.ErrValidateData},
+		{Symbol: "btc", Currency: "usd", Err: errors.New("timeout")},
+	})
+	require.ErrorIs(t, pricesErr, repo.ErrValidateData)
+	require.True(t, isProviderFailure(pricesErr))
+	require.False(t, isProviderFailure(repo.NewCoinPricesError([]*repo.CoinPriceError{
+		{Symbol: "unexisting", Currency: "usd", Err: repo.ErrValidateData},
+	})))
+}
+
+func TestFailover_AllCircuitsOpen(t *testing.T) {
+	t.Log("Return circuit open error if circuits of all providers are open")
+
+	primary := newTestBreaker(&stubPriceRepoAPI{name: "primary", err: errors.New("timeout")})
+	secondary := newTestBreaker(&stubPriceRepoAPI{name: "secondary", err: errors.New("timeout")})
+	failover := NewFailover(
+		NamedPriceRepoAPI{PriceRepoAPI: primary, Name: "primary"},
+		NamedPriceRepoAPI{PriceRepoAPI: secondary, Name: "secondary"},
+	)
+
+	for range 2 {
+		_, err := failover.OneCoinPrice(context.Background(), &entity.Coin{Symbol: "btc"}, "usd")
+		require.NotErrorIs(t, err, repo.ErrCircuitOpen)
+	}
+	_, err := failover.OneCoinPrice(context.Background(), &entity.Coin{Symbol: "btc"}, "usd")
+	require.ErrorIs(t, err, repo.ErrCircuitOpen)
+	_, err = failover.ManyCoinPrices(context.Background(), entity.CoinList{{Symbol: "btc"}}, []string{"usd"})
+	require.ErrorIs(t, err, repo.ErrCircuitOpen)
+	t.Logf("Expected error: %v", err)
+}
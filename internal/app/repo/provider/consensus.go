@@ -0,0 +1,213 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ repo.PriceRepoAPI = (*Consensus)(nil)
+
+const ConsensusSource = "consensus" // source name of consensus prices
+
+// Consensus is a composite price API repo. It asks all providers
+// concurrently and returns the median of their quotes as a coin price.
+// Quotes deviating from the median more than the max deviation
+// are rejected and the median is recalculated without them.
+type Consensus struct {
+	providers []NamedPriceRepoAPI
+	// max allowed deviation from the median in percents
+	maxDeviation float64
+}
+
+// NewConsensus returns new consensus of given providers.
+// The maxDeviation is a max allowed deviation of quote
+// from the median of all quotes in percents.
+func NewConsensus(maxDeviation float64, providers ...NamedPriceRepoAPI) *Consensus {
+	return &Consensus{
+		providers:    providers,
+		maxDeviation: maxDeviation,
+	}
+}
+
+// OneCoinPrice returns consensus coin price. It returns validate data
+// error only if all providers consider the coin symbol invalid and
+// circuit open error only if circuits of all providers are open.
+func (c *Consensus) OneCoinPrice(ctx context.Context, coin *entity.Coin,
+	currency string) (*entity.CoinPriceAPI, error) {
+
+	quotes := make([]entity.CoinPriceAPI, 0, len(c.providers))
+	providerErrs := newProviderErrors(len(c.providers))
+
+	for result := range c.requestAll(func(provider NamedPriceRepoAPI) (entity.CoinPriceAPIList, error) {
+		coinPrice, err := provider.OneCoinPrice(ctx, coin, currency)
+		if err != nil {
+			return nil, err
+		}
+		return entity.CoinPriceAPIList{*coinPrice}, nil
+	}) {
+		if result.err != nil {
+			providerErrs.add(result.name, result.err)
+			continue
+		}
+		quotes = append(quotes, result.coinPrices...)
+	}
+
+	if len(quotes) == 0 {
+		return nil, providerErrs.err()
+	}
+	return c.consensusPrice(priceKey{coinID: coin.ID, symbol: coin.Symbol, currency: currency},
+		quotes)
+}
+
+// ManyCoinPrices returns consensus coins' prices.
+// Coins which are missing in all providers or have no agreed
+// quotes are skipped and reported in the returned coin prices error.
+func (c *Consensus) ManyCoinPrices(ctx context.Context, coins entity.CoinList,
+	currencies []string) (entity.CoinPriceAPIList, error) {
+
+	quotes := make(map[priceKey][]entity.CoinPriceAPI, len(coins)*len(currencies))
+	providerErrs := newProviderErrors(len(c.providers))
+
+	for result := range c.requestAll(func(provider NamedPriceRepoAPI) (entity.CoinPriceAPIList, error) {
+		return provider.ManyCoinPrices(ctx, coins, currencies)
+	}) {
+		if result.err != nil {
+			logrus.Warnf("Get coin prices from %s: %v", result.name, result.err)
+			providerErrs.add(result.name, result.err)
+		}
+		for _, coinPrice := range result.coinPrices {
+			key := priceKey{
+				coinID: coinPrice.CoinID, symbol: coinPrice.Symbol, currency: coinPrice.Currency,
+			}
+			quotes[key] = append(quotes[key], coinPrice)
+		}
+	}
+
+	// if no one provider returned prices
+	if len(quotes) == 0 && len(providerErrs.errList) == len(c.providers) {
+		return nil, providerErrs.err()
+	}
+
+	coinPricesList := make(entity.CoinPriceAPIList, 0, len(coins)*len(currencies))
+	errList := make([]*repo.CoinPriceError, 0)
+	for _, coin := range coins {
+		for _, currency := range currencies {
+			key := priceKey{coinID: coin.ID, symbol: coin.Symbol, currency: currency}
+			coinPrice, err := c.consensusPrice(key, quotes[key])
+			if err != nil {
+				errList = append(errList, &repo.CoinPriceError{
+					CoinID: coin.ID, Symbol: coin.Symbol, Currency: currency, Err: err,
+				})
+				continue
+			}
+			coinPricesList = append(coinPricesList, *coinPrice)
+		}
+	}
+	return coinPricesList, repo.NewCoinPricesError(errList)
+}
+
+// providerResult is a result of request to one provider.
+type providerResult struct {
+	name       string
+	coinPrices entity.CoinPriceAPIList
+	err        error
+}
+
+// requestAll calls request for each provider concurrently
+// and returns channel with results. The channel is
+// closed after all providers have responded.
+func (c *Consensus) requestAll(
+	request func(provider NamedPriceRepoAPI) (entity.CoinPriceAPIList, error),
+) <-chan providerResult {
+
+	results := make(chan providerResult, len(c.providers))
+	var wg sync.WaitGroup // nolint:varnamelen // generally accepted name
+	for _, provider := range c.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			coinPrices, err := request(provider)
+			results <- providerResult{name: provider.Name, coinPrices: coinPrices, err: err}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// consensusPrice returns median price of given provider quotes for coin.
+// All given quotes are kept in the result and deviated ones are marked rejected.
+func (c *Consensus) consensusPrice(key priceKey,
+	quotes []entity.CoinPriceAPI) (*entity.CoinPriceAPI, error) {
+
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("%w: coin %s is not found", repo.ErrValidateData, key)
+	}
+
+	prices := make([]decimal.Decimal, 0, len(quotes))
+	for _, quote := range quotes {
+		prices = append(prices, quote.Price)
+	}
+	rawMedian := median(prices)
+
+	coinPrice := &entity.CoinPriceAPI{
+		CoinID:   key.coinID,
+		Symbol:   key.symbol,
+		Currency: key.currency,
+		Source:   ConsensusSource,
+		Quotes:   make(entity.PriceQuoteList, 0, len(quotes)),
+	}
+	accepted := make([]decimal.Decimal, 0, len(quotes))
+	for _, quote := range quotes {
+		deviation := deviationPercents(quote.Price, rawMedian)
+		rejected := deviation > c.maxDeviation
+		if rejected {
+			logrus.Warnf("Reject coin %s quote from %s: %s deviates %.2f%% from median %s",
+				key, quote.Source, quote.Price, deviation, rawMedian)
+		} else {
+			accepted = append(accepted, quote.Price)
+			coinPrice.LastUpdate = max(coinPrice.LastUpdate, quote.LastUpdate)
+		}
+		coinPrice.Quotes = append(coinPrice.Quotes, entity.PriceQuote{
+			Source:    quote.Source,
+			Price:     quote.Price,
+			Timestamp: quote.LastUpdate,
+			Rejected:  rejected,
+		})
+	}
+
+	if len(accepted) == 0 {
+		return nil, fmt.Errorf("coin %s: no one quote is agreed: %v", key, prices)
+	}
+	coinPrice.Price = median(accepted)
+	return coinPrice, nil
+}
+
+// median returns median of given non-empty values.
+func median(values []decimal.Decimal) decimal.Decimal {
+	sorted := slices.SortedFunc(slices.Values(values), decimal.Decimal.Cmp)
+	mid := len(sorted) / 2 // nolint:mnd // half of slice
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return sorted[mid-1].Add(sorted[mid]).Div(decimal.NewFromInt(2)) // nolint:mnd // mean of two values
+}
+
+// deviationPercents returns deviation of value from median in percents.
+// Any value deviates from zero median by zero percents.
+func deviationPercents(value, median decimal.Decimal) float64 {
+	if median.IsZero() {
+		return 0
+	}
+	return value.Sub(median).Abs().Div(median).InexactFloat64() * 100 // nolint:mnd // percents
+}
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+// newTestConsensus returns consensus of given stubs with 5% max deviation.
+func newTestConsensus(stubs ...*stubPriceRepoAPI) *Consensus {
+	providers := make([]NamedPriceRepoAPI, 0, len(stubs))
+	for _, stub := range stubs {
+		providers = append(providers, NamedPriceRepoAPI{PriceRepoAPI: stub, Name: stub.name})
+	}
+	return NewConsensus(5, providers...) // nolint:mnd // test deviation
+}
+
+func TestConsensus_ManyCoinPricesRejectDeviated(t *testing.T) {
+	t.Log("Get median price and reject deviated quote")
+
+	consensus := newTestConsensus(
+		&stubPriceRepoAPI{name: "first", prices: map[string]float64{"btc": 100, "eth": 10}},
+		&stubPriceRepoAPI{name: "second", prices: map[string]float64{"btc": 102, "eth": 11}},
+		&stubPriceRepoAPI{name: "third", prices: map[string]float64{"btc": 150}},
+	)
+	coinPricesList, err := consensus.ManyCoinPrices(context.Background(),
+		entity.CoinList{{Symbol: "btc"}, {Symbol: "eth"}}, []string{"usd"})
+	require.NoError(t, err)
+	require.Len(t, coinPricesList, 2)
+
+	btcPrice := coinPricesList[0]
+	require.Equal(t, ConsensusSource, btcPrice.Source)
+	require.Equal(t, "101", btcPrice.Price.String())
+	require.Len(t, btcPrice.Quotes, 3)
+	for _, quote := range btcPrice.Quotes {
+		require.Equal(t, quote.Source == "third", quote.Rejected)
+	}
+	require.Equal(t, "10.5", coinPricesList[1].Price.String())
+}
+
+func TestConsensus_ManyCoinPricesProviderDown(t *testing.T) {
+	t.Log("Get consensus price without failed provider")
+
+	consensus := newTestConsensus(
+		&stubPriceRepoAPI{name: "first", err: errors.New("request to api: timeout")},
+		&stubPriceRepoAPI{name: "second", prices: map[string]float64{"btc": 100}},
+	)
+	coinPricesList, err := consensus.ManyCoinPrices(context.Background(),
+		entity.CoinList{{Symbol: "btc"}}, []string{"usd"})
+	require.NoError(t, err)
+	require.Equal(t, "100", coinPricesList[0].Price.String())
+	require.Len(t, coinPricesList[0].Quotes, 1)
+}
+
+func TestConsensus_ManyCoinPricesNoAgreement(t *testing.T) {
+	t.Log("Get error if no one quote is agreed")
+
+	consensus := newTestConsensus(
+		&stubPriceRepoAPI{name: "first", prices: map[string]float64{"btc": 100}},
+		&stubPriceRepoAPI{name: "second", prices: map[string]float64{"btc": 200}},
+	)
+	coinPricesList, err := consensus.ManyCoinPrices(context.Background(),
+		entity.CoinList{{Symbol: "btc"}}, []string{"usd"})
+	require.Error(t, err)
+	require.Empty(t, coinPricesList)
+	t.Logf("Expected error: %v", err)
+}
+
+func TestConsensus_OneCoinPriceInvalid(t *testing.T) {
+	t.Log("Get validate data error if all providers do not know the coin")
+
+	consensus := newTestConsensus(
+		&stubPriceRepoAPI{name: "first", prices: map[string]float64{}},
+		&stubPriceRepoAPI{name: "second", prices: map[string]float64{}},
+	)
+	_, err := consensus.OneCoinPrice(context.Background(), &entity.Coin{Symbol: "unexisting"}, "usd")
+	require.ErrorIs(t, err, repo.ErrValidateData)
+}
@@ -0,0 +1,264 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ repo.PriceRepoAPI = (*Failover)(nil)
+
+// NamedPriceRepoAPI is a price API repo with its provider name.
+type NamedPriceRepoAPI struct {
+	repo.PriceRepoAPI
+	// provider name
+	Name string
+}
+
+// Failover is a composite price API repo. It asks providers in
+// the given order and fills prices which the previous providers
+// failed to return from the next providers.
+type Failover struct {
+	providers []NamedPriceRepoAPI
+}
+
+// NewFailover returns new failover chain of given ordered providers.
+// The first provider is the primary one.
+func NewFailover(providers ...NamedPriceRepoAPI) *Failover {
+	return &Failover{
+		providers: providers,
+	}
+}
+
+// OneCoinPrice returns coin price from the first provider that
+// succeeded to get it. It returns validate data error only
+// if all providers consider the coin symbol invalid and
+// circuit open error only if circuits of all providers are open.
+// The next providers are not asked if context is done.
+func (f *Failover) OneCoinPrice(ctx context.Context, coin *entity.Coin,
+	currency string) (*entity.CoinPriceAPI, error) {
+
+	providerErrs := newProviderErrors(len(f.providers))
+	for _, provider := range f.providers {
+		coinPrice, err := provider.OneCoinPrice(ctx, coin, currency)
+		if err == nil {
+			return coinPrice, nil
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("get coin price from %s: %w", provider.Name, ctx.Err())
+		}
+		logrus.Warnf("Get coin %s price in %s from %s: %v",
+			coin.Symbol, currency, provider.Name, err)
+		providerErrs.add(provider.Name, err)
+	}
+	return nil, providerErrs.err()
+}
+
+// ManyCoinPrices returns coins' prices from the primary provider.
+// If the provider fails or returns not all prices, the missing
+// prices are requested from the next provider and so on.
+// Every price keeps the name of provider which supplied it.
+// Prices missing in all providers are reported in the returned
+// coin prices error with the error of the last provider.
+// The next providers are not asked if context is done.
+func (f *Failover) ManyCoinPrices(ctx context.Context, coins entity.CoinList,
+	currencies []string) (entity.CoinPriceAPIList, error) {
+
+	coinPricesList := make(entity.CoinPriceAPIList, 0, len(coins)*len(currencies))
+	// all requested prices are missing at the beginning
+	missing := make(priceKeySet, len(coins)*len(currencies))
+	for _, coin := range coins {
+		for _, currency := range currencies {
+			missing[priceKey{coinID: coin.ID, symbol: coin.Symbol, currency: currency}] = struct{}{}
+		}
+	}
+
+	// last provider error for each missing price
+	missingErrs := make(map[priceKey]error, len(missing))
+	providerErrs := newProviderErrors(len(f.providers))
+	for _, provider := range f.providers {
+		if len(missing) == 0 || ctx.Err() != nil {
+			break
+		}
+
+		missingCoins, missingCurrencies := missing.split(coins)
+		coinPrices, err := provider.ManyCoinPrices(ctx, missingCoins, missingCurrencies)
+		// take only missing prices (some of them might be received before)
+		for _, coinPrice := range coinPrices {
+			key := priceKey{
+				coinID: coinPrice.CoinID, symbol: coinPrice.Symbol, currency: coinPrice.Currency,
+			}
+			if _, found := missing[key]; found {
+				coinPricesList = append(coinPricesList, coinPrice)
+				delete(missing, key)
+			}
+		}
+		if len(missing) != 0 {
+			if err == nil {
+				err = errors.New("prices are not returned")
+			}
+			providerErrs.add(provider.Name, err)
+			setMissingErrs(missingErrs, missing, provider.Name, err)
+			logrus.Warnf("Get coin prices from %s: %d prices are missing: %v",
+				provider.Name, len(missing), err)
+		}
+	}
+
+	if len(missing) == 0 {
+		return coinPricesList, nil
+	}
+	// if no one provider returned prices
+	if len(coinPricesList) == 0 {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("get coin prices: %w", ctx.Err())
+		}
+		return nil, providerErrs.err()
+	}
+	errList := make([]*repo.CoinPriceError, 0, len(missing))
+	for _, key := range missing.sorted() {
+		errList = append(errList, &repo.CoinPriceError{
+			CoinID: key.coinID, Symbol: key.symbol, Currency: key.currency, Err: missingErrs[key],
+		})
+	}
+	return coinPricesList, repo.NewCoinPricesError(errList)
+}
+
+// setMissingErrs sets error of provider with given name for each missing price.
+// If error is coin prices error its coin price errors are used for prices.
+func setMissingErrs(missingErrs map[priceKey]error, missing priceKeySet,
+	name string, err error) {
+
+	coinErrs := make(map[priceKey]error)
+	var pricesErr *repo.CoinPricesError
+	if errors.As(err, &pricesErr) {
+		for _, coinErr := range pricesErr.Errs {
+			key := priceKey{
+				coinID: coinErr.CoinID, symbol: coinErr.Symbol, currency: coinErr.Currency,
+			}
+			coinErrs[key] = coinErr.Err
+		}
+	}
+	for key := range missing {
+		if coinErr, found := coinErrs[key]; found {
+			missingErrs[key] = fmt.Errorf("%s: %w", name, coinErr)
+			continue
+		}
+		missingErrs[key] = fmt.Errorf("%s: %w", name, err)
+	}
+}
+
+// providerErrors collects errors of failed providers.
+type providerErrors struct {
+	names   []string
+	errList []string
+	// true if all providers consider the coin symbol invalid
+	allInvalid bool
+	// true if circuits of all providers are open
+	allOpen bool
+	// min time until the next attempt to request one of providers
+	retryAfter time.Duration
+}
+
+// newProviderErrors returns new empty provider errors collection.
+func newProviderErrors(capacity int) *providerErrors {
+	return &providerErrors{
+		names:      make([]string, 0, capacity),
+		errList:    make([]string, 0, capacity),
+		allInvalid: true,
+		allOpen:    true,
+	}
+}
+
+// add appends error of provider with given name.
+func (e *providerErrors) add(name string, err error) {
+	e.names = append(e.names, name)
+	e.errList = append(e.errList, fmt.Sprintf("%s: %v", name, err))
+	// coin prices error is invalid if all its coin prices are invalid
+	e.allInvalid = e.allInvalid && errors.Is(err, repo.ErrValidateData) && !isProviderFailure(err)
+
+	var openErr *repo.CircuitOpenError
+	if !errors.As(err, &openErr) {
+		e.allOpen = false
+		return
+	}
+	if len(e.names) == 1 || openErr.RetryAfter < e.retryAfter {
+		e.retryAfter = openErr.RetryAfter
+	}
+}
+
+// err returns validate data error if all providers consider the coin symbol
+// invalid, circuit open error if circuits of all providers are open
+// and common error otherwise.
+func (e *providerErrors) err() error {
+	errStr := strings.Join(e.errList, " && ")
+	switch {
+	case len(e.errList) == 0:
+		return errors.New("no one provider is given")
+	case e.allInvalid:
+		return fmt.Errorf("%w: %s", repo.ErrValidateData, errStr)
+	case e.allOpen:
+		return &repo.CircuitOpenError{
+			Provider:   strings.Join(e.names, ","),
+			RetryAfter: e.retryAfter,
+		}
+	default:
+		return fmt.Errorf("all providers failed: %s", errStr)
+	}
+}
+
+// priceKey is a coin (its uuid and symbol) and quote currency to identify price.
+// Coins sharing the same symbol are told apart by uuid.
+type priceKey struct {
+	coinID   string
+	symbol   string
+	currency string
+}
+
+// String returns price key in "symbol/currency" format.
+func (k priceKey) String() string {
+	return k.symbol + "/" + k.currency
+}
+
+// priceKeySet is a set of price keys.
+type priceKeySet map[priceKey]struct{}
+
+// split returns coins (from the given ones) and sorted
+// unique currencies which are presented in price keys.
+func (s priceKeySet) split(coins entity.CoinList) (entity.CoinList, []string) {
+	type coinKey struct{ coinID, symbol string }
+	keyCoinSet := make(map[coinKey]struct{}, len(s))
+	currencies := make([]string, 0, len(s))
+	for key := range s {
+		keyCoinSet[coinKey{key.coinID, key.symbol}] = struct{}{}
+		currencies = append(currencies, key.currency)
+	}
+	slices.Sort(currencies)
+
+	keyCoins := make(entity.CoinList, 0, len(keyCoinSet))
+	for _, coin := range coins {
+		if _, found := keyCoinSet[coinKey{coin.ID, coin.Symbol}]; found {
+			keyCoins = append(keyCoins, coin)
+		}
+	}
+	return keyCoins, slices.Compact(currencies)
+}
+
+// sorted returns price keys sorted by symbol, coin uuid and currency.
+func (s priceKeySet) sorted() []priceKey {
+	keys := slices.Collect(maps.Keys(s))
+	slices.SortFunc(keys, func(a, b priceKey) int {
+		return cmp.Or(cmp.Compare(a.symbol, b.symbol), cmp.Compare(a.coinID, b.coinID),
+			cmp.Compare(a.currency, b.currency))
+	})
+	return keys
+}
@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+// stubPriceRepoAPI is a price API repo with fixed coin prices.
+// The same coin price is returned for any quote currency.
+type stubPriceRepoAPI struct {
+	name   string
+	prices map[string]float64
+	err    error
+}
+
+func (s *stubPriceRepoAPI) OneCoinPrice(_ context.Context, coin *entity.Coin,
+	currency string) (*entity.CoinPriceAPI, error) {
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	price, found := s.prices[coin.Symbol]
+	if !found {
+		return nil, fmt.Errorf("%w: coin %s is not found", repo.ErrValidateData, coin.Symbol)
+	}
+	return &entity.CoinPriceAPI{
+		Symbol: coin.Symbol, Price: decimal.NewFromFloat(price), Currency: currency, Source: s.name,
+	}, nil
+}
+
+func (s *stubPriceRepoAPI) ManyCoinPrices(_ context.Context, coins entity.CoinList,
+	currencies []string) (entity.CoinPriceAPIList, error) {
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	coinPricesList := make(entity.CoinPriceAPIList, 0, len(coins)*len(currencies))
+	errList := make([]*repo.CoinPriceError, 0)
+	for _, coin := range coins {
+		for _, currency := range currencies {
+			price, found := s.prices[coin.Symbol]
+			if !found {
+				errList = append(errList, &repo.CoinPriceError{
+					Symbol: coin.Symbol, Currency: currency, Err: repo.ErrValidateData,
+				})
+				continue
+			}
+			coinPricesList = append(coinPricesList, entity.CoinPriceAPI{
+				Symbol: coin.Symbol, Price: decimal.NewFromFloat(price), Currency: currency, Source: s.name,
+			})
+		}
+	}
+	return coinPricesList, repo.NewCoinPricesError(errList)
+}
+
+// newTestFailover returns failover of given stubs.
+func newTestFailover(stubs ...*stubPriceRepoAPI) *Failover {
+	providers := make([]NamedPriceRepoAPI, 0, len(stubs))
+	for _, stub := range stubs {
+		providers = append(providers, NamedPriceRepoAPI{PriceRepoAPI: stub, Name: stub.name})
+	}
+	return NewFailover(providers...)
+}
+
+func TestFailover_ManyCoinPricesPartial(t *testing.T) {
+	t.Log("Fill missing coin prices from the next provider")
+
+	failover := newTestFailover(
+		&stubPriceRepoAPI{name: "primary", prices: map[string]float64{"btc": 1}},
+		&stubPriceRepoAPI{name: "secondary", prices: map[string]float64{"btc": 2, "eth": 3}},
+	)
+	coinPricesList, err := failover.ManyCoinPrices(context.Background(),
+		entity.CoinList{{Symbol: "btc"}, {Symbol: "eth"}}, []string{"usd"})
+	require.NoError(t, err)
+	require.Equal(t, entity.CoinPriceAPIList{
+		{Symbol: "btc", Price: decimal.NewFromInt(1), Currency: "usd", Source: "primary"},
+		{Symbol: "eth", Price: decimal.NewFromInt(3), Currency: "usd", Source: "secondary"},
+	}, coinPricesList)
+}
+
+func TestFailover_ManyCoinPricesPrimaryDown(t *testing.T) {
+	t.Log("Get all coin prices from the next provider if the primary is down")
+
+	failover := newTestFailover(
+		&stubPriceRepoAPI{name: "primary", err: errors.New("request to api: timeout")},
+		&stubPriceRepoAPI{name: "secondary", prices: map[string]float64{"btc": 2}},
+	)
+	coinPricesList, err := failover.ManyCoinPrices(context.Background(),
+		entity.CoinList{{Symbol: "btc"}}, []string{"usd"})
+	require.NoError(t, err)
+	require.Equal(t, "secondary", coinPricesList[0].Source)
+}
+
+func TestFailover_ManyCoinPricesMissing(t *testing.T) {
+	t.Log("Get error for coin missing in all providers")
+
+	failover := newTestFailover(
+		&stubPriceRepoAPI{name: "primary", prices: map[string]float64{"btc": 1}},
+		&stubPriceRepoAPI{name: "secondary", prices: map[string]float64{}},
+	)
+	coinPricesList, err := failover.ManyCoinPrices(context.Background(),
+		entity.CoinList{{Symbol: "btc"}, {Symbol: "eth"}}, []string{"usd"})
+	require.Len(t, coinPricesList, 1)
+
+	var pricesErr *repo.CoinPricesError
+	require.ErrorAs(t, err, &pricesErr)
+	require.Len(t, pricesErr.Errs, 1)
+	require.Equal(t, "eth", pricesErr.Errs[0].Symbol)
+	require.Equal(t, repo.CoinPriceErrNotFound, pricesErr.Errs[0].Kind())
+	t.Logf("Expected error: %v", err)
+}
+
+func TestFailover_OneCoinPriceInvalid(t *testing.T) {
+	t.Log("Get validate data error if all providers do not know the coin")
+
+	failover := newTestFailover(
+		&stubPriceRepoAPI{name: "primary", prices: map[string]float64{}},
+		&stubPriceRepoAPI{name: "secondary", prices: map[string]float64{}},
+	)
+	_, err := failover.OneCoinPrice(context.Background(), &entity.Coin{Symbol: "unexisting"}, "usd")
+	require.ErrorIs(t, err, repo.ErrValidateData)
+
+	failover = newTestFailover(
+		&stubPriceRepoAPI{name: "primary", err: errors.New("request to api: timeout")},
+		&stubPriceRepoAPI{name: "secondary", prices: map[string]float64{}},
+	)
+	_, err = failover.OneCoinPrice(context.Background(), &entity.Coin{Symbol: "unexisting"}, "usd")
+	require.NotErrorIs(t, err, repo.ErrValidateData)
+}
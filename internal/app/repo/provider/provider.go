@@ -0,0 +1,234 @@
+// Package provider contains registry of price API repos
+// to select price providers by its names from config
+// and composite price API repos built on top of them.
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+	"CryptocoinPrice/internal/app/repo/binance"
+	"CryptocoinPrice/internal/app/repo/coingecko"
+	"CryptocoinPrice/internal/app/repo/cryptocompare"
+	"CryptocoinPrice/internal/app/repo/fake"
+)
+
+var _ repo.ProviderStatusAPI = (*Registry)(nil)
+
+var ErrUnknownProvider = errors.New("unknown price provider") // unknown provider name error
+
+const (
+	ModeFailover  = "failover"  // ask providers in order and fill missing prices
+	ModeConsensus = "consensus" // ask all providers and use the median price
+
+	CoingeckoModeLive   = "live"   // requests to CoinGecko API
+	CoingeckoModeRecord = "record" // requests to CoinGecko API with recording responses
+	CoingeckoModeReplay = "replay" // recorded CoinGecko API responses without requests
+)
+
+// Factory creates new price API repo using app config.
+type Factory func(cfg *config.Config) (repo.PriceRepoAPI, error)
+
+// Registry is a set of price API repo factories with its names.
+type Registry struct {
+	factories map[string]Factory
+
+	mu sync.Mutex
+	// CoinGecko API calls limiter shared by all CoinGecko API repos
+	coingeckoLimiter *coingecko.Limiter
+	// circuit breakers of providers created from config
+	breakers []*CircuitBreaker
+}
+
+// NewRegistry returns new empty price provider registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]Factory),
+	}
+}
+
+// NewDefaultRegistry returns new registry with all built-in price providers.
+func NewDefaultRegistry() *Registry {
+	registry := NewRegistry()
+	registry.Register(coingecko.ProviderName, registry.newCoingecko)
+	registry.Register(binance.ProviderName, newBinance)
+	registry.Register(cryptocompare.ProviderName, newCryptocompare)
+	registry.Register(fake.RandomWalkProviderName, newRandomWalk)
+	return registry
+}
+
+// Register adds price provider factory with given name into registry.
+// It replaces the factory if name is already registered.
+func (r *Registry) Register(name string, factory Factory) {
+	r.factories[name] = factory
+}
+
+// Names returns sorted names of all registered price providers.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// New creates new price API repo registered with given name.
+func (r *Registry) New(name string, cfg *config.Config) (repo.PriceRepoAPI, error) {
+	factory, found := r.factories[name]
+	if !found {
+		return nil, fmt.Errorf("%w %q. Accepted providers: %v",
+			ErrUnknownProvider, name, r.Names())
+	}
+	priceRepoAPI, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create %s provider: %w", name, err)
+	}
+	return priceRepoAPI, nil
+}
+
+// NewFromConfig creates price API repos selected in config, wraps each of
+// them in circuit breaker and combines them in the selected mode (failover
+// or consensus). If only one provider is selected it returns the single
+// price API repo in circuit breaker.
+func (r *Registry) NewFromConfig(cfg *config.Config) (repo.PriceRepoAPI, error) {
+	names := cfg.App.PriceProviders
+	if len(names) == 0 {
+		return nil, errors.New("no one price provider is given")
+	}
+	providers := make([]NamedPriceRepoAPI, 0, len(names))
+	breakers := make([]*CircuitBreaker, 0, len(names))
+	for _, name := range names {
+		priceRepoAPI, err := r.New(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		breaker := NewCircuitBreaker(NamedPriceRepoAPI{PriceRepoAPI: priceRepoAPI, Name: name},
+			cfg.App.PriceBreakerFailures, cfg.App.PriceBreakerCoolDown)
+		breakers = append(breakers, breaker)
+		providers = append(providers, NamedPriceRepoAPI{PriceRepoAPI: breaker, Name: name})
+	}
+	r.mu.Lock()
+	r.breakers = append(r.breakers, breakers...)
+	r.mu.Unlock()
+
+	if len(providers) == 1 {
+		return providers[0].PriceRepoAPI, nil
+	}
+	switch cfg.App.PriceProvidersMode {
+	case ModeFailover:
+		return NewFailover(providers...), nil
+	case ModeConsensus:
+		return NewConsensus(cfg.App.PriceConsensusMaxDeviation, providers...), nil
+	default:
+		return nil, fmt.Errorf("unknown price providers mode %q", cfg.App.PriceProvidersMode)
+	}
+}
+
+// ProvidersStatus returns circuit breakers status of
+// all providers created from config.
+func (r *Registry) ProvidersStatus() entity.ProviderStatusList {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statusList := make(entity.ProviderStatusList, 0, len(r.breakers))
+	for _, breaker := range r.breakers {
+		statusList = append(statusList, breaker.Status())
+	}
+	return statusList
+}
+
+// NewCoinRepoAPI returns coin API repo to resolve coin symbols into
+// canonical coin IDs. It is backed by CoinGecko coin list so it
+// returns nil if CoinGecko API key is not set.
+func (r *Registry) NewCoinRepoAPI(cfg *config.Config) repo.CoinRepoAPI {
+	if !coingeckoConfigured(cfg) {
+		return nil
+	}
+	return coingecko.NewCoinRepoCoingecko(cfg.App.CoingeckoAPIKey, r.coingeckoOptions(cfg)...)
+}
+
+// NewPriceHistoryRepoAPI creates CoinGecko historical prices API repo
+// sharing calls limiter with other CoinGecko API repos.
+// It returns nil if CoinGecko API key is not set.
+func (r *Registry) NewPriceHistoryRepoAPI(cfg *config.Config) repo.PriceHistoryRepoAPI {
+	if !coingeckoConfigured(cfg) {
+		return nil
+	}
+	return coingecko.NewPriceHistoryRepoCoingecko(cfg.App.CoingeckoAPIKey,
+		r.coingeckoOptions(cfg)...)
+}
+
+// newCoingecko creates CoinGecko price API repo.
+// API key is required apart of replay mode.
+func (r *Registry) newCoingecko(cfg *config.Config) (repo.PriceRepoAPI, error) {
+	if !coingeckoConfigured(cfg) {
+		return nil, errors.New("COINGECKO_API_KEY is required apart of replay mode")
+	}
+	return coingecko.NewPriceRepoCoingecko(cfg.App.CoingeckoAPIKey, r.coingeckoOptions(cfg)...), nil
+}
+
+// coingeckoOptions returns CoinGecko API repo options from config.
+// All CoinGecko API repos created by registry share the same calls limiter.
+// In record mode API responses are saved into fixtures dir and in replay
+// mode they are served from it without requests to API and limits.
+func (r *Registry) coingeckoOptions(cfg *config.Config) []coingecko.Option {
+	options := []coingecko.Option{
+		coingecko.WithPlan(cfg.App.CoingeckoAPIPlan),
+		coingecko.WithRequestTimeout(cfg.App.CoingeckoRequestTimeout),
+		coingecko.WithRetry(cfg.App.CoingeckoRetryCount,
+			cfg.App.CoingeckoRetryWaitTime, cfg.App.CoingeckoRetryMaxWaitTime),
+	}
+	if cfg.App.CoingeckoBatchSize > 0 && cfg.App.CoingeckoBatchWorkers > 0 {
+		options = append(options, coingecko.WithBatching(
+			cfg.App.CoingeckoBatchSize, cfg.App.CoingeckoBatchWorkers))
+	}
+	if cfg.App.CoingeckoBaseURL != "" {
+		options = append(options, coingecko.WithBaseURL(cfg.App.CoingeckoBaseURL))
+	}
+
+	switch cfg.App.CoingeckoMode {
+	case CoingeckoModeReplay:
+		return append(options,
+			coingecko.WithTransport(fake.NewReplayer(cfg.App.CoingeckoFixturesDir)))
+	case CoingeckoModeRecord:
+		options = append(options,
+			coingecko.WithTransport(fake.NewRecorder(cfg.App.CoingeckoFixturesDir, nil)))
+	}
+
+	r.mu.Lock()
+	if r.coingeckoLimiter == nil {
+		r.coingeckoLimiter = coingecko.NewLimiter(
+			cfg.App.CoingeckoRateLimit, cfg.App.CoingeckoMonthlyQuota)
+	}
+	limiter := r.coingeckoLimiter
+	r.mu.Unlock()
+	return append(options, coingecko.WithLimiter(limiter))
+}
+
+// coingeckoConfigured returns true if CoinGecko API repos can be created:
+// API key is set or recorded API responses are replayed.
+func coingeckoConfigured(cfg *config.Config) bool {
+	return cfg.App.CoingeckoAPIKey != "" || cfg.App.CoingeckoMode == CoingeckoModeReplay
+}
+
+// newRandomWalk creates fake price API repo generating prices with random walk.
+func newRandomWalk(cfg *config.Config) (repo.PriceRepoAPI, error) {
+	return fake.NewPriceRepoRandomWalk(cfg.App.RandomWalkSeed), nil
+}
+
+// newBinance creates Binance price API repo.
+func newBinance(_ *config.Config) (repo.PriceRepoAPI, error) {
+	return binance.NewPriceRepoBinance(binance.DefaultBaseURL), nil
+}
+
+// newCryptocompare creates CryptoCompare price API repo. API key is optional.
+func newCryptocompare(cfg *config.Config) (repo.PriceRepoAPI, error) {
+	return cryptocompare.NewPriceRepoCryptocompare(
+		cryptocompare.DefaultBaseURL, cfg.App.CryptocompareAPIKey), nil
+}
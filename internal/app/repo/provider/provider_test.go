@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+	"CryptocoinPrice/internal/app/repo/binance"
+	"CryptocoinPrice/internal/app/repo/coingecko"
+)
+
+func TestRegistry_New(t *testing.T) {
+	t.Log("Create provider registered in default registry")
+
+	priceRepoAPI, err := NewDefaultRegistry().New(binance.ProviderName, &config.Config{})
+	require.NoError(t, err)
+	require.IsType(t, &binance.PriceRepoBinance{}, priceRepoAPI)
+}
+
+func TestRegistry_NewUnknown(t *testing.T) {
+	t.Log("Create unknown provider")
+
+	_, err := NewDefaultRegistry().New("unknown", &config.Config{})
+	require.ErrorIs(t, err, ErrUnknownProvider)
+	t.Logf("Expected error: %v", err)
+}
+
+func TestRegistry_NewWithoutKey(t *testing.T) {
+	t.Log("Create CoinGecko provider without API key")
+
+	_, err := NewDefaultRegistry().New("coingecko", &config.Config{})
+	require.Error(t, err)
+	t.Logf("Expected error: %v", err)
+}
+
+func TestRegistry_NewCoingeckoFromConfig(t *testing.T) {
+	t.Log("Create CoinGecko provider with pro plan pointed at mock server")
+
+	server, _ := newCoingeckoServer(t)
+	cfg := &config.Config{App: config.App{
+		CoingeckoAPIKey:         "pro-key",
+		CoingeckoAPIPlan:        coingecko.PlanPro,
+		CoingeckoBaseURL:        server.URL,
+		CoingeckoRequestTimeout: time.Second,
+	}}
+	priceRepoAPI, err := NewDefaultRegistry().New(coingecko.ProviderName, cfg)
+	require.NoError(t, err)
+
+	coinPrice, err := priceRepoAPI.OneCoinPrice(context.Background(), &entity.Coin{Symbol: "btc"}, "usd")
+	require.NoError(t, err)
+	require.Equal(t, "115380", coinPrice.Price.String())
+	require.Equal(t, int64(1754050754), coinPrice.LastUpdate)
+}
+
+func TestRegistry_CoingeckoQuotaExhausted(t *testing.T) {
+	t.Log("Use up CoinGecko monthly quota shared by all CoinGecko repos")
+
+	server, hits := newCoingeckoServer(t)
+	cfg := &config.Config{App: config.App{
+		CoingeckoAPIKey:         "pro-key",
+		CoingeckoAPIPlan:        coingecko.PlanPro,
+		CoingeckoBaseURL:        server.URL,
+		CoingeckoRequestTimeout: time.Second,
+		CoingeckoMonthlyQuota:   1,
+	}}
+	registry := NewDefaultRegistry()
+	priceRepoAPI, err := registry.New(coingecko.ProviderName, cfg)
+	require.NoError(t, err)
+
+	coin := &entity.Coin{Symbol: "btc"}
+	coinPrice, err := priceRepoAPI.OneCoinPrice(context.Background(), coin, "usd")
+	require.NoError(t, err)
+
+	// lookup is served from cache
+	cachedPrice, err := priceRepoAPI.OneCoinPrice(context.Background(), coin, "usd")
+	require.NoError(t, err)
+	require.Equal(t, coinPrice, cachedPrice)
+	// collection is skipped
+	_, err = priceRepoAPI.ManyCoinPrices(context.Background(), entity.CoinList{*coin}, []string{"usd"})
+	require.ErrorIs(t, err, repo.ErrQuotaExhausted)
+	// quota is shared with coin repo
+	_, err = registry.NewCoinRepoAPI(cfg).SearchBySymbol("btc")
+	require.ErrorIs(t, err, repo.ErrQuotaExhausted)
+	require.Equal(t, int32(1), hits.Load())
+	t.Logf("Expected error: %v", err)
+}
+
+func TestRegistry_CoingeckoBatching(t *testing.T) {
+	t.Log("Request CoinGecko prices in concurrent chunks and merge them")
+
+	server, hits := newCoingeckoServer(t)
+	cfg := &config.Config{App: config.App{
+		CoingeckoAPIKey:         "pro-key",
+		CoingeckoAPIPlan:        coingecko.PlanPro,
+		CoingeckoBaseURL:        server.URL,
+		CoingeckoRequestTimeout: time.Second,
+		CoingeckoBatchSize:      1,
+		CoingeckoBatchWorkers:   2,
+	}}
+	priceRepoAPI, err := NewDefaultRegistry().New(coingecko.ProviderName, cfg)
+	require.NoError(t, err)
+
+	coins := entity.CoinList{{Symbol: "btc"}, {Symbol: "down"}, {Symbol: "eth"}}
+	coinPricesList, err := priceRepoAPI.ManyCoinPrices(context.Background(), coins, []string{"usd"})
+	require.Error(t, err)
+	require.Len(t, coinPricesList, 2)
+	require.Equal(t, int32(3), hits.Load())
+	t.Logf("Expected error: %v", err)
+
+	// all chunks are failed
+	_, err = priceRepoAPI.ManyCoinPrices(context.Background(), entity.CoinList{{Symbol: "down"}}, []string{"usd"})
+	require.Error(t, err)
+}
+
+func TestRegistry_CoingeckoHistory(t *testing.T) {
+	t.Log("Get CoinGecko historical prices by coin id")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/coins/bitcoin/market_chart/range" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		require.Equal(t, "1754006400", r.URL.Query().Get("from"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"prices":[[1754006400000,115380.12],[1754010000000,115412.48]]}`))
+	}))
+	t.Cleanup(server.Close)
+	cfg := &config.Config{App: config.App{
+		CoingeckoAPIKey:         "demo-key",
+		CoingeckoBaseURL:        server.URL,
+		CoingeckoRequestTimeout: time.Second,
+	}}
+	historyRepoAPI := NewDefaultRegistry().NewPriceHistoryRepoAPI(cfg)
+
+	coin := &entity.Coin{Symbol: "btc", ExternalID: "bitcoin"}
+	coinPrices, err := historyRepoAPI.PriceHistory(context.Background(), coin, "usd", 1754006400, 1754010000)
+	require.NoError(t, err)
+	require.Len(t, coinPrices, 2)
+	require.Equal(t, int64(1754010000), coinPrices[1].LastUpdate)
+	require.Equal(t, "115412.48", coinPrices[1].Price.String())
+
+	// unknown coin id
+	coin.ExternalID = "unexisting"
+	_, err = historyRepoAPI.PriceHistory(context.Background(), coin, "usd", 1754006400, 1754010000)
+	require.ErrorIs(t, err, repo.ErrValidateData)
+	t.Logf("Expected error: %v", err)
+}
+
+// newCoingeckoServer returns CoinGecko API mock server for pro plan
+// and counter of requests to it. Requests with "down" coin are failed.
+func newCoingeckoServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	prices := map[string]string{
+		"btc": `{"usd":115380,"last_updated_at":1754050754}`,
+		"eth": `{"usd":3647.54,"last_updated_at":1754050755}`,
+	}
+	hits := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path != "/simple/price" || r.Header.Get("x-cg-pro-api-key") != "pro-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		coinsData := make([]string, 0)
+		for _, symbol := range strings.Split(r.URL.Query().Get("symbols"), ",") {
+			if symbol == "down" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			if coinData, found := prices[symbol]; found {
+				coinsData = append(coinsData, fmt.Sprintf("%q:%s", symbol, coinData))
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{" + strings.Join(coinsData, ",") + "}"))
+	}))
+	t.Cleanup(server.Close)
+	return server, hits
+}
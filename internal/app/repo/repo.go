@@ -3,7 +3,11 @@
 package repo
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"CryptocoinPrice/internal/app/entity"
 )
@@ -11,22 +15,213 @@ import (
 var (
 	ErrNotFound     = errors.New("record not found") // record not found error
 	ErrValidateData = errors.New("validate data")    // validat data error
+	// API calls quota exhausted error
+	ErrQuotaExhausted = errors.New("api quota is exhausted")
+	// provider circuit breaker is open error
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// malformed provider payload error
+	ErrMalformedData = errors.New("malformed data")
+	// no space in prices spool error
+	ErrSpoolFull = errors.New("spool is full")
+	// data rejected by DB error (saving the same data again is failed too)
+	ErrRejectedData = errors.New("data is rejected")
 )
 
+const (
+	CoinPriceErrNotFound  = "not_found" // coin is unknown to provider
+	CoinPriceErrMalformed = "malformed" // provider returned malformed coin data
+	CoinPriceErrProvider  = "provider"  // provider request is failed
+)
+
+// CoinPriceError is an error of getting coin price in quote currency.
+// Its cause error wraps validate data error if coin is not found,
+// malformed data error if coin data is invalid or any other
+// provider error if request for coin is failed.
+type CoinPriceError struct {
+	// uuid of requested coin
+	CoinID string
+	// coin symbol
+	Symbol string
+	// quote currency
+	Currency string
+	// cause error
+	Err error
+}
+
+// Error implements error interface.
+func (e *CoinPriceError) Error() string {
+	return fmt.Sprintf("coin %s/%s: %v", e.Symbol, e.Currency, e.Err)
+}
+
+// Unwrap returns cause error.
+func (e *CoinPriceError) Unwrap() error {
+	return e.Err
+}
+
+// Kind returns kind of coin price error (not_found/malformed/provider).
+func (e *CoinPriceError) Kind() string {
+	switch {
+	case errors.Is(e.Err, ErrValidateData):
+		return CoinPriceErrNotFound
+	case errors.Is(e.Err, ErrMalformedData):
+		return CoinPriceErrMalformed
+	default:
+		return CoinPriceErrProvider
+	}
+}
+
+// CoinPricesError is returned with received prices if
+// prices of some coins are not received.
+type CoinPricesError struct {
+	// error for each coin price which is not received
+	Errs []*CoinPriceError
+}
+
+// Error implements error interface.
+func (e *CoinPricesError) Error() string {
+	errList := make([]string, 0, len(e.Errs))
+	for _, coinErr := range e.Errs {
+		errList = append(errList, coinErr.Error())
+	}
+	return fmt.Sprintf("%d coin prices are not received: %s",
+		len(e.Errs), strings.Join(errList, " && "))
+}
+
+// Unwrap returns errors of coin prices which are not received.
+func (e *CoinPricesError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errs))
+	for _, coinErr := range e.Errs {
+		errs = append(errs, coinErr)
+	}
+	return errs
+}
+
+// NewCoinPricesError returns coin prices error with given coin price
+// errors or nil if no one coin price error is given.
+func NewCoinPricesError(errs []*CoinPriceError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &CoinPricesError{Errs: errs}
+}
+
+// SharedSymbols returns symbols each of which is shared by many of given coins.
+// Providers which request coins by symbols can not tell such coins apart.
+func SharedSymbols(coins entity.CoinList) map[string]struct{} {
+	seen := make(map[string]struct{}, len(coins))
+	shared := make(map[string]struct{})
+	for _, coin := range coins {
+		if _, found := seen[coin.Symbol]; found {
+			shared[coin.Symbol] = struct{}{}
+		}
+		seen[coin.Symbol] = struct{}{}
+	}
+	return shared
+}
+
+// CircuitOpenError is returned without request to provider
+// if provider circuit breaker is open after consecutive failures.
+type CircuitOpenError struct {
+	// provider name
+	Provider string
+	// time until the next attempt to request provider
+	RetryAfter time.Duration
+}
+
+// Error implements error interface.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %v: retry after %s", e.Provider, ErrCircuitOpen, e.RetryAfter)
+}
+
+// Unwrap returns circuit breaker is open error.
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
 type CoinRepoDB interface {
-	Create(symbol string) (*entity.Coin, error)
-	GetBySymbol(symbol string) (*entity.Coin, error)
+	Create(coin *entity.Coin) (*entity.Coin, error)
+	GetBySymbol(symbol string) (entity.CoinList, error)
 	Update(coinID string, coinUpdates *entity.CoinPartial) error
 	GetObserved() (entity.CoinList, error)
+	AddFailure(coinIDs []string, threshold int) (entity.CoinList, error)
+	ResetFailures(coinIDs []string) error
 }
 
 type PriceRepoDB interface {
-	Create(coin *entity.Coin, price float64, timestamp int64) (*entity.Price, error)
-	CreateMany(priceList entity.PriceList) (entity.PriceList, error)
-	GetNearestTimestamp(coin *entity.Coin, timestamp int64) (*entity.Price, error)
+	Create(price *entity.Price) (*entity.Price, error)
+	CreateMany(ctx context.Context, priceList entity.PriceList) (entity.PriceList, error)
+	GetSurrounding(coin *entity.Coin, currency string, timestamp int64,
+		timeAxis string, maxDistance int64) (before, after *entity.Price, err error)
+	GetRange(coinID, currency string, from, to int64, order string,
+		after *entity.PriceCursor, limit int) (entity.PriceList, error)
+	GetLatestTimestamps(coinIDs []string) (entity.PriceList, error)
+	GetTimestamps(coinID, currency string, from, to int64) ([]int64, error)
+}
+
+type PriceGapRepoDB interface {
+	CreateMany(gapList entity.PriceGapList) (entity.PriceGapList, error)
+	GetOverlapping(coinID, currency string, from, to int64) (entity.PriceGapList, error)
+	GetMany(coinID, currency, state string, limit int) (entity.PriceGapList, error)
+	Update(gap *entity.PriceGap) error
+}
+
+type BackfillJobRepoDB interface {
+	CreateActive(job *entity.BackfillJob) (*entity.BackfillJob, error)
+	GetByID(jobID string) (*entity.BackfillJob, error)
+	ClaimQueued(startedAt int64) (*entity.BackfillJob, error)
+	Update(job *entity.BackfillJob) error
+	RequeueRunning() (int, error)
+	DeleteFinishedBefore(finishedAt int64) (int, error)
+}
+
+type PriceSpoolRepo interface {
+	Append(priceList entity.PriceList) error
+	Peek() (entity.PriceList, error)
+	Pop() error
+	Reject(priceList entity.PriceList, reason error) error
+}
+
+type LeaderLockDB interface {
+	TryLock(ctx context.Context) (bool, error)
+	Check(ctx context.Context) error
+	Unlock(ctx context.Context) error
 }
 
 type PriceRepoAPI interface {
-	OneCoinPrice(symbol string) (*entity.CoinPriceAPI, error)
-	ManyCoinPrices(symbols []string) (entity.CoinPriceAPIList, error)
+	OneCoinPrice(ctx context.Context, coin *entity.Coin,
+		currency string) (*entity.CoinPriceAPI, error)
+	ManyCoinPrices(ctx context.Context, coins entity.CoinList,
+		currencies []string) (entity.CoinPriceAPIList, error)
+}
+
+type PriceHistoryRepoAPI interface {
+	PriceHistory(ctx context.Context, coin *entity.Coin, currency string,
+		from, to int64) (entity.CoinPriceAPIList, error)
+}
+
+type PriceStreamAPI interface {
+	Dial(ctx context.Context) (PriceStreamConn, error)
+}
+
+type PriceStreamConn interface {
+	Subscribe(coins entity.CoinList, currencies []string) error
+	Unsubscribe(coins entity.CoinList, currencies []string) error
+	Read() (*entity.CoinPriceAPI, error)
+	Close() error
+}
+
+type CoinRepoAPI interface {
+	SearchBySymbol(symbol string) (entity.CoinCandidateList, error)
+}
+
+type ProviderStatusAPI interface {
+	ProvidersStatus() entity.ProviderStatusList
+}
+
+type LeaderStatusAPI interface {
+	LeaderStatus() entity.LeaderStatus
+}
+
+type SpoolStatusAPI interface {
+	SpoolStatus() entity.SpoolStatus
 }
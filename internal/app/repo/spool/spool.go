@@ -0,0 +1,407 @@
+// Package spool contains on-disk spool of prices which are failed to
+// save into DB. Spooled batches are kept in append-only file in order
+// of spooling until they are replayed.
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var (
+	_ repo.PriceSpoolRepo = (*PriceSpoolFile)(nil)
+	_ repo.SpoolStatusAPI = (*PriceSpoolFile)(nil)
+)
+
+const (
+	_spoolFileName  = "prices.spool"  // append-only file with spooled batches
+	_offsetFileName = "prices.offset" // file with offset of the oldest batch to replay
+	// append-only file with batches rejected by DB (they are not replayed)
+	_rejectedFileName = "prices.rejected"
+)
+
+// spoolRecord is a spooled batch of prices (one line of spool file).
+type spoolRecord struct {
+	// time the batch is spooled at in unix format
+	SpooledAt int64 `json:"spooled_at"`
+	// prices of batch
+	Prices entity.PriceList `json:"prices"`
+}
+
+// rejectedRecord is a batch of prices rejected by DB or malformed
+// spooled batch (one line of rejected file).
+type rejectedRecord struct {
+	// time the batch is rejected at in unix format
+	RejectedAt int64 `json:"rejected_at"`
+	// reason of rejection
+	Error string `json:"error"`
+	// prices of batch
+	Prices entity.PriceList `json:"prices,omitempty"`
+	// raw record of malformed spooled batch
+	Record string `json:"record,omitempty"`
+}
+
+// spoolEntry is an index entry of batch which is not replayed yet.
+type spoolEntry struct {
+	// size of batch record in bytes
+	size int64
+	// amount of prices in batch
+	prices int
+	// time the batch is spooled at in unix format
+	spooledAt int64
+}
+
+// PriceSpoolFile is an on-disk spool of prices. Batches are appended to
+// spool file and the offset of the oldest batch which is not replayed yet
+// is kept in offset file. Spool file is truncated when all batches are
+// replayed and is compacted if it reaches max size.
+type PriceSpoolFile struct {
+	dir     string
+	maxSize int64
+
+	mu   sync.Mutex
+	file *os.File
+	// spool file size
+	size int64
+	// offset of the oldest batch which is not replayed yet
+	offset int64
+	// batches which are not replayed yet from the oldest one
+	pending []spoolEntry
+}
+
+// NewPriceSpoolFile opens prices spool in given dir (dir is created
+// if it does not exist). Max size is max spool file size in bytes.
+// Incomplete batch written before crash is dropped and malformed
+// batches are moved aside to rejected file.
+func NewPriceSpoolFile(dir string, maxSize int64) (*PriceSpoolFile, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil { // nolint:mnd // dir permissions
+		return nil, fmt.Errorf("create spool dir: %w", err)
+	}
+	spool := &PriceSpoolFile{dir: dir, maxSize: maxSize}
+	if err := spool.open(); err != nil {
+		return nil, err
+	}
+	return spool, nil
+}
+
+// Append appends prices batch to spool. If spool file reaches max size
+// replayed batches are removed from it. If there is still no space
+// spool full error is returned and batch is not spooled.
+func (s *PriceSpoolFile) Append(priceList entity.PriceList) error {
+	spooledAt := time.Now().UTC().Unix()
+	record, err := json.Marshal(spoolRecord{SpooledAt: spooledAt, Prices: priceList})
+	if err != nil {
+		return fmt.Errorf("marshal batch: %w", err)
+	}
+	record = append(record, '\n')
+	recordSize := int64(len(record))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size+recordSize > s.maxSize && s.offset > 0 {
+		if err := s.compact(); err != nil {
+			return fmt.Errorf("compact spool: %w", err)
+		}
+	}
+	if s.size+recordSize > s.maxSize {
+		return fmt.Errorf("%w: %d bytes of %d are used", repo.ErrSpoolFull, s.size, s.maxSize)
+	}
+
+	if _, err := s.file.Write(record); err != nil {
+		return fmt.Errorf("write batch: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("sync spool file: %w", err)
+	}
+	s.size += recordSize
+	s.pending = append(s.pending, spoolEntry{
+		size:      recordSize,
+		prices:    len(priceList),
+		spooledAt: spooledAt,
+	})
+	return nil
+}
+
+// Peek returns the oldest spooled batch which is not replayed yet.
+// If spool is empty it returns not found error.
+func (s *PriceSpoolFile) Peek() (entity.PriceList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return nil, repo.ErrNotFound
+	}
+	record := make([]byte, s.pending[0].size)
+	if _, err := s.file.ReadAt(record, s.offset); err != nil {
+		return nil, fmt.Errorf("read batch: %w", err)
+	}
+	batch := &spoolRecord{}
+	if err := json.Unmarshal(record, batch); err != nil {
+		return nil, fmt.Errorf("unmarshal batch: %w", err)
+	}
+	return batch.Prices, nil
+}
+
+// Pop removes the oldest spooled batch after it is replayed.
+// Spool file is truncated if all batches are replayed.
+func (s *PriceSpoolFile) Pop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return nil
+	}
+	offset := s.offset + s.pending[0].size
+	if len(s.pending) == 1 {
+		if err := s.file.Truncate(0); err != nil {
+			return fmt.Errorf("truncate spool file: %w", err)
+		}
+		s.size, offset = 0, 0
+	}
+	if err := s.writeOffset(offset); err != nil {
+		return err
+	}
+	s.offset = offset
+	s.pending = s.pending[1:]
+	return nil
+}
+
+// Reject appends prices batch rejected by DB with given reason to
+// rejected file to keep it for manual recovery. Rejected batches are
+// not replayed, so spooled batch must be popped after it is rejected.
+func (s *PriceSpoolFile) Reject(priceList entity.PriceList, reason error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.appendRejected(rejectedRecord{
+		RejectedAt: time.Now().UTC().Unix(),
+		Error:      reason.Error(),
+		Prices:     priceList,
+	})
+}
+
+// appendRejected appends given records to rejected file.
+// It must be called under mutex.
+func (s *PriceSpoolFile) appendRejected(records ...rejectedRecord) error {
+	content := make([]byte, 0)
+	for _, rejected := range records {
+		record, err := json.Marshal(rejected)
+		if err != nil {
+			return fmt.Errorf("marshal rejected batch: %w", err)
+		}
+		content = append(append(content, record...), '\n')
+	}
+
+	file, err := os.OpenFile(filepath.Join(s.dir, _rejectedFileName),
+		os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600) // nolint:mnd // file permissions
+	if err != nil {
+		return fmt.Errorf("open rejected file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(content); err != nil {
+		return fmt.Errorf("write rejected batch: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("sync rejected file: %w", err)
+	}
+	return nil
+}
+
+// SpoolStatus returns spool backlog depth.
+func (s *PriceSpoolFile) SpoolStatus() entity.SpoolStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := entity.SpoolStatus{
+		Batches: len(s.pending),
+		Size:    s.size - s.offset,
+		MaxSize: s.maxSize,
+	}
+	for _, entry := range s.pending {
+		status.Prices += entry.prices
+	}
+	if len(s.pending) != 0 {
+		status.OldestAt = s.pending[0].spooledAt
+	}
+	return status
+}
+
+// Close closes spool file.
+func (s *PriceSpoolFile) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// open opens spool file, reads offset and indexes batches which are not
+// replayed yet. Incomplete tail of spool file (the last batch without line
+// end) is truncated. Malformed batches are moved aside to rejected file
+// and spool file is rewritten without them to keep the next batches.
+func (s *PriceSpoolFile) open() error {
+	file, err := os.OpenFile(filepath.Join(s.dir, _spoolFileName),
+		os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600) // nolint:mnd // file permissions
+	if err != nil {
+		return fmt.Errorf("open spool file: %w", err)
+	}
+	s.file = file
+
+	offset, err := s.readOffset()
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat spool file: %w", err)
+	}
+	if offset > info.Size() {
+		offset = 0
+	}
+
+	// index complete batches from offset
+	reader := bufio.NewReader(io.NewSectionReader(file, offset, info.Size()-offset))
+	size := offset
+	// records of indexed batches and malformed batches
+	pending := make([]byte, 0)
+	malformed := make([]rejectedRecord, 0)
+	for {
+		record, err := reader.ReadBytes('\n')
+		// incomplete batch is not indexed
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read spool file: %w", err)
+		}
+		size += int64(len(record))
+		batch := &spoolRecord{}
+		if err := json.Unmarshal(record, batch); err != nil {
+			malformed = append(malformed, rejectedRecord{
+				RejectedAt: time.Now().UTC().Unix(),
+				Error:      fmt.Sprintf("malformed spooled batch: %v", err),
+				Record:     string(bytes.TrimSpace(record)),
+			})
+			continue
+		}
+		pending = append(pending, record...)
+		s.pending = append(s.pending, spoolEntry{
+			size:      int64(len(record)),
+			prices:    len(batch.Prices),
+			spooledAt: batch.SpooledAt,
+		})
+	}
+	// drop incomplete batch written before crash
+	if size != info.Size() {
+		if err := file.Truncate(size); err != nil {
+			return fmt.Errorf("truncate spool file: %w", err)
+		}
+	}
+	s.size, s.offset = size, offset
+	if len(malformed) == 0 {
+		return nil
+	}
+
+	// move malformed batches aside before they are removed from spool file
+	if err := s.appendRejected(malformed...); err != nil {
+		return fmt.Errorf("reject malformed batches: %w", err)
+	}
+	if err := s.rewrite(pending); err != nil {
+		return fmt.Errorf("remove malformed batches: %w", err)
+	}
+	return nil
+}
+
+// compact rewrites spool file with batches which are not replayed yet only.
+// It must be called under mutex.
+func (s *PriceSpoolFile) compact() error {
+	pending := make([]byte, s.size-s.offset)
+	if _, err := s.file.ReadAt(pending, s.offset); err != nil {
+		return fmt.Errorf("read batches: %w", err)
+	}
+	return s.rewrite(pending)
+}
+
+// rewrite replaces spool file with given records of batches
+// which are not replayed yet. It must be called under mutex.
+func (s *PriceSpoolFile) rewrite(pending []byte) error {
+	spoolPath := filepath.Join(s.dir, _spoolFileName)
+	if err := writeFileSync(spoolPath+".tmp", pending); err != nil {
+		return err
+	}
+	// offset is reset before replace of spool file to not lose batches
+	// on crash (then replayed batches of the old file are replayed again)
+	if err := s.writeOffset(0); err != nil {
+		return err
+	}
+	if err := os.Rename(spoolPath+".tmp", spoolPath); err != nil {
+		return fmt.Errorf("replace spool file: %w", err)
+	}
+
+	file, err := os.OpenFile(spoolPath, os.O_RDWR|os.O_APPEND, 0o600) // nolint:mnd // file permissions
+	if err != nil {
+		return fmt.Errorf("open spool file: %w", err)
+	}
+	_ = s.file.Close()
+	s.file = file
+	s.size, s.offset = int64(len(pending)), 0
+	return nil
+}
+
+// readOffset returns offset from offset file (zero if file does not exist).
+func (s *PriceSpoolFile) readOffset() (int64, error) {
+	content, err := os.ReadFile(filepath.Join(s.dir, _offsetFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read offset file: %w", err)
+	}
+	offset, err := strconv.ParseInt(string(bytes.TrimSpace(content)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse offset: %w", err)
+	}
+	return offset, nil
+}
+
+// writeOffset atomically writes offset into offset file.
+func (s *PriceSpoolFile) writeOffset(offset int64) error {
+	offsetPath := filepath.Join(s.dir, _offsetFileName)
+	if err := writeFileSync(offsetPath+".tmp", strconv.AppendInt(nil, offset, 10)); err != nil {
+		return err
+	}
+	if err := os.Rename(offsetPath+".tmp", offsetPath); err != nil {
+		return fmt.Errorf("replace offset file: %w", err)
+	}
+	return nil
+}
+
+// writeFileSync writes content into file and flushes it to disk.
+func writeFileSync(path string, content []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) // nolint:mnd // file permissions
+	if err != nil {
+		return fmt.Errorf("open %s: %w", filepath.Base(path), err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(content); err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
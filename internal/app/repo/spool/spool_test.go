@@ -0,0 +1,132 @@
+package spool
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+// newBatch returns batch with one price of given value.
+func newBatch(price string) entity.PriceList {
+	return entity.PriceList{{
+		CoinID:    "0c1d0c57-7c53-4c4b-9c4c-6f0f5e0f4b9e",
+		Price:     decimal.RequireFromString(price),
+		Currency:  "usd",
+		Timestamp: 1754050754,
+		Source:    "coingecko",
+	}}
+}
+
+func TestPriceSpoolFile_Replay(t *testing.T) {
+	t.Log("Replay spooled batches in order after reopen")
+
+	dir := t.TempDir()
+	spool, err := NewPriceSpoolFile(dir, 1<<20)
+	require.NoError(t, err)
+	for _, price := range []string{"1", "2", "3"} {
+		require.NoError(t, spool.Append(newBatch(price)))
+	}
+	require.NoError(t, spool.Pop())
+	require.NoError(t, spool.Close())
+
+	// batch is written partially before crash
+	file, err := os.OpenFile(filepath.Join(dir, _spoolFileName), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"spooled_at":1754050754,"prices":[{"Pri`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	spool, err = NewPriceSpoolFile(dir, 1<<20)
+	require.NoError(t, err)
+	status := spool.SpoolStatus()
+	require.Equal(t, 2, status.Batches)
+	require.Equal(t, 2, status.Prices)
+	t.Logf("Spool status: %+v", status)
+
+	for _, price := range []string{"2", "3"} {
+		priceList, err := spool.Peek()
+		require.NoError(t, err)
+		require.Equal(t, newBatch(price), priceList)
+		require.NoError(t, spool.Pop())
+	}
+	_, err = spool.Peek()
+	require.ErrorIs(t, err, repo.ErrNotFound)
+	require.Equal(t, entity.SpoolStatus{MaxSize: 1 << 20}, spool.SpoolStatus())
+	require.NoError(t, spool.Close())
+}
+
+func TestPriceSpoolFile_Full(t *testing.T) {
+	t.Log("Compact spool with replayed batches and reject batch if spool is full")
+
+	dir := t.TempDir()
+	spool, err := NewPriceSpoolFile(dir, 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, spool.Append(newBatch("1")))
+	batchSize := spool.SpoolStatus().Size
+	require.NoError(t, spool.Close())
+
+	// spool has space for two batches
+	spool, err = NewPriceSpoolFile(dir, 2*batchSize)
+	require.NoError(t, err)
+	require.NoError(t, spool.Append(newBatch("2")))
+	require.ErrorIs(t, spool.Append(newBatch("3")), repo.ErrSpoolFull)
+
+	// replayed batch is removed to append new one
+	require.NoError(t, spool.Pop())
+	require.NoError(t, spool.Append(newBatch("3")))
+	for _, price := range []string{"2", "3"} {
+		priceList, err := spool.Peek()
+		require.NoError(t, err)
+		require.Equal(t, newBatch(price), priceList)
+		require.NoError(t, spool.Pop())
+	}
+	require.NoError(t, spool.Close())
+}
+
+func TestPriceSpoolFile_Malformed(t *testing.T) {
+	t.Log("Move malformed batch aside and keep the next batches after reopen")
+
+	dir := t.TempDir()
+	spool, err := NewPriceSpoolFile(dir, 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, spool.Append(newBatch("1")))
+	require.NoError(t, spool.Close())
+
+	// malformed batch is followed by complete one
+	record, err := json.Marshal(spoolRecord{SpooledAt: 1754050754, Prices: newBatch("2")})
+	require.NoError(t, err)
+	file, err := os.OpenFile(filepath.Join(dir, _spoolFileName), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString("{\"spooled_at\":1754050754,\"pri\n" + string(record) + "\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	spool, err = NewPriceSpoolFile(dir, 1<<20)
+	require.NoError(t, err)
+	require.Equal(t, 2, spool.SpoolStatus().Batches)
+	for _, price := range []string{"1", "2"} {
+		priceList, err := spool.Peek()
+		require.NoError(t, err)
+		require.Equal(t, newBatch(price), priceList)
+		require.NoError(t, spool.Pop())
+	}
+	require.NoError(t, spool.Close())
+
+	rejected, err := os.ReadFile(filepath.Join(dir, _rejectedFileName))
+	require.NoError(t, err)
+	require.Contains(t, string(rejected), "malformed spooled batch")
+	t.Logf("Rejected file: %s", rejected)
+
+	// malformed batch is removed from spool file
+	spool, err = NewPriceSpoolFile(dir, 1<<20)
+	require.NoError(t, err)
+	require.Zero(t, spool.SpoolStatus().Batches)
+	require.NoError(t, spool.Close())
+}
@@ -3,26 +3,58 @@ package server
 import (
 	"gorm.io/gorm"
 
-	"CryptocoinPrice/config"
 	httpv1 "CryptocoinPrice/internal/app/controller/http/v1"
+	"CryptocoinPrice/internal/app/controller/http/v1/backfill"
 	"CryptocoinPrice/internal/app/controller/http/v1/coinmanage"
-	repocoingecko "CryptocoinPrice/internal/app/repo/coingecko"
+	"CryptocoinPrice/internal/app/controller/http/v1/gap"
+	"CryptocoinPrice/internal/app/controller/http/v1/status"
+	"CryptocoinPrice/internal/app/repo"
 	repopg "CryptocoinPrice/internal/app/repo/pg"
 	"CryptocoinPrice/internal/app/usecase"
 	"CryptocoinPrice/internal/pkg/validator"
 )
 
 // registerEndpointsV1 register all endpoints for 1st version of API.
-func (s *Server) registerEndpointsV1(cfg *config.Config, db *gorm.DB, valid validator.Validator) {
+func (s *Server) registerEndpointsV1(db *gorm.DB, priceRepoAPI repo.PriceRepoAPI,
+	coinRepoAPI repo.CoinRepoAPI, historyRepoAPI repo.PriceHistoryRepoAPI,
+	providerStatusAPI repo.ProviderStatusAPI, leaderStatusAPI repo.LeaderStatusAPI,
+	spoolStatusAPI repo.SpoolStatusAPI, valid validator.Validator) {
+
 	// create repos
 	coinRepoPG := repopg.NewCoinRepoPG(db)
 	priceRepoDB := repopg.NewPriceRepoPG(db)
-	priceRepoCoingecko := repocoingecko.NewPriceRepoCoingecko(cfg.App.CoingeckoAPIKey)
 	// create usecases
-	coinManageUC := usecase.NewCoinManageUC(coinRepoPG, priceRepoDB, priceRepoCoingecko)
+	coinManageUC := usecase.NewCoinManageUC(coinRepoPG, priceRepoDB,
+		priceRepoAPI, coinRepoAPI, s.cfg.App.QuoteCurrencies)
+	backfillUC := usecase.NewBackfillUC(coinRepoPG, priceRepoDB,
+		repopg.NewBackfillJobRepoPG(db), historyRepoAPI, s.cfg.App.QuoteCurrencies)
+	priceGapUC := usecase.NewPriceGapUC(coinRepoPG, priceRepoDB,
+		repopg.NewPriceGapRepoPG(db), backfillUC, s.cfg.App.QuoteCurrencies,
+		s.cfg.App.PriceCollectInterval, s.cfg.App.GapMinDuration, s.cfg.App.GapDetectWindow)
+	statusUC := usecase.NewStatusUC(providerStatusAPI, leaderStatusAPI, spoolStatusAPI)
 	// create controllers
-	coinManageController := coinmanage.NewController(coinManageUC, valid)
+	coinManageController := coinmanage.NewController(coinManageUC, backfillUC, valid)
+	backfillController := backfill.NewController(backfillUC, valid)
+	gapController := gap.NewController(priceGapUC, valid)
+	statusController := status.NewController(statusUC)
 	// register endpoints
 	apiV1 := s.fiberApp.Group("/api/v1")
 	httpv1.RegisterCoinManageEndpoints(apiV1, coinManageController)
+	httpv1.RegisterBackfillEndpoints(apiV1, backfillController)
+	httpv1.RegisterGapEndpoints(apiV1, gapController)
+	httpv1.RegisterStatusEndpoints(apiV1, statusController)
+}
+
+// registerStatusEndpointsV1 register service status endpoint only
+// for 1st version of API.
+func (s *Server) registerStatusEndpointsV1(providerStatusAPI repo.ProviderStatusAPI,
+	leaderStatusAPI repo.LeaderStatusAPI, spoolStatusAPI repo.SpoolStatusAPI) {
+
+	// create usecases
+	statusUC := usecase.NewStatusUC(providerStatusAPI, leaderStatusAPI, spoolStatusAPI)
+	// create controllers
+	statusController := status.NewController(statusUC)
+	// register endpoints
+	apiV1 := s.fiberApp.Group("/api/v1")
+	httpv1.RegisterStatusEndpoints(apiV1, statusController)
 }
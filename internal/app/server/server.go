@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/repo"
 	"CryptocoinPrice/internal/app/server/middleware"
 
 	"CryptocoinPrice/internal/pkg/jsonify"
@@ -35,8 +36,35 @@ type Server struct {
 //
 // New returns new server instance.
 func New(cfg *config.Config, dbStorage *gorm.DB,
+	priceRepoAPI repo.PriceRepoAPI, coinRepoAPI repo.CoinRepoAPI,
+	historyRepoAPI repo.PriceHistoryRepoAPI, providerStatusAPI repo.ProviderStatusAPI,
+	leaderStatusAPI repo.LeaderStatusAPI, spoolStatusAPI repo.SpoolStatusAPI,
 	valid validator.Validator, jsonifier jsonify.Jsonify) (*Server, error) {
 
+	server := newServer(cfg, jsonifier)
+	server.fiberApp.Use(middleware.Swagger())
+	// register all endpoints
+	server.registerEndpointsV1(dbStorage, priceRepoAPI, coinRepoAPI, historyRepoAPI,
+		providerStatusAPI, leaderStatusAPI, spoolStatusAPI, valid)
+
+	return server, nil
+}
+
+// NewStatus returns new server instance with service status endpoint only.
+// It is used by the replica which collects prices without serving API.
+func NewStatus(cfg *config.Config, providerStatusAPI repo.ProviderStatusAPI,
+	leaderStatusAPI repo.LeaderStatusAPI, spoolStatusAPI repo.SpoolStatusAPI,
+	jsonifier jsonify.Jsonify) *Server {
+
+	server := newServer(cfg, jsonifier)
+	// register status endpoint
+	server.registerStatusEndpointsV1(providerStatusAPI, leaderStatusAPI, spoolStatusAPI)
+
+	return server
+}
+
+// newServer returns new server instance with base middlewares and without endpoints.
+func newServer(cfg *config.Config, jsonifier jsonify.Jsonify) *Server {
 	// fiber init
 	server := &Server{
 		cfg: cfg,
@@ -54,11 +82,7 @@ func New(cfg *config.Config, dbStorage *gorm.DB,
 		server.fiberApp.Use(httpLogger)
 	}
 	server.fiberApp.Use(middleware.Recover())
-	server.fiberApp.Use(middleware.Swagger())
-	// register all endpoints
-	server.registerEndpointsV1(cfg, dbStorage, valid)
-
-	return server, nil
+	return server
 }
 
 // StartWithShutdown starts server and waits for
@@ -0,0 +1,77 @@
+// Package spoolreplayer provides service to replay prices which
+// are failed to save into DB from on-disk spool of the replica.
+package spoolreplayer
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"CryptocoinPrice/config"
+	"CryptocoinPrice/internal/app/repo"
+	repopg "CryptocoinPrice/internal/app/repo/pg"
+	"CryptocoinPrice/internal/app/usecase"
+)
+
+// Spool replayer. It periodically replays spooled prices into DB.
+// Spool is kept on disk of each replica, so replayer is run by each
+// collector replica whether it is the leader or not.
+type SpoolReplayer struct {
+	spoolReplayUC usecase.SpoolReplayUsecase
+	// interval to replay spooled prices (it bounds one replay too)
+	interval time.Duration
+}
+
+// New returns new spool replayer instance for given spool.
+func New(cfg *config.Config, db *gorm.DB, spoolRepo repo.PriceSpoolRepo) *SpoolReplayer {
+	// create usecases
+	spoolReplayUC := usecase.NewSpoolReplayUC(repopg.NewPriceRepoPG(db), spoolRepo)
+
+	return NewWithUsecase(cfg, spoolReplayUC)
+}
+
+// NewWithUsecase returns new spool replayer instance with given usecase.
+func NewWithUsecase(cfg *config.Config,
+	spoolReplayUC usecase.SpoolReplayUsecase) *SpoolReplayer {
+
+	return &SpoolReplayer{
+		spoolReplayUC: spoolReplayUC,
+		interval:      cfg.App.PriceSpoolReplayInterval,
+	}
+}
+
+// StartWithShutdown starts spool replayer and waits for
+// context is done for gracefully shutdown replayer.
+// Replay in progress is canceled on shutdown.
+// This method is blocking.
+func (r *SpoolReplayer) StartWithShutdown(ctx context.Context) error {
+	logrus.Info("Start spool replayer")
+	defer logrus.Info("Spool replayer is shutdown")
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.replay(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// replay saves spooled prices which are failed to save before.
+// Replay is bounded by replay interval.
+func (r *SpoolReplayer) replay(ctx context.Context) {
+	replayCtx, cancel := context.WithTimeout(ctx, r.interval)
+	defer cancel()
+	replayed, err := r.spoolReplayUC.ReplaySpooledPrices(replayCtx)
+	if replayed != 0 {
+		logrus.Infof("%d spooled prices are saved", replayed)
+	}
+	if err != nil && ctx.Err() == nil {
+		logrus.Warnf("Replay spooled prices: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package spoolreplayer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/config"
+)
+
+const _testWait = time.Second // max time to wait for replay
+
+// stubSpoolReplayUC is a spool replay usecase stub which counts replays.
+// Each replay is blocked until its context is done.
+type stubSpoolReplayUC struct {
+	replays  atomic.Int32
+	canceled atomic.Int32
+}
+
+func (u *stubSpoolReplayUC) ReplaySpooledPrices(ctx context.Context) (int, error) {
+	u.replays.Add(1)
+	<-ctx.Done()
+	u.canceled.Add(1)
+	return 0, ctx.Err()
+}
+
+func TestSpoolReplayer_StartWithShutdown(t *testing.T) {
+	t.Log("Replay spool periodically with bounded replays and cancel replay on shutdown")
+
+	uc := &stubSpoolReplayUC{}
+	replayer := NewWithUsecase(&config.Config{App: config.App{
+		PriceSpoolReplayInterval: 10 * time.Millisecond,
+	}}, uc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- replayer.StartWithShutdown(ctx)
+	}()
+	// hung replay is bounded by interval and the next replay is started
+	require.Eventually(t, func() bool {
+		return uc.replays.Load() >= 2
+	}, _testWait, 5*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-stopped)
+	require.Equal(t, uc.replays.Load(), uc.canceled.Load())
+}
@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ BackfillUsecase = (*BackfillUC)(nil)
+
+const (
+	// max window of one request to provider (wider windows have daily granularity)
+	_backfillChunk = 90 * 24 * time.Hour
+	// time to keep finished backfill jobs
+	_backfillJobTTL = 24 * time.Hour
+)
+
+type BackfillUC struct {
+	coinRepoDB      repo.CoinRepoDB
+	priceRepoDB     repo.PriceRepoDB
+	jobRepoDB       repo.BackfillJobRepoDB
+	historyRepoAPI  repo.PriceHistoryRepoAPI
+	quoteCurrencies []string
+}
+
+// NewBackfillUC returns new historical prices backfill usecase.
+// Prices are backfilled in each of given quote currencies.
+// The historyRepoAPI can be nil, then backfill is unavailable.
+func NewBackfillUC(coinRepoDB repo.CoinRepoDB, priceRepoDB repo.PriceRepoDB,
+	jobRepoDB repo.BackfillJobRepoDB, historyRepoAPI repo.PriceHistoryRepoAPI,
+	quoteCurrencies []string) *BackfillUC {
+
+	return &BackfillUC{
+		coinRepoDB:      coinRepoDB,
+		priceRepoDB:     priceRepoDB,
+		jobRepoDB:       jobRepoDB,
+		historyRepoAPI:  historyRepoAPI,
+		quoteCurrencies: quoteCurrencies,
+	}
+}
+
+// StartBackfill queues backfill of coin prices from given timestamp to given
+// one and returns its job. Queued jobs are run in background by the replica
+// collecting prices. If backfill of the coin is already queued or running its
+// job is returned. If "to" is zero or in future the current time is used.
+// If externalID is given it is used to choose one of coins with the same symbol.
+func (u *BackfillUC) StartBackfill(symbol, externalID string,
+	from, to int64) (*entity.BackfillJob, error) {
+
+	_, job, err := u.newJob(symbol, externalID, u.quoteCurrencies, from, to)
+	if err != nil {
+		return nil, err
+	}
+	job.State, job.StartedAt = entity.BackfillStateQueued, 0
+
+	job, err = u.jobRepoDB.CreateActive(job)
+	if err != nil {
+		return nil, fmt.Errorf("create job: %w", err)
+	}
+	return job, nil
+}
+
+// Backfill backfills coin prices in given quote currencies (all quote currencies
+// if empty) from given timestamp to given one and returns finished job.
+// The onProgress func (optional) is called after each request to provider.
+// If "to" is zero or in future the current time is used.
+// If externalID is given it is used to choose one of coins with the same symbol.
+// Requests to provider are canceled if context is done.
+func (u *BackfillUC) Backfill(ctx context.Context, symbol, externalID string,
+	currencies []string, from, to int64,
+	onProgress func(job entity.BackfillJob)) (*entity.BackfillJob, error) {
+
+	if len(currencies) == 0 {
+		currencies = u.quoteCurrencies
+	}
+	coin, job, err := u.newJob(symbol, externalID, currencies, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.run(ctx, coin, currencies, job, onProgress); err != nil {
+		return job, err
+	}
+	return job, nil
+}
+
+// GetBackfillJob returns queued backfill job.
+func (u *BackfillUC) GetBackfillJob(jobID string) (*entity.BackfillJob, error) {
+	job, err := u.jobRepoDB.GetByID(jobID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, fmt.Errorf("backfill job: %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get job: %w", err)
+	}
+	return job, nil
+}
+
+// RunQueuedJob runs the oldest queued backfill job and returns it finished.
+// Job progress is saved after each request to provider. If context is done
+// job is queued again to be run from the beginning (e.g. by the next leader).
+// If there are no queued jobs not found error is returned.
+func (u *BackfillUC) RunQueuedJob(ctx context.Context) (*entity.BackfillJob, error) {
+	if u.historyRepoAPI == nil {
+		return nil, fmt.Errorf("%w: historical prices provider is not configured",
+			ErrUnavailable)
+	}
+
+	job, err := u.jobRepoDB.ClaimQueued(time.Now().UTC().Unix())
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, fmt.Errorf("queued backfill job: %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim queued job: %w", err)
+	}
+
+	runErr := u.run(ctx, job.Coin, u.quoteCurrencies, job, func(progress entity.BackfillJob) {
+		if err := u.jobRepoDB.Update(&progress); err != nil {
+			logrus.Warnf("Save backfill %s progress: %v", progress.Symbol, err)
+		}
+	})
+	if ctx.Err() != nil {
+		*job = entity.BackfillJob{
+			ID:         job.ID,
+			CoinID:     job.CoinID,
+			Symbol:     job.Symbol,
+			From:       job.From,
+			To:         job.To,
+			State:      entity.BackfillStateQueued,
+			TotalSteps: job.TotalSteps,
+			QueuedAt:   job.QueuedAt,
+		}
+	}
+	if err := u.jobRepoDB.Update(job); err != nil {
+		return job, fmt.Errorf("update job: %w", err)
+	}
+	return job, runErr
+}
+
+// RequeueJobs queues again running backfill jobs to run them from the
+// beginning and returns amount of requeued jobs. It is used to resume jobs
+// of the replica which stopped collecting prices without finishing them.
+func (u *BackfillUC) RequeueJobs() (int, error) {
+	requeued, err := u.jobRepoDB.RequeueRunning()
+	if err != nil {
+		return 0, fmt.Errorf("requeue running jobs: %w", err)
+	}
+	return requeued, nil
+}
+
+// PruneJobs removes jobs finished before job TTL and returns amount of them.
+func (u *BackfillUC) PruneJobs() (int, error) {
+	expiredAt := time.Now().Add(-_backfillJobTTL).Unix()
+	pruned, err := u.jobRepoDB.DeleteFinishedBefore(expiredAt)
+	if err != nil {
+		return 0, fmt.Errorf("delete finished jobs: %w", err)
+	}
+	return pruned, nil
+}
+
+// newJob validates backfill params and returns coin and new job
+// for it to backfill prices in given quote currencies.
+func (u *BackfillUC) newJob(symbol, externalID string, currencies []string,
+	from, to int64) (*entity.Coin, *entity.BackfillJob, error) {
+
+	if u.historyRepoAPI == nil {
+		return nil, nil, fmt.Errorf("%w: historical prices provider is not configured",
+			ErrUnavailable)
+	}
+
+	now := time.Now().UTC().Unix()
+	if to == 0 || to > now {
+		to = now
+	}
+	if from < 0 || from >= to {
+		return nil, nil, fmt.Errorf("%w: window start must be before its end", ErrValidateData)
+	}
+
+	// get coin from DB by symbol
+	coin, err := getCoin(u.coinRepoDB, symbol, externalID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if coin.ExternalID == "" {
+		return nil, nil, fmt.Errorf("%w: coin %s has no canonical id", ErrValidateData, symbol)
+	}
+
+	chunkSeconds := int64(_backfillChunk.Seconds())
+	chunks := int((to - from + chunkSeconds - 1) / chunkSeconds)
+	return coin, &entity.BackfillJob{
+		ID:         uuid.NewString(),
+		CoinID:     coin.ID,
+		Symbol:     coin.Symbol,
+		From:       from,
+		To:         to,
+		State:      entity.BackfillStateRunning,
+		TotalSteps: chunks * len(currencies),
+		QueuedAt:   now,
+		StartedAt:  now,
+	}, nil
+}
+
+// run backfills job window by chunks in each of given quote currencies
+// from the oldest chunk to the newest one and updates job progress.
+// Requests to provider are canceled if context is done.
+func (u *BackfillUC) run(ctx context.Context, coin *entity.Coin, currencies []string,
+	job *entity.BackfillJob, onProgress func(job entity.BackfillJob)) error {
+
+	logrus.Infof("Start backfill %s prices from %d to %d", job.Symbol, job.From, job.To)
+	chunkSeconds := int64(_backfillChunk.Seconds())
+	for chunkFrom := job.From; chunkFrom < job.To; chunkFrom += chunkSeconds {
+		chunkTo := min(chunkFrom+chunkSeconds, job.To)
+		for _, currency := range currencies {
+			saved, skipped, err := u.backfillChunk(ctx, coin, currency, chunkFrom, chunkTo)
+			if err != nil {
+				err = fmt.Errorf("backfill %s from %d to %d: %w",
+					currency, chunkFrom, chunkTo, err)
+				u.finishJob(job, err)
+				logrus.Errorf("Backfill %s prices: %v", job.Symbol, err)
+				return err
+			}
+
+			job.DoneSteps++
+			job.Saved += saved
+			job.Skipped += skipped
+			progress := *job
+
+			logrus.Infof("Backfill %s prices: %d/%d steps, %d saved, %d skipped",
+				progress.Symbol, progress.DoneSteps, progress.TotalSteps,
+				progress.Saved, progress.Skipped)
+			if onProgress != nil {
+				onProgress(progress)
+			}
+		}
+	}
+	u.finishJob(job, nil)
+	logrus.Infof("Backfill %s prices is done: %d saved, %d skipped",
+		job.Symbol, job.Saved, job.Skipped)
+	return nil
+}
+
+// backfillChunk saves coin prices in quote currency from given timestamp
+// to given one skipping already saved ones. It returns amounts of saved
+// and skipped prices.
+func (u *BackfillUC) backfillChunk(ctx context.Context, coin *entity.Coin, currency string,
+	from, to int64) (saved, skipped int, err error) {
+
+	coinPrices, err := u.historyRepoAPI.PriceHistory(ctx, coin, currency, from, to)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get price history: %w", err)
+	}
+	timestamps, err := u.priceRepoDB.GetTimestamps(coin.ID, currency, from, to)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get saved timestamps: %w", err)
+	}
+
+	// index saved timestamps to skip duplicates
+	savedTimestamps := make(map[int64]struct{}, len(timestamps)+len(coinPrices))
+	for _, timestamp := range timestamps {
+		savedTimestamps[timestamp] = struct{}{}
+	}
+	ingestTime := time.Now().UTC().Unix()
+	priceList := make(entity.PriceList, 0, len(coinPrices))
+	for _, coinPrice := range coinPrices {
+		if _, found := savedTimestamps[coinPrice.LastUpdate]; found {
+			skipped++
+			continue
+		}
+		savedTimestamps[coinPrice.LastUpdate] = struct{}{}
+		priceList = append(priceList, entity.Price{
+			CoinID:     coin.ID,
+			Price:      coinPrice.Price,
+			Currency:   coinPrice.Currency,
+			Timestamp:  coinPrice.LastUpdate,
+			IngestedAt: ingestTime,
+			Source:     coinPrice.Source,
+		})
+	}
+
+	if len(priceList) == 0 {
+		return 0, skipped, nil
+	}
+	savedList, err := u.priceRepoDB.CreateMany(ctx, priceList)
+	if err != nil {
+		return 0, 0, fmt.Errorf("create many: %w", err)
+	}
+	// prices saved concurrently (e.g. by collector) are skipped too
+	skipped += len(priceList) - len(savedList)
+	return len(savedList), skipped, nil
+}
+
+// finishJob sets job finish state with given error.
+func (u *BackfillUC) finishJob(job *entity.BackfillJob, err error) {
+	job.State = entity.BackfillStateDone
+	if err != nil {
+		job.State, job.Error = entity.BackfillStateFailed, err.Error()
+	}
+	job.FinishedAt = time.Now().UTC().Unix()
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+const _testWait = time.Second // max time to wait for background job
+
+// stubPriceHistoryRepoAPI is a historical prices API repo stub
+// which blocks requests until context is done.
+type stubPriceHistoryRepoAPI struct {
+	requested chan struct{}
+}
+
+func (r *stubPriceHistoryRepoAPI) PriceHistory(ctx context.Context, _ *entity.Coin,
+	_ string, _, _ int64) (entity.CoinPriceAPIList, error) {
+
+	r.requested <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// stubBackfillJobRepoDB is a backfill job DB repo stub
+// which keeps jobs in memory.
+type stubBackfillJobRepoDB struct {
+	coins entity.CoinList
+	jobs  []*entity.BackfillJob
+}
+
+func (r *stubBackfillJobRepoDB) CreateActive(
+	job *entity.BackfillJob) (*entity.BackfillJob, error) {
+
+	for _, savedJob := range r.jobs {
+		if savedJob.CoinID == job.CoinID && (savedJob.State == entity.BackfillStateQueued ||
+			savedJob.State == entity.BackfillStateRunning) {
+
+			jobCopy := *savedJob
+			return &jobCopy, nil
+		}
+	}
+	job.ID = strconv.Itoa(len(r.jobs))
+	jobCopy := *job
+	r.jobs = append(r.jobs, &jobCopy)
+	return job, nil
+}
+
+func (r *stubBackfillJobRepoDB) GetByID(jobID string) (*entity.BackfillJob, error) {
+	for _, savedJob := range r.jobs {
+		if savedJob.ID == jobID {
+			jobCopy := *savedJob
+			return &jobCopy, nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *stubBackfillJobRepoDB) ClaimQueued(startedAt int64) (*entity.BackfillJob, error) {
+	for _, savedJob := range r.jobs {
+		if savedJob.State != entity.BackfillStateQueued {
+			continue
+		}
+		savedJob.State, savedJob.StartedAt = entity.BackfillStateRunning, startedAt
+		jobCopy := *savedJob
+		for i := range r.coins {
+			if r.coins[i].ID == jobCopy.CoinID {
+				jobCopy.Coin = &r.coins[i]
+			}
+		}
+		return &jobCopy, nil
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *stubBackfillJobRepoDB) Update(job *entity.BackfillJob) error {
+	for _, savedJob := range r.jobs {
+		if savedJob.ID == job.ID {
+			*savedJob = *job
+			savedJob.Coin = nil
+		}
+	}
+	return nil
+}
+
+func (r *stubBackfillJobRepoDB) RequeueRunning() (int, error) {
+	return 0, nil
+}
+
+func (r *stubBackfillJobRepoDB) DeleteFinishedBefore(int64) (int, error) {
+	return 0, nil
+}
+
+func TestBackfillUC_RunQueuedJob(t *testing.T) {
+	t.Log("Queue backfill job and queue it again when running is canceled")
+
+	coins := entity.CoinList{{ID: "btc-id", Symbol: "btc", ExternalID: "bitcoin"}}
+	jobRepoDB := &stubBackfillJobRepoDB{coins: coins}
+	historyRepoAPI := &stubPriceHistoryRepoAPI{requested: make(chan struct{}, 1)}
+	uc := NewBackfillUC(&stubCoinRepoDB{observed: coins}, &stubPriceRepoDB{},
+		jobRepoDB, historyRepoAPI, []string{"usd"})
+
+	job, err := uc.StartBackfill("btc", "", 1754006400, 1754092800)
+	require.NoError(t, err)
+	require.Equal(t, entity.BackfillStateQueued, job.State)
+	sameJob, err := uc.StartBackfill("btc", "", 1754006400, 1754092800)
+	require.NoError(t, err)
+	require.Equal(t, job.ID, sameJob.ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-historyRepoAPI.requested:
+		case <-time.After(_testWait):
+		}
+		cancel()
+	}()
+	_, err = uc.RunQueuedJob(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	job, err = uc.GetBackfillJob(job.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.BackfillStateQueued, job.State)
+	require.Empty(t, job.Error)
+	t.Logf("Backfill job: %+v", job)
+
+	_, err = uc.GetBackfillJob("unknown")
+	require.ErrorIs(t, err, ErrNotFound)
+}
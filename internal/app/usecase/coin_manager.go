@@ -3,10 +3,13 @@ package usecase
 import (
 	"CryptocoinPrice/internal/app/entity"
 	"CryptocoinPrice/internal/app/repo"
+	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,74 +19,174 @@ type CoinManageUC struct {
 	coinRepoDB   repo.CoinRepoDB
 	priceRepoDB  repo.PriceRepoDB
 	priceRepoAPI repo.PriceRepoAPI
+	coinRepoAPI  repo.CoinRepoAPI
+	// default quote currency
+	currency string
 }
 
 // NewCoinManageUC returns new coin manage usecase.
-func NewCoinManageUC(coinRepoDB repo.CoinRepoDB,
-	priceRepoDB repo.PriceRepoDB, priceRepoAPI repo.PriceRepoAPI) *CoinManageUC {
+// The coinRepoAPI is used to resolve coin symbols into canonical coin IDs.
+// It can be nil, then coins are observed by symbols only.
+// The first of given quote currencies is used as default one.
+func NewCoinManageUC(coinRepoDB repo.CoinRepoDB, priceRepoDB repo.PriceRepoDB,
+	priceRepoAPI repo.PriceRepoAPI, coinRepoAPI repo.CoinRepoAPI,
+	quoteCurrencies []string) *CoinManageUC {
 
 	return &CoinManageUC{
 		coinRepoDB:   coinRepoDB,
 		priceRepoDB:  priceRepoDB,
 		priceRepoAPI: priceRepoAPI,
+		coinRepoAPI:  coinRepoAPI,
+		currency:     quoteCurrencies[0],
 	}
 }
 
 // ObserveCoin creates new observed coin or sets observed on true for existing coin.
-func (u *CoinManageUC) ObserveCoin(symbol string) (*entity.Coin, error) {
-	// get coin from DB by symbol
-	coin, err := u.coinRepoDB.GetBySymbol(symbol)
-	// true if coin is not found
-	coinNotFound := errors.Is(err, repo.ErrNotFound)
+// If externalID is given it is used to choose one of coins with the same symbol,
+// and new coin is created if no one of existing coins with the symbol has it.
+// Request to price API is canceled if context is done.
+func (u *CoinManageUC) ObserveCoin(ctx context.Context,
+	symbol, externalID string) (*entity.Coin, error) {
+
+	// get coins from DB by symbol
+	coinList, err := u.coinRepoDB.GetBySymbol(symbol)
 	// if unknown error
-	if err != nil && !coinNotFound {
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
 		return nil, fmt.Errorf("get by symbol: %w", err)
 	}
 
 	// if coin is found - set observed on true for coin
-	if !coinNotFound {
-		coin.Observed = true
-		coinUpdates := &entity.CoinPartial{Observed: &coin.Observed}
-		if err := u.coinRepoDB.Update(coin.ID, coinUpdates); err != nil {
-			return nil, fmt.Errorf("update: %w", err)
-		}
-		return coin, nil
+	coin, err := pickCoin(coinList, symbol, externalID)
+	if err == nil {
+		return u.reobserveCoin(coin, externalID)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	// the only coin without canonical ID is clarified with given one
+	if len(coinList) == 1 && coinList[0].ExternalID == "" {
+		return u.reobserveCoin(&coinList[0], externalID)
 	}
 
 	// if coin is not found
+	// resolve coin symbol into the canonical coin
+	coin, err = u.resolveCoin(symbol, externalID)
+	if err != nil {
+		return nil, err
+	}
 	// get coin price from API to check that coin exists in the world.
-	coinPrice, err := u.priceRepoAPI.OneCoinPrice(symbol)
+	coinPrice, err := u.priceRepoAPI.OneCoinPrice(ctx, coin, u.currency)
 	// if coin symbol is invalid
 	if errors.Is(err, repo.ErrValidateData) {
 		return nil, fmt.Errorf("%w: invalid symbol: unexisting coin", ErrValidateData)
 	}
-	if err != nil {
+	// if providers are failing
+	var openErr *repo.CircuitOpenError
+	if errors.As(err, &openErr) {
+		return nil, &UnavailableError{RetryAfter: openErr.RetryAfter, Err: err}
+	}
+	// if API quota is exhausted but coin is already resolved with cached coin list
+	// it is observed without initial price
+	quotaExhausted := errors.Is(err, repo.ErrQuotaExhausted) && coin.ExternalID != ""
+	if err != nil && !quotaExhausted {
 		return nil, fmt.Errorf("check coin: %w", err)
 	}
 
 	// create coin
-	coin, err = u.coinRepoDB.Create(symbol)
+	coin, err = u.coinRepoDB.Create(coin)
 	if err != nil {
 		return nil, fmt.Errorf("create: %w", err)
 	}
+	if quotaExhausted {
+		logrus.Warnf("Observe coin %s without initial price: api quota is exhausted", coin.Symbol)
+		return coin, nil
+	}
 	// save coin price into DB
-	_, err = u.priceRepoDB.Create(coin, coinPrice.Price, time.Now().UTC().Unix())
+	_, err = u.priceRepoDB.Create(&entity.Price{
+		CoinID:     coin.ID,
+		Price:      coinPrice.Price,
+		Currency:   coinPrice.Currency,
+		Timestamp:  coinPrice.LastUpdate,
+		IngestedAt: time.Now().UTC().Unix(),
+		Source:     coinPrice.Source,
+		Coin:       coin,
+		Quotes:     coinPrice.Quotes,
+	})
 	if err != nil {
 		logrus.Errorf("Save coin price into DB: %v", err)
 	}
 	return coin, nil
 }
 
+// reobserveCoin sets observed on true for existing coin.
+// If externalID is given and the coin has no canonical ID,
+// the coin canonical ID is set to it.
+func (u *CoinManageUC) reobserveCoin(coin *entity.Coin,
+	externalID string) (*entity.Coin, error) {
+
+	coin.Observed = true
+	coinUpdates := &entity.CoinPartial{Observed: &coin.Observed}
+	// clarify canonical coin
+	if externalID != "" && externalID != coin.ExternalID {
+		resolvedCoin, err := u.resolveCoin(coin.Symbol, externalID)
+		if err != nil {
+			return nil, err
+		}
+		coin.ExternalID, coin.Name = resolvedCoin.ExternalID, resolvedCoin.Name
+		coinUpdates.ExternalID, coinUpdates.Name = &coin.ExternalID, &coin.Name
+	}
+
+	if err := u.coinRepoDB.Update(coin.ID, coinUpdates); err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+	return coin, nil
+}
+
+// resolveCoin returns new coin with canonical ID for given symbol.
+// If many coins have given symbol the externalID is used to choose
+// one of them, and if it is empty the ambiguous coin error is returned.
+// If coin API repo is not set the externalID is used as is.
+func (u *CoinManageUC) resolveCoin(symbol, externalID string) (*entity.Coin, error) {
+	coin := &entity.Coin{Symbol: symbol, ExternalID: externalID}
+	if u.coinRepoAPI == nil {
+		return coin, nil
+	}
+
+	candidates, err := u.coinRepoAPI.SearchBySymbol(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("search coin: %w", err)
+	}
+	// if coin symbol is invalid
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: invalid symbol: unexisting coin", ErrValidateData)
+	}
+
+	candidateIdx := 0
+	switch {
+	case externalID != "":
+		candidateIdx = slices.IndexFunc(candidates, func(candidate entity.CoinCandidate) bool {
+			return candidate.ExternalID == externalID
+		})
+		if candidateIdx == -1 {
+			return nil, fmt.Errorf("%w: invalid id: coin %s with id %s does not exist",
+				ErrValidateData, symbol, externalID)
+		}
+	case len(candidates) > 1:
+		return nil, &AmbiguousCoinError{Symbol: symbol, Candidates: candidates}
+	}
+
+	coin.ExternalID = candidates[candidateIdx].ExternalID
+	coin.Name = candidates[candidateIdx].Name
+	return coin, nil
+}
+
 // DisableObserveCoin sets observed on false for coin.
-func (u *CoinManageUC) DisableObserveCoin(symbol string) (*entity.Coin, error) {
+// If externalID is given it is used to choose one of coins with the same symbol.
+func (u *CoinManageUC) DisableObserveCoin(symbol, externalID string) (*entity.Coin, error) {
 	// get coin from DB by symbol
-	coin, err := u.coinRepoDB.GetBySymbol(symbol)
-	// if coin is not found
-	if errors.Is(err, repo.ErrNotFound) {
-		return nil, fmt.Errorf("get coin: %w", ErrNotFound)
-	}
+	coin, err := getCoin(u.coinRepoDB, symbol, externalID)
 	if err != nil {
-		return nil, fmt.Errorf("get by symbol: %w", err)
+		return nil, err
 	}
 
 	coin.Observed = false
@@ -94,26 +197,251 @@ func (u *CoinManageUC) DisableObserveCoin(symbol string) (*entity.Coin, error) {
 	return coin, nil
 }
 
-// GetNearestPrice returns first price with coin symbol
-// and nearest timestamp for given timestamp.
-func (u *CoinManageUC) GetNearestPrice(symbol string, timestamp int64) (*entity.Price, error) {
+// SetCollectInterval sets interval between coin price collections
+// in seconds. Zero interval resets it to the default one.
+// If externalID is given it is used to choose one of coins with the same symbol.
+func (u *CoinManageUC) SetCollectInterval(symbol, externalID string,
+	interval int64) (*entity.Coin, error) {
+
+	if interval < 0 {
+		return nil, fmt.Errorf("%w: collect interval must not be negative", ErrValidateData)
+	}
 	// get coin from DB by symbol
-	coin, err := u.coinRepoDB.GetBySymbol(symbol)
-	// if coin is not found
-	if errors.Is(err, repo.ErrNotFound) {
-		return nil, fmt.Errorf("get coin: %w", ErrNotFound)
+	coin, err := getCoin(u.coinRepoDB, symbol, externalID)
+	if err != nil {
+		return nil, err
+	}
+
+	coin.CollectInterval = interval
+	coinUpdates := &entity.CoinPartial{CollectInterval: &coin.CollectInterval}
+	if err := u.coinRepoDB.Update(coin.ID, coinUpdates); err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+	return coin, nil
+}
+
+// GetPrice returns price of coin with symbol in quote currency at given
+// timestamp on given time axis found in given lookup mode: the nearest
+// price, the last price before timestamp, the first price after it or
+// price linearly interpolated between them.
+// If currency is empty the default quote currency is used.
+// If time axis is empty the source timestamp is used.
+// If lookup mode is empty the nearest price is found.
+// If max distance is positive prices farther from timestamp
+// (in seconds) are not found.
+// If externalID is given it is used to choose one of coins with the same symbol.
+func (u *CoinManageUC) GetPrice(symbol, externalID, currency string, timestamp int64,
+	timeAxis, mode string, maxDistance int64) (*entity.LookupPrice, error) {
+
+	if currency == "" {
+		currency = u.currency
+	}
+	if timeAxis == "" {
+		timeAxis = entity.TimeAxisSource
+	}
+	if mode == "" {
+		mode = entity.LookupNearest
 	}
+	// get coin from DB by symbol
+	coin, err := getCoin(u.coinRepoDB, symbol, externalID)
+	if err != nil {
+		return nil, err
+	}
+
+	before, after, err := u.priceRepoDB.GetSurrounding(coin, currency,
+		timestamp, timeAxis, maxDistance)
 	if err != nil {
-		return nil, fmt.Errorf("get coin by symbol: %w", err)
+		return nil, fmt.Errorf("price: %w", err)
+	}
+	// choose prices the price is found by
+	sources := make(entity.PriceList, 0, 2) // nolint:mnd // before and after prices
+	switch mode {
+	case entity.LookupNearest:
+		nearest := before
+		if after != nil && (before == nil || axisDistance(after, timeAxis, timestamp) <
+			axisDistance(before, timeAxis, timestamp)) {
+
+			nearest = after
+		}
+		if nearest != nil {
+			sources = append(sources, *nearest)
+		}
+	case entity.LookupBefore:
+		if before != nil {
+			sources = append(sources, *before)
+		}
+	case entity.LookupAfter:
+		if after != nil {
+			sources = append(sources, *after)
+		}
+	case entity.LookupLinear:
+		if before == nil || after == nil {
+			break
+		}
+		sources = append(sources, *before)
+		// price at timestamp is not interpolated
+		if axisTimestamp(before, timeAxis) != timestamp {
+			sources = append(sources, *after)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown lookup mode %q", ErrValidateData, mode)
 	}
 
-	price, err := u.priceRepoDB.GetNearestTimestamp(coin, timestamp)
 	// if price is not found
-	if errors.Is(err, repo.ErrNotFound) {
+	if len(sources) == 0 && maxDistance > 0 {
+		return nil, fmt.Errorf("price within %d seconds: %w", maxDistance, ErrNotFound)
+	}
+	if len(sources) == 0 {
 		return nil, fmt.Errorf("price: %w", ErrNotFound)
 	}
+	return newLookupPrice(sources, mode, timeAxis, timestamp), nil
+}
+
+// GetPriceHistory returns page of prices of coin with symbol in quote
+// currency with source timestamp from given timestamp to given one
+// inclusive ordered by source timestamp in given order. The page starts
+// after given cursor (from the first price if cursor is nil) and contains
+// at most limit prices. Cursor of the next page is returned (nil if the
+// page is the last one).
+// If currency is empty the default quote currency is used.
+// If order is empty prices are ordered from the oldest one.
+// If externalID is given it is used to choose one of coins with the same symbol.
+func (u *CoinManageUC) GetPriceHistory(symbol, externalID, currency string, from, to int64,
+	order string, cursor *entity.PriceCursor,
+	limit int) (entity.PriceList, *entity.PriceCursor, error) {
+
+	if currency == "" {
+		currency = u.currency
+	}
+	if order == "" {
+		order = entity.OrderAsc
+	}
+	// get coin from DB by symbol
+	coin, err := getCoin(u.coinRepoDB, symbol, externalID)
 	if err != nil {
-		return nil, fmt.Errorf("price: %w", err)
+		return nil, nil, err
+	}
+
+	// one more price is got to know whether the next page exists
+	priceList, err := u.priceRepoDB.GetRange(coin.ID, currency, from, to, order,
+		cursor, limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get prices: %w", err)
+	}
+	if len(priceList) <= limit {
+		return priceList, nil, nil
+	}
+
+	priceList = priceList[:limit]
+	last := priceList[limit-1]
+	return priceList, &entity.PriceCursor{Timestamp: last.Timestamp, ID: last.ID}, nil
+}
+
+// getCoin returns coin with given symbol from DB. If externalID is given
+// it is used to choose one of coins with the same symbol, and if it is empty
+// and many coins have the symbol the ambiguous coin error is returned.
+func getCoin(coinRepoDB repo.CoinRepoDB, symbol, externalID string) (*entity.Coin, error) {
+	coinList, err := coinRepoDB.GetBySymbol(symbol)
+	// if coin is not found
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, fmt.Errorf("get coin: %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get by symbol: %w", err)
+	}
+	return pickCoin(coinList, symbol, externalID)
+}
+
+// pickCoin returns one of given coins with the symbol. If externalID is given
+// the coin with it is returned, and if it is empty and many coins are given
+// the ambiguous coin error is returned with the coins as candidates.
+// If coin is not found it returns not found error.
+func pickCoin(coinList entity.CoinList, symbol, externalID string) (*entity.Coin, error) {
+	if externalID != "" {
+		coinIdx := slices.IndexFunc(coinList, func(coin entity.Coin) bool {
+			return coin.ExternalID == externalID
+		})
+		if coinIdx == -1 {
+			return nil, fmt.Errorf("get coin %s with id %s: %w", symbol, externalID, ErrNotFound)
+		}
+		return &coinList[coinIdx], nil
+	}
+
+	switch len(coinList) {
+	case 0:
+		return nil, fmt.Errorf("get coin: %w", ErrNotFound)
+	case 1:
+		return &coinList[0], nil
+	}
+	candidates := make(entity.CoinCandidateList, 0, len(coinList))
+	for _, coin := range coinList {
+		candidates = append(candidates, entity.CoinCandidate{
+			ExternalID: coin.ExternalID,
+			Symbol:     coin.Symbol,
+			Name:       coin.Name,
+		})
+	}
+	return nil, &AmbiguousCoinError{Symbol: symbol, Candidates: candidates}
+}
+
+// newLookupPrice returns price found in lookup mode by given prices.
+// If two prices are given the price at timestamp on time axis is
+// linearly interpolated between them.
+func newLookupPrice(sources entity.PriceList, mode, timeAxis string,
+	timestamp int64) *entity.LookupPrice {
+
+	price := &entity.LookupPrice{
+		Price:      sources[0].Price,
+		Currency:   sources[0].Currency,
+		Timestamp:  sources[0].Timestamp,
+		IngestedAt: sources[0].IngestedAt,
+		Mode:       mode,
+		Sources:    sources,
+	}
+	if len(sources) == 1 {
+		return price
+	}
+
+	before, after := &sources[0], &sources[1]
+	from, to := axisTimestamp(before, timeAxis), axisTimestamp(after, timeAxis)
+	// price = before + (after - before) * (timestamp - from) / (to - from)
+	price.Price = before.Price.Add(after.Price.Sub(before.Price).
+		Mul(decimal.NewFromInt(timestamp - from)).
+		Div(decimal.NewFromInt(to - from)))
+	// requested timestamp is on its time axis and timestamp on the other axis
+	// is interpolated in the same way
+	if timeAxis == entity.TimeAxisIngested {
+		price.IngestedAt = timestamp
+		price.Timestamp = interpolateTimestamp(before.Timestamp, after.Timestamp,
+			timestamp-from, to-from)
+	} else {
+		price.Timestamp = timestamp
+		price.IngestedAt = interpolateTimestamp(before.IngestedAt, after.IngestedAt,
+			timestamp-from, to-from)
+	}
+	return price
+}
+
+// interpolateTimestamp returns timestamp between given ones which is
+// at given part (numerator/denominator) of distance between them.
+func interpolateTimestamp(from, to, numerator, denominator int64) int64 {
+	return from + (to-from)*numerator/denominator
+}
+
+// axisTimestamp returns price timestamp on given time axis.
+func axisTimestamp(price *entity.Price, timeAxis string) int64 {
+	if timeAxis == entity.TimeAxisIngested {
+		return price.IngestedAt
+	}
+	return price.Timestamp
+}
+
+// axisDistance returns distance in seconds from price
+// to given timestamp on given time axis.
+func axisDistance(price *entity.Price, timeAxis string, timestamp int64) int64 {
+	priceTimestamp := axisTimestamp(price, timeAxis)
+	if priceTimestamp < timestamp {
+		return timestamp - priceTimestamp
 	}
-	return price, nil
+	return priceTimestamp - timestamp
 }
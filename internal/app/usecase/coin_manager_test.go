@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+)
+
+func TestNewLookupPrice_Linear(t *testing.T) {
+	t.Log("Interpolate price timestamps on their time axes")
+
+	sources := entity.PriceList{
+		{Price: decimal.NewFromInt(100), Currency: "usd", Timestamp: 1000, IngestedAt: 1010},
+		{Price: decimal.NewFromInt(200), Currency: "usd", Timestamp: 1100, IngestedAt: 1210},
+	}
+
+	price := newLookupPrice(sources, entity.LookupLinear, entity.TimeAxisIngested, 1060)
+	require.True(t, decimal.NewFromInt(125).Equal(price.Price))
+	require.Equal(t, int64(1060), price.IngestedAt)
+	require.Equal(t, int64(1025), price.Timestamp)
+
+	price = newLookupPrice(sources, entity.LookupLinear, entity.TimeAxisSource, 1025)
+	require.True(t, decimal.NewFromInt(125).Equal(price.Price))
+	require.Equal(t, int64(1025), price.Timestamp)
+	require.Equal(t, int64(1060), price.IngestedAt)
+}
+
+func TestPickCoin(t *testing.T) {
+	t.Log("Pick one of coins sharing the same symbol by canonical ID")
+
+	coinList := entity.CoinList{
+		{ID: "uni-id", Symbol: "uni", ExternalID: "uniswap", Name: "Uniswap"},
+		{ID: "other-uni-id", Symbol: "uni", ExternalID: "other-uni", Name: "Other"},
+	}
+
+	coin, err := pickCoin(coinList, "uni", "other-uni")
+	require.NoError(t, err)
+	require.Equal(t, "other-uni-id", coin.ID)
+
+	_, err = pickCoin(coinList, "uni", "unexisting")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = pickCoin(coinList, "uni", "")
+	var ambiguousErr *AmbiguousCoinError
+	require.ErrorAs(t, err, &ambiguousErr)
+	require.Len(t, ambiguousErr.Candidates, 2)
+	require.Equal(t, "uniswap", ambiguousErr.Candidates[0].ExternalID)
+
+	coin, err = pickCoin(coinList[:1], "uni", "")
+	require.NoError(t, err)
+	require.Equal(t, "uni-id", coin.ID)
+}
@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ PriceGapUsecase = (*PriceGapUC)(nil)
+
+const (
+	// min gap duration in expected intervals between prices
+	_gapIntervalFactor = 3
+	// max amount of detected gaps to repair at once
+	_gapRepairLimit = 100
+)
+
+type PriceGapUC struct {
+	coinRepoDB      repo.CoinRepoDB
+	priceRepoDB     repo.PriceRepoDB
+	gapRepoDB       repo.PriceGapRepoDB
+	backfillUC      BackfillUsecase
+	quoteCurrencies []string
+	// interval between price collections of coin without its own interval
+	defaultInterval time.Duration
+	// min duration of gap
+	minDuration time.Duration
+	// window before the current time to detect gaps in
+	window time.Duration
+}
+
+// NewPriceGapUC returns new price gaps usecase. Gaps are detected in each
+// of given quote currencies and repaired with given backfill usecase.
+func NewPriceGapUC(coinRepoDB repo.CoinRepoDB, priceRepoDB repo.PriceRepoDB,
+	gapRepoDB repo.PriceGapRepoDB, backfillUC BackfillUsecase, quoteCurrencies []string,
+	defaultInterval, minDuration, window time.Duration) *PriceGapUC {
+
+	return &PriceGapUC{
+		coinRepoDB:      coinRepoDB,
+		priceRepoDB:     priceRepoDB,
+		gapRepoDB:       gapRepoDB,
+		backfillUC:      backfillUC,
+		quoteCurrencies: quoteCurrencies,
+		defaultInterval: defaultInterval,
+		minDuration:     minDuration,
+		window:          window,
+	}
+}
+
+// DetectGaps detects gaps in prices of observed coins in each quote
+// currency within detection window before the current time. Gap is
+// detected if consecutive prices are farther from each other than several
+// expected intervals of the coin (but not less than min gap duration).
+// Gaps within already saved ones are skipped. It saves and returns new gaps.
+func (u *PriceGapUC) DetectGaps() (entity.PriceGapList, error) {
+	observedCoins, err := u.coinRepoDB.GetObserved()
+	if err != nil {
+		return nil, fmt.Errorf("get observed coins: %w", err)
+	}
+
+	now := time.Now().UTC().Unix()
+	from := now - int64(u.window.Seconds())
+	gapList := make(entity.PriceGapList, 0)
+	for i := range observedCoins {
+		coin := &observedCoins[i]
+		interval := coin.CollectInterval
+		if interval == 0 {
+			interval = int64(u.defaultInterval.Seconds())
+		}
+		threshold := max(_gapIntervalFactor*interval, int64(u.minDuration.Seconds()))
+
+		for _, currency := range u.quoteCurrencies {
+			coinGaps, err := u.detectCoinGaps(coin, currency, from, now, threshold)
+			if err != nil {
+				return nil, fmt.Errorf("detect %s/%s gaps: %w", coin.Symbol, currency, err)
+			}
+			for j := range coinGaps {
+				coinGaps[j].Coin = coin
+				coinGaps[j].ExpectedInterval = interval
+				coinGaps[j].DetectedAt = now
+			}
+			gapList = append(gapList, coinGaps...)
+		}
+	}
+
+	gapList, err = u.gapRepoDB.CreateMany(gapList)
+	if err != nil {
+		return nil, fmt.Errorf("create many: %w", err)
+	}
+	return gapList, nil
+}
+
+// RepairGaps backfills prices of detected gaps from the newest one in
+// the gap quote currency only and sets their states. It stops if context
+// is done (running backfill is canceled and its gap is kept detected) and
+// returns amount of repaired gaps. If backfill is unavailable gap states
+// are not changed.
+func (u *PriceGapUC) RepairGaps(ctx context.Context) (int, error) {
+	gapList, err := u.gapRepoDB.GetMany("", "", entity.PriceGapStateDetected, _gapRepairLimit)
+	if err != nil {
+		return 0, fmt.Errorf("get detected gaps: %w", err)
+	}
+
+	repaired := 0
+	for i := range gapList {
+		if ctx.Err() != nil {
+			return repaired, nil
+		}
+		gap := &gapList[i]
+		job, err := u.backfillUC.Backfill(ctx, gap.Coin.Symbol, gap.Coin.ExternalID,
+			[]string{gap.Currency}, gap.FromTimestamp, gap.ToTimestamp, nil)
+		if errors.Is(err, ErrUnavailable) {
+			return repaired, fmt.Errorf("backfill: %w", err)
+		}
+		// gap is repaired again after restart
+		if ctx.Err() != nil {
+			return repaired, nil
+		}
+
+		if job != nil {
+			gap.Backfilled = job.Saved
+		}
+		gap.State = entity.PriceGapStateRepaired
+		if err != nil {
+			gap.State, gap.Error = entity.PriceGapStateFailed, err.Error()
+			logrus.Errorf("Repair %s/%s gap from %d to %d: %v", gap.Coin.Symbol,
+				gap.Currency, gap.FromTimestamp, gap.ToTimestamp, err)
+		} else {
+			repaired++
+		}
+		if err := u.gapRepoDB.Update(gap); err != nil {
+			return repaired, fmt.Errorf("update gap: %w", err)
+		}
+	}
+	return repaired, nil
+}
+
+// GetGaps returns gaps from the newest one filtered by coin symbol,
+// quote currency and state if they are not empty. Limit is max amount
+// of returned gaps. If externalID is given it is used to choose one
+// of coins with the same symbol.
+func (u *PriceGapUC) GetGaps(symbol, externalID, currency, state string,
+	limit int) (entity.PriceGapList, error) {
+
+	coinID := ""
+	if symbol != "" {
+		// get coin from DB by symbol
+		coin, err := getCoin(u.coinRepoDB, symbol, externalID)
+		if err != nil {
+			return nil, err
+		}
+		coinID = coin.ID
+	}
+
+	gapList, err := u.gapRepoDB.GetMany(coinID, currency, state, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get many: %w", err)
+	}
+	return gapList, nil
+}
+
+// detectCoinGaps returns gaps between consecutive prices of given coin in
+// given quote currency from given timestamp to given one which are longer
+// than threshold in seconds. Gaps within already saved ones are skipped.
+func (u *PriceGapUC) detectCoinGaps(coin *entity.Coin, currency string,
+	from, to, threshold int64) (entity.PriceGapList, error) {
+
+	timestamps, err := u.priceRepoDB.GetTimestamps(coin.ID, currency, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("get saved timestamps: %w", err)
+	}
+	savedGaps, err := u.gapRepoDB.GetOverlapping(coin.ID, currency, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("get saved gaps: %w", err)
+	}
+
+	slices.Sort(timestamps)
+	gapList := make(entity.PriceGapList, 0)
+	for i := 1; i < len(timestamps); i++ {
+		gapFrom, gapTo := timestamps[i-1], timestamps[i]
+		if gapTo-gapFrom <= threshold {
+			continue
+		}
+		// skip gap which is already saved or is left after backfill of saved one
+		known := slices.ContainsFunc(savedGaps, func(gap entity.PriceGap) bool {
+			return gap.FromTimestamp <= gapFrom && gapTo <= gap.ToTimestamp
+		})
+		if known {
+			continue
+		}
+		gapList = append(gapList, entity.PriceGap{
+			CoinID:        coin.ID,
+			Currency:      currency,
+			FromTimestamp: gapFrom,
+			ToTimestamp:   gapTo,
+			State:         entity.PriceGapStateDetected,
+		})
+	}
+	return gapList, nil
+}
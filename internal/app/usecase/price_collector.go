@@ -3,74 +3,260 @@ package usecase
 import (
 	"CryptocoinPrice/internal/app/entity"
 	"CryptocoinPrice/internal/app/repo"
+	"context"
+	"errors"
 	"fmt"
-	"slices"
+	"sync"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 var _ PriceCollectorUsecase = (*PriceCollectorUC)(nil)
 
 type PriceCollectorUC struct {
-	coinRepoDB   repo.CoinRepoDB
-	priceRepoDB  repo.PriceRepoDB
-	priceRepoAPI repo.PriceRepoAPI
+	coinRepoDB      repo.CoinRepoDB
+	priceRepoDB     repo.PriceRepoDB
+	priceRepoAPI    repo.PriceRepoAPI
+	spoolRepo       repo.PriceSpoolRepo
+	quoteCurrencies []string
+	// amount of consecutive failed collections to mark coin as failing
+	failingThreshold int
+
+	mu sync.Mutex
+	// the last received observed coins (nil if they are never received)
+	observedCoins entity.CoinList
 }
 
 // NewPriceCollectorUC returns new price collector usecase.
-func NewPriceCollectorUC(coinRepoDB repo.CoinRepoDB,
-	priceRepoDB repo.PriceRepoDB, priceRepoAPI repo.PriceRepoAPI) *PriceCollectorUC {
+// Prices are collected in each of given quote currencies.
+// Coin is marked as failing if its prices are failed
+// failingThreshold collections in a row. Prices failed
+// to save are kept in given spool until they are replayed.
+func NewPriceCollectorUC(coinRepoDB repo.CoinRepoDB, priceRepoDB repo.PriceRepoDB,
+	priceRepoAPI repo.PriceRepoAPI, spoolRepo repo.PriceSpoolRepo,
+	quoteCurrencies []string, failingThreshold int) *PriceCollectorUC {
 
 	return &PriceCollectorUC{
-		coinRepoDB:   coinRepoDB,
-		priceRepoDB:  priceRepoDB,
-		priceRepoAPI: priceRepoAPI,
+		coinRepoDB:       coinRepoDB,
+		priceRepoDB:      priceRepoDB,
+		priceRepoAPI:     priceRepoAPI,
+		spoolRepo:        spoolRepo,
+		quoteCurrencies:  quoteCurrencies,
+		failingThreshold: failingThreshold,
 	}
 }
 
-// GetNewObservedCoinPrices gets new prices for observed coins.
-func (u *PriceCollectorUC) GetNewObservedCoinPrices() (entity.PriceList, error) {
-	// get observed coins
+// GetObservedCoins returns observed coins to schedule its prices collection.
+// If DB is unavailable the last received observed coins are returned
+// to keep collecting prices (they are spooled until DB is available).
+func (u *PriceCollectorUC) GetObservedCoins() (entity.CoinList, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	observedCoins, err := u.coinRepoDB.GetObserved()
+	if err != nil && u.observedCoins != nil {
+		logrus.Warnf("Get observed coins: %v. Use the last received ones", err)
+		return append(entity.CoinList{}, u.observedCoins...), nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("get observed coins: %w", err)
 	}
-	// takes only coins' symbols
-	coinSymbols := make([]string, 0, len(observedCoins))
-	for _, coin := range observedCoins {
-		coinSymbols = append(coinSymbols, coin.Symbol)
-	}
+	u.observedCoins = observedCoins
+	return append(entity.CoinList{}, observedCoins...), nil
+}
+
+// GetNewCoinPrices gets new prices for given coins in all quote
+// currencies with one batched request to provider. Failed coin prices
+// are logged and not returned, and coins with failed prices are tracked.
+// Prices whose source timestamp is not advanced are skipped.
+// It returns new prices and coins whose prices are received from
+// provider (even if they are skipped as not advanced) or are rejected
+// by provider as unknown or malformed (retry does not help them).
+// Requests to provider are canceled if context is done, then
+// failures of coins are not tracked.
+func (u *PriceCollectorUC) GetNewCoinPrices(ctx context.Context,
+	coins entity.CoinList) (entity.PriceList, entity.CoinList, error) {
 
+	if len(coins) == 0 {
+		return entity.PriceList{}, entity.CoinList{}, nil
+	}
 	// get coin prices
-	coinPrices, err := u.priceRepoAPI.ManyCoinPrices(coinSymbols)
-	updateTime := time.Now().UTC().Unix()
+	coinPrices, err := u.priceRepoAPI.ManyCoinPrices(ctx, coins, u.quoteCurrencies)
+	var pricesErr *repo.CoinPricesError
+	// if all prices are failed
+	if err != nil && !errors.As(err, &pricesErr) {
+		return nil, nil, fmt.Errorf("get coin prices: %w", err)
+	}
+	ingestTime := time.Now().UTC().Unix()
 
-	var coinIdx int
-	var coinSymbol string
-	var coinIdxFunc = func(coin entity.Coin) bool {
-		return coin.Symbol == coinSymbol
+	// index requested coins by ID (many coins can share the same symbol)
+	coinsByID := make(map[string]*entity.Coin, len(coins))
+	for i := range coins {
+		coinsByID[coins[i].ID] = &coins[i]
 	}
-	// fill price list
+	// fill price list and coins with received prices
 	priceList := make(entity.PriceList, 0, len(coinPrices))
+	received := make(entity.CoinList, 0, len(coins))
+	receivedIDs := make(map[string]struct{}, len(coins))
 	for _, coinPrice := range coinPrices {
 		// get coin struct from observed coins list
-		coinSymbol = coinPrice.Symbol
-		coinIdx = slices.IndexFunc(observedCoins, coinIdxFunc)
+		coin, found := coinsByID[coinPrice.CoinID]
+		if !found {
+			logrus.WithField("coin", coinPrice.Symbol).Warn("Skip price of unrequested coin")
+			continue
+		}
+		if _, found := receivedIDs[coin.ID]; !found {
+			receivedIDs[coin.ID] = struct{}{}
+			received = append(received, *coin)
+		}
 		// append price object
 		priceList = append(priceList, entity.Price{
-			Coin:      &observedCoins[coinIdx],
-			Price:     fmt.Sprint(coinPrice.Price),
-			Timestamp: updateTime,
-			CoinID:    observedCoins[coinIdx].ID,
+			Coin:       coin,
+			Price:      coinPrice.Price,
+			Currency:   coinPrice.Currency,
+			Timestamp:  coinPrice.LastUpdate,
+			IngestedAt: ingestTime,
+			Source:     coinPrice.Source,
+			CoinID:     coin.ID,
+			Quotes:     coinPrice.Quotes,
 		})
 	}
-	return priceList, err
+
+	// provider answered about coins it rejected
+	if pricesErr != nil {
+		for _, coinErr := range pricesErr.Errs {
+			coin, found := coinsByID[coinErr.CoinID]
+			if !found || coinErr.Kind() == repo.CoinPriceErrProvider {
+				continue
+			}
+			if _, found := receivedIDs[coin.ID]; !found {
+				receivedIDs[coin.ID] = struct{}{}
+				received = append(received, *coin)
+			}
+		}
+	}
+	// coins are not failed by themselves if collection is canceled
+	if ctx.Err() == nil {
+		u.trackFailures(coins, pricesErr)
+	}
+	return u.skipStalePrices(coins, priceList), received, nil
 }
 
-// SaveCoinPrices saves coin prices.
-func (u *PriceCollectorUC) SaveCoinPrices(priceList entity.PriceList) (entity.PriceList, error) {
-	priceList, err := u.priceRepoDB.CreateMany(priceList)
+// skipStalePrices returns prices whose source timestamp is advanced since
+// the latest saved price of the coin in the same quote currency from the
+// same source (prices of other sources, e.g. stream, do not make it stale).
+// If latest timestamps are not received all prices are returned.
+func (u *PriceCollectorUC) skipStalePrices(coins entity.CoinList,
+	priceList entity.PriceList) entity.PriceList {
+
+	coinIDs := make([]string, 0, len(coins))
+	for _, coin := range coins {
+		coinIDs = append(coinIDs, coin.ID)
+	}
+	latestPrices, err := u.priceRepoDB.GetLatestTimestamps(coinIDs)
 	if err != nil {
-		return nil, fmt.Errorf("create many: %w", err)
+		logrus.Errorf("Get latest price timestamps: %v", err)
+		return priceList
+	}
+
+	// index latest timestamps by coin ID, currency and source
+	type latestKey struct{ coinID, currency, source string }
+	latest := make(map[latestKey]int64, len(latestPrices))
+	for _, price := range latestPrices {
+		latest[latestKey{price.CoinID, price.Currency, price.Source}] = price.Timestamp
+	}
+
+	freshPrices := make(entity.PriceList, 0, len(priceList))
+	for _, price := range priceList {
+		timestamp, found := latest[latestKey{price.CoinID, price.Currency, price.Source}]
+		if found && price.Timestamp <= timestamp {
+			logrus.WithFields(logrus.Fields{
+				"coin":     price.Coin.Symbol,
+				"currency": price.Currency,
+				"source":   price.Source,
+			}).Debugf("Skip price: source timestamp %d is not advanced", price.Timestamp)
+			continue
+		}
+		freshPrices = append(freshPrices, price)
+	}
+	return freshPrices
+}
+
+// trackFailures logs failed coin prices and counts consecutive failed
+// collections of coins. Coins with at least one failed price are failed.
+// Coins failed failingThreshold collections in a row are marked as failing.
+func (u *PriceCollectorUC) trackFailures(coins entity.CoinList,
+	pricesErr *repo.CoinPricesError) {
+
+	failedCoins := make(map[string]struct{})
+	if pricesErr != nil {
+		for _, coinErr := range pricesErr.Errs {
+			logrus.WithFields(logrus.Fields{
+				"coin":     coinErr.Symbol,
+				"currency": coinErr.Currency,
+				"kind":     coinErr.Kind(),
+			}).Warnf("Get coin price: %v", coinErr.Err)
+			failedCoins[coinErr.CoinID] = struct{}{}
+		}
+	}
+
+	failedIDs := make([]string, 0, len(failedCoins))
+	recoveredIDs := make([]string, 0)
+	for _, coin := range coins {
+		if _, failed := failedCoins[coin.ID]; failed {
+			failedIDs = append(failedIDs, coin.ID)
+		} else if coin.FailCount > 0 {
+			recoveredIDs = append(recoveredIDs, coin.ID)
+		}
+	}
+
+	markedCoins, err := u.coinRepoDB.AddFailure(failedIDs, u.failingThreshold)
+	if err != nil {
+		logrus.Errorf("Add coins failure: %v", err)
+	}
+	for _, coin := range markedCoins {
+		logrus.WithField("coin", coin.Symbol).
+			Errorf("Coin is marked as failing: prices are failed %d collections in a row",
+				coin.FailCount)
+	}
+	if err := u.coinRepoDB.ResetFailures(recoveredIDs); err != nil {
+		logrus.Errorf("Reset coins failures: %v", err)
+	}
+}
+
+// SaveCoinPrices saves coin prices. If spool is not empty (to keep order
+// of batches) or saving is failed because DB is unavailable, prices are
+// appended to spool to be replayed later and are not returned. Error is
+// returned only if prices are not spooled too. Prices rejected by DB are
+// moved aside to spool rejected batches and error is returned. Saving is
+// canceled if context is done (then prices are spooled).
+func (u *PriceCollectorUC) SaveCoinPrices(ctx context.Context,
+	priceList entity.PriceList) (entity.PriceList, error) {
+
+	if _, err := u.spoolRepo.Peek(); !errors.Is(err, repo.ErrNotFound) {
+		return nil, u.spoolCoinPrices(priceList, errors.New("spooled prices are not replayed"))
+	}
+	savedPrices, err := u.priceRepoDB.CreateMany(ctx, priceList)
+	if errors.Is(err, repo.ErrRejectedData) {
+		saveErr := fmt.Errorf("create many: %w", err)
+		if err := rejectPrices(u.spoolRepo, priceList, saveErr); err != nil {
+			return nil, err
+		}
+		return nil, saveErr
+	}
+	if err != nil {
+		return nil, u.spoolCoinPrices(priceList, fmt.Errorf("create many: %w", err))
+	}
+	return savedPrices, nil
+}
+
+// spoolCoinPrices appends prices failed to save with given error
+// to spool. It returns error if prices are not spooled.
+func (u *PriceCollectorUC) spoolCoinPrices(priceList entity.PriceList, saveErr error) error {
+	if err := u.spoolRepo.Append(priceList); err != nil {
+		return fmt.Errorf("%w; spool prices: %w", saveErr, err)
 	}
-	return priceList, nil
+	logrus.Warnf("Save prices: %v. %d prices are spooled", saveErr, len(priceList))
+	return nil
 }
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var errConnRefused = errors.New("connection refused")
+
+// stubPriceRepoDB is a price DB repo stub which saves prices in memory.
+// Saving of prices of coins from errs is failed with the error of coin.
+// Saving is failed with context error if context is done.
+// Latest timestamps are returned from latest prices.
+type stubPriceRepoDB struct {
+	repo.PriceRepoDB
+	errs   map[string]error
+	saved  entity.PriceList
+	latest entity.PriceList
+}
+
+func (r *stubPriceRepoDB) GetLatestTimestamps(_ []string) (entity.PriceList, error) {
+	return r.latest, nil
+}
+
+func (r *stubPriceRepoDB) CreateMany(ctx context.Context,
+	priceList entity.PriceList) (entity.PriceList, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, price := range priceList {
+		if err := r.errs[price.CoinID]; err != nil {
+			return nil, err
+		}
+	}
+	r.saved = append(r.saved, priceList...)
+	return priceList, nil
+}
+
+// stubPriceRepoAPI is a price API repo stub which returns given
+// coin prices with given error.
+type stubPriceRepoAPI struct {
+	repo.PriceRepoAPI
+	coinPrices entity.CoinPriceAPIList
+	err        error
+}
+
+func (r *stubPriceRepoAPI) ManyCoinPrices(_ context.Context, _ entity.CoinList,
+	_ []string) (entity.CoinPriceAPIList, error) {
+
+	return r.coinPrices, r.err
+}
+
+// stubPriceSpoolRepo is an in-memory prices spool stub.
+type stubPriceSpoolRepo struct {
+	batches  []entity.PriceList
+	rejected []entity.PriceList
+}
+
+func (s *stubPriceSpoolRepo) Append(priceList entity.PriceList) error {
+	s.batches = append(s.batches, priceList)
+	return nil
+}
+
+func (s *stubPriceSpoolRepo) Peek() (entity.PriceList, error) {
+	if len(s.batches) == 0 {
+		return nil, repo.ErrNotFound
+	}
+	return s.batches[0], nil
+}
+
+func (s *stubPriceSpoolRepo) Pop() error {
+	s.batches = s.batches[1:]
+	return nil
+}
+
+func (s *stubPriceSpoolRepo) Reject(priceList entity.PriceList, _ error) error {
+	s.rejected = append(s.rejected, priceList)
+	return nil
+}
+
+func newBatch(coinID string) entity.PriceList {
+	return entity.PriceList{{CoinID: coinID, Currency: "USD", Timestamp: 1}}
+}
+
+func newTestPriceCollectorUC(priceRepoDB repo.PriceRepoDB,
+	spoolRepo repo.PriceSpoolRepo) *PriceCollectorUC {
+
+	return NewPriceCollectorUC(nil, priceRepoDB, nil, spoolRepo, []string{"USD"}, 1)
+}
+
+func TestPriceCollectorUC_SaveCoinPrices(t *testing.T) {
+	t.Log("Save coin prices rejected by DB and failed because DB is unavailable")
+
+	priceRepoDB := &stubPriceRepoDB{errs: map[string]error{
+		"bad":  repo.ErrRejectedData,
+		"down": errConnRefused,
+	}}
+	spoolRepo := &stubPriceSpoolRepo{}
+	uc := newTestPriceCollectorUC(priceRepoDB, spoolRepo)
+
+	_, err := uc.SaveCoinPrices(context.Background(), newBatch("bad"))
+	require.ErrorIs(t, err, repo.ErrRejectedData)
+	require.Equal(t, []entity.PriceList{newBatch("bad")}, spoolRepo.rejected)
+	require.Empty(t, spoolRepo.batches)
+
+	savedPrices, err := uc.SaveCoinPrices(context.Background(), newBatch("down"))
+	require.NoError(t, err)
+	require.Empty(t, savedPrices)
+	require.Equal(t, []entity.PriceList{newBatch("down")}, spoolRepo.batches)
+	require.Len(t, spoolRepo.rejected, 1)
+}
+
+func TestPriceCollectorUC_SaveCoinPricesCanceled(t *testing.T) {
+	t.Log("Save coin prices with done context")
+
+	priceRepoDB := &stubPriceRepoDB{}
+	spoolRepo := &stubPriceSpoolRepo{}
+	uc := newTestPriceCollectorUC(priceRepoDB, spoolRepo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	savedPrices, err := uc.SaveCoinPrices(ctx, newBatch("first"))
+	require.NoError(t, err)
+	require.Empty(t, savedPrices)
+	require.Equal(t, []entity.PriceList{newBatch("first")}, spoolRepo.batches)
+	require.Empty(t, priceRepoDB.saved)
+}
+
+func TestPriceCollectorUC_SkipStalePrices(t *testing.T) {
+	t.Log("Skip prices whose source timestamp is not advanced in the same source only")
+
+	coin := entity.Coin{ID: "btc-id", Symbol: "btc"}
+	newPrice := func(source string, timestamp int64) entity.Price {
+		return entity.Price{
+			Coin: &coin, CoinID: coin.ID, Currency: "usd", Source: source, Timestamp: timestamp,
+		}
+	}
+	// stream price is newer than polled one
+	priceRepoDB := &stubPriceRepoDB{latest: entity.PriceList{
+		newPrice("binance", 1754050800),
+		newPrice("coingecko", 1754050700),
+	}}
+	uc := newTestPriceCollectorUC(priceRepoDB, &stubPriceSpoolRepo{})
+
+	freshPrices := uc.skipStalePrices(entity.CoinList{coin}, entity.PriceList{
+		newPrice("coingecko", 1754050760),
+		newPrice("binance", 1754050760),
+		newPrice("cryptocompare", 1754050760),
+	})
+	require.Equal(t, entity.PriceList{
+		newPrice("coingecko", 1754050760),
+		newPrice("cryptocompare", 1754050760),
+	}, freshPrices)
+}
+
+func TestPriceCollectorUC_GetNewCoinPrices(t *testing.T) {
+	t.Log("Return coins whose prices are received or rejected by provider")
+
+	coins := entity.CoinList{
+		{ID: "btc-id", Symbol: "btc"},
+		{ID: "eth-id", Symbol: "eth"},
+		{ID: "ton-id", Symbol: "ton"},
+		{ID: "xrp-id", Symbol: "xrp"},
+	}
+	priceRepoAPI := &stubPriceRepoAPI{
+		coinPrices: entity.CoinPriceAPIList{
+			{CoinID: "btc-id", Symbol: "btc", Currency: "USD", LastUpdate: 1754050760},
+		},
+		err: repo.NewCoinPricesError([]*repo.CoinPriceError{
+			{CoinID: "eth-id", Symbol: "eth", Currency: "USD", Err: repo.ErrValidateData},
+			{CoinID: "ton-id", Symbol: "ton", Currency: "USD", Err: repo.ErrMalformedData},
+			{CoinID: "xrp-id", Symbol: "xrp", Currency: "USD", Err: errConnRefused},
+		}),
+	}
+	uc := NewPriceCollectorUC(&stubCoinRepoDB{}, &stubPriceRepoDB{}, priceRepoAPI,
+		&stubPriceSpoolRepo{}, []string{"USD"}, 1)
+
+	newPrices, received, err := uc.GetNewCoinPrices(context.Background(), coins)
+	require.NoError(t, err)
+	require.Len(t, newPrices, 1)
+	require.Equal(t, coins[:3], received)
+}
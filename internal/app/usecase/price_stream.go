@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ PriceStreamUsecase = (*PriceStreamUC)(nil)
+
+// tickKey is a coin symbol, quote currency and source to identify streamed prices.
+type tickKey struct {
+	symbol   string
+	currency string
+	source   string
+}
+
+type PriceStreamUC struct {
+	coinRepoDB  repo.CoinRepoDB
+	priceRepoDB repo.PriceRepoDB
+
+	mu sync.Mutex
+	// observed coins by symbol
+	coins map[string]*entity.Coin
+	// the latest tick of each coin in each currency since the last flush
+	pending map[tickKey]entity.CoinPriceAPI
+	// source timestamp of the latest saved price of each coin
+	// in each currency from each source
+	saved map[tickKey]int64
+}
+
+// NewPriceStreamUC returns new price stream usecase.
+func NewPriceStreamUC(coinRepoDB repo.CoinRepoDB, priceRepoDB repo.PriceRepoDB) *PriceStreamUC {
+	return &PriceStreamUC{
+		coinRepoDB:  coinRepoDB,
+		priceRepoDB: priceRepoDB,
+		coins:       make(map[string]*entity.Coin),
+		pending:     make(map[tickKey]entity.CoinPriceAPI),
+		saved:       make(map[tickKey]int64),
+	}
+}
+
+// GetObservedCoins returns observed coins to stream its prices.
+// Ticks of coins which are not observed any more are not saved.
+// Ticks are bound to coins by symbols so coins sharing the same
+// symbol are not streamed.
+func (u *PriceStreamUC) GetObservedCoins() (entity.CoinList, error) {
+	observedCoins, err := u.coinRepoDB.GetObserved()
+	if err != nil {
+		return nil, fmt.Errorf("get observed coins: %w", err)
+	}
+	sharedSymbols := repo.SharedSymbols(observedCoins)
+	streamedCoins := make(entity.CoinList, 0, len(observedCoins))
+	for _, coin := range observedCoins {
+		if _, shared := sharedSymbols[coin.Symbol]; !shared {
+			streamedCoins = append(streamedCoins, coin)
+		}
+	}
+	coinIDs := make([]string, 0, len(streamedCoins))
+	coins := make(map[string]*entity.Coin, len(streamedCoins))
+	for i := range streamedCoins {
+		coinIDs = append(coinIDs, streamedCoins[i].ID)
+		coins[streamedCoins[i].Symbol] = &streamedCoins[i]
+	}
+	// latest saved prices are used to skip ticks which are not advanced
+	latestPrices, err := u.priceRepoDB.GetLatestTimestamps(coinIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get latest price timestamps: %w", err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.coins = coins
+	for _, coin := range coins {
+		for _, price := range latestPrices {
+			key := tickKey{symbol: coin.Symbol, currency: price.Currency, source: price.Source}
+			if price.CoinID == coin.ID && price.Timestamp > u.saved[key] {
+				u.saved[key] = price.Timestamp
+			}
+		}
+	}
+	return streamedCoins, nil
+}
+
+// AddTick buffers streamed coin price. Only the latest tick of each
+// coin in each currency is kept until flush to throttle writes.
+func (u *PriceStreamUC) AddTick(tick *entity.CoinPriceAPI) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.pending[tickKey{symbol: tick.Symbol, currency: tick.Currency, source: tick.Source}] = *tick
+}
+
+// FlushTicks saves buffered ticks and returns saved prices. Ticks whose
+// source timestamp is not advanced since the latest saved price from the
+// same source are skipped (prices of other sources do not make them stale).
+// Saving is canceled if context is done.
+func (u *PriceStreamUC) FlushTicks(ctx context.Context) (entity.PriceList, error) {
+	u.mu.Lock()
+	pending := u.pending
+	u.pending = make(map[tickKey]entity.CoinPriceAPI, len(pending))
+
+	ingestTime := time.Now().UTC().Unix()
+	priceList := make(entity.PriceList, 0, len(pending))
+	for key, tick := range pending {
+		coin, found := u.coins[tick.Symbol]
+		if !found || tick.LastUpdate <= u.saved[key] {
+			continue
+		}
+		priceList = append(priceList, entity.Price{
+			Coin:       coin,
+			CoinID:     coin.ID,
+			Price:      tick.Price,
+			Currency:   tick.Currency,
+			Timestamp:  tick.LastUpdate,
+			IngestedAt: ingestTime,
+			Source:     tick.Source,
+		})
+	}
+	u.mu.Unlock()
+
+	if len(priceList) == 0 {
+		return priceList, nil
+	}
+	priceList, err := u.priceRepoDB.CreateMany(ctx, priceList)
+	if err != nil {
+		return nil, fmt.Errorf("create many: %w", err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, price := range priceList {
+		key := tickKey{symbol: price.Coin.Symbol, currency: price.Currency, source: price.Source}
+		u.saved[key] = max(u.saved[key], price.Timestamp)
+	}
+	logrus.Debugf("Save %d streamed prices", len(priceList))
+	return priceList, nil
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+// stubCoinRepoDB is a coin DB repo stub which returns given observed coins
+// and ignores coin failures.
+type stubCoinRepoDB struct {
+	repo.CoinRepoDB
+	observed entity.CoinList
+}
+
+func (r *stubCoinRepoDB) GetObserved() (entity.CoinList, error) {
+	return append(entity.CoinList{}, r.observed...), nil
+}
+
+func (r *stubCoinRepoDB) AddFailure(_ []string, _ int) (entity.CoinList, error) {
+	return entity.CoinList{}, nil
+}
+
+func (r *stubCoinRepoDB) ResetFailures(_ []string) error {
+	return nil
+}
+
+func (r *stubCoinRepoDB) GetBySymbol(symbol string) (entity.CoinList, error) {
+	coinList := entity.CoinList{}
+	for _, coin := range r.observed {
+		if coin.Symbol == symbol {
+			coinList = append(coinList, coin)
+		}
+	}
+	if len(coinList) == 0 {
+		return nil, repo.ErrNotFound
+	}
+	return coinList, nil
+}
+
+func TestPriceStreamUC_FlushTicks(t *testing.T) {
+	t.Log("Skip ticks whose source timestamp is not advanced in the same source only")
+
+	coin := entity.Coin{ID: "btc-id", Symbol: "btc"}
+	// polled price is newer than streamed one
+	priceRepoDB := &stubPriceRepoDB{latest: entity.PriceList{
+		{CoinID: coin.ID, Currency: "usd", Source: "coingecko", Timestamp: 1754050800},
+		{CoinID: coin.ID, Currency: "usd", Source: "binance", Timestamp: 1754050700},
+	}}
+	uc := NewPriceStreamUC(&stubCoinRepoDB{observed: entity.CoinList{coin}}, priceRepoDB)
+	_, err := uc.GetObservedCoins()
+	require.NoError(t, err)
+
+	newTick := func(timestamp int64) *entity.CoinPriceAPI {
+		return &entity.CoinPriceAPI{
+			Symbol: coin.Symbol, Currency: "usd", Source: "binance", LastUpdate: timestamp,
+		}
+	}
+	uc.AddTick(newTick(1754050700))
+	savedPrices, err := uc.FlushTicks(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, savedPrices)
+
+	uc.AddTick(newTick(1754050760))
+	savedPrices, err = uc.FlushTicks(context.Background())
+	require.NoError(t, err)
+	require.Len(t, savedPrices, 1)
+	require.Equal(t, "binance", savedPrices[0].Source)
+	require.Equal(t, int64(1754050760), savedPrices[0].Timestamp)
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+var _ SpoolReplayUsecase = (*SpoolReplayUC)(nil)
+
+type SpoolReplayUC struct {
+	priceRepoDB repo.PriceRepoDB
+	spoolRepo   repo.PriceSpoolRepo
+}
+
+// NewSpoolReplayUC returns new usecase to replay prices
+// from given spool which are failed to save before.
+func NewSpoolReplayUC(priceRepoDB repo.PriceRepoDB, spoolRepo repo.PriceSpoolRepo) *SpoolReplayUC {
+	return &SpoolReplayUC{
+		priceRepoDB: priceRepoDB,
+		spoolRepo:   spoolRepo,
+	}
+}
+
+// ReplaySpooledPrices saves spooled prices batch by batch in order of
+// spooling. Batches rejected by DB are moved aside to rejected batches
+// to not block the next ones. It stops at the first batch failed because
+// DB is unavailable which is kept in spool. It returns amount of saved prices.
+// Replay is stopped if context is done.
+func (u *SpoolReplayUC) ReplaySpooledPrices(ctx context.Context) (int, error) {
+	saved := 0
+	for {
+		priceList, err := u.spoolRepo.Peek()
+		// if spool is empty
+		if errors.Is(err, repo.ErrNotFound) {
+			return saved, nil
+		}
+		if err != nil {
+			return saved, fmt.Errorf("peek spooled prices: %w", err)
+		}
+		savedPrices, err := u.priceRepoDB.CreateMany(ctx, priceList)
+		if errors.Is(err, repo.ErrRejectedData) {
+			saveErr := fmt.Errorf("save spooled prices: %w", err)
+			if err := rejectPrices(u.spoolRepo, priceList, saveErr); err != nil {
+				return saved, err
+			}
+		} else if err != nil {
+			return saved, fmt.Errorf("save spooled prices: %w", err)
+		}
+		if err := u.spoolRepo.Pop(); err != nil {
+			return saved, fmt.Errorf("pop spooled prices: %w", err)
+		}
+		saved += len(savedPrices)
+	}
+}
+
+// rejectPrices moves prices rejected by DB with given error aside
+// to rejected batches of given spool. It returns error if prices are not moved.
+func rejectPrices(spoolRepo repo.PriceSpoolRepo, priceList entity.PriceList,
+	saveErr error) error {
+
+	if err := spoolRepo.Reject(priceList, saveErr); err != nil {
+		return fmt.Errorf("%w; reject prices: %w", saveErr, err)
+	}
+	logrus.Errorf("Save prices: %v. %d prices are moved to rejected", saveErr, len(priceList))
+	return nil
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+func TestSpoolReplayUC_ReplaySpooledPricesRejected(t *testing.T) {
+	t.Log("Replay spooled prices with batch rejected by DB")
+
+	priceRepoDB := &stubPriceRepoDB{errs: map[string]error{
+		"bad": repo.ErrRejectedData,
+	}}
+	spoolRepo := &stubPriceSpoolRepo{}
+	spoolRepo.batches = []entity.PriceList{newBatch("bad"), newBatch("good")}
+	uc := NewSpoolReplayUC(priceRepoDB, spoolRepo)
+
+	saved, err := uc.ReplaySpooledPrices(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, saved)
+	require.Empty(t, spoolRepo.batches)
+	require.Equal(t, []entity.PriceList{newBatch("bad")}, spoolRepo.rejected)
+	require.Equal(t, newBatch("good"), priceRepoDB.saved)
+}
+
+func TestSpoolReplayUC_ReplaySpooledPricesUnavailable(t *testing.T) {
+	t.Log("Replay spooled prices while DB is unavailable")
+
+	priceRepoDB := &stubPriceRepoDB{errs: map[string]error{
+		"first": errConnRefused,
+	}}
+	spoolRepo := &stubPriceSpoolRepo{}
+	spoolRepo.batches = []entity.PriceList{newBatch("first"), newBatch("second")}
+	uc := NewSpoolReplayUC(priceRepoDB, spoolRepo)
+
+	saved, err := uc.ReplaySpooledPrices(context.Background())
+	require.ErrorIs(t, err, errConnRefused)
+	require.Zero(t, saved)
+	require.Len(t, spoolRepo.batches, 2)
+	require.Empty(t, spoolRepo.rejected)
+	require.Empty(t, priceRepoDB.saved)
+}
+
+func TestSpoolReplayUC_ReplaySpooledPricesCanceled(t *testing.T) {
+	t.Log("Replay spooled prices with done context")
+
+	priceRepoDB := &stubPriceRepoDB{}
+	spoolRepo := &stubPriceSpoolRepo{}
+	spoolRepo.batches = []entity.PriceList{newBatch("first")}
+	uc := NewSpoolReplayUC(priceRepoDB, spoolRepo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	saved, err := uc.ReplaySpooledPrices(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, saved)
+	require.Len(t, spoolRepo.batches, 1)
+	require.Empty(t, priceRepoDB.saved)
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"CryptocoinPrice/internal/app/entity"
+	"CryptocoinPrice/internal/app/repo"
+)
+
+var _ StatusUsecase = (*StatusUC)(nil)
+
+type StatusUC struct {
+	providerStatusAPI repo.ProviderStatusAPI
+	leaderStatusAPI   repo.LeaderStatusAPI
+	spoolStatusAPI    repo.SpoolStatusAPI
+}
+
+// NewStatusUC returns new service status usecase.
+// The leaderStatusAPI and spoolStatusAPI can be nil
+// if the replica does not collect prices.
+func NewStatusUC(providerStatusAPI repo.ProviderStatusAPI,
+	leaderStatusAPI repo.LeaderStatusAPI, spoolStatusAPI repo.SpoolStatusAPI) *StatusUC {
+
+	return &StatusUC{
+		providerStatusAPI: providerStatusAPI,
+		leaderStatusAPI:   leaderStatusAPI,
+		spoolStatusAPI:    spoolStatusAPI,
+	}
+}
+
+// GetStatus returns service status with price providers status,
+// leader election status and prices spool status of the replica.
+func (u *StatusUC) GetStatus() *entity.Status {
+	status := &entity.Status{
+		Providers: u.providerStatusAPI.ProvidersStatus(),
+	}
+	if u.leaderStatusAPI != nil {
+		leaderStatus := u.leaderStatusAPI.LeaderStatus()
+		status.Leader = &leaderStatus
+	}
+	if u.spoolStatusAPI != nil {
+		spoolStatus := u.spoolStatusAPI.SpoolStatus()
+		status.Spool = &spoolStatus
+	}
+	return status
+}
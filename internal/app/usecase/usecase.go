@@ -2,7 +2,10 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"CryptocoinPrice/internal/app/entity"
 )
@@ -10,25 +13,191 @@ import (
 var (
 	ErrNotFound     = errors.New("not found")     // not found error
 	ErrValidateData = errors.New("validate data") // validat data error
+	ErrAmbiguous    = errors.New("ambiguous")     // ambiguous data error
+	ErrUnavailable  = errors.New("unavailable")   // external service unavailable error
 )
 
+// AmbiguousCoinError is returned if many coins have the same symbol
+// and the caller has to choose one of the candidates.
+type AmbiguousCoinError struct {
+	// requested coin symbol
+	Symbol string
+	// coins with requested symbol
+	Candidates entity.CoinCandidateList
+}
+
+// Error implements error interface.
+func (e *AmbiguousCoinError) Error() string {
+	return fmt.Sprintf("%v: %d coins have symbol %s: coin id must be specified",
+		ErrAmbiguous, len(e.Candidates), e.Symbol)
+}
+
+// Unwrap returns ambiguous data error.
+func (e *AmbiguousCoinError) Unwrap() error {
+	return ErrAmbiguous
+}
+
+// UnavailableError is returned if price providers are temporarily unavailable.
+type UnavailableError struct {
+	// time until providers may become available
+	RetryAfter time.Duration
+	// cause error
+	Err error
+}
+
+// Error implements error interface.
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%v: price providers: %v", ErrUnavailable, e.Err)
+}
+
+// Unwrap returns external service unavailable error.
+func (e *UnavailableError) Unwrap() error {
+	return ErrUnavailable
+}
+
 // CoinManageUsecase used to manage observed coins and its prices.
 type CoinManageUsecase interface {
 	// ObserveCoin creates new observed coin or sets observed on true for existing coin.
-	// Before creating new coin it gets price
+	// Before creating new coin it resolves the coin symbol into canonical coin ID
+	// (externalID is used to choose one of coins with the same symbol) and gets price
 	// for coin to check that coin exists in the world.
-	ObserveCoin(symbol string) (*entity.Coin, error)
+	// Request to price API is canceled if context is done.
+	//
+	// In all methods many coins can have the same symbol, then externalID
+	// is used to choose one of them (ambiguous coin error is returned if
+	// it is empty).
+	ObserveCoin(ctx context.Context, symbol, externalID string) (*entity.Coin, error)
 	// DisableObserveCoin sets observed on false for coin.
-	DisableObserveCoin(symbol string) (*entity.Coin, error)
-	// GetNearestPrice returns first price with coin symbol
-	// and nearest timestamp for given timestamp.
-	GetNearestPrice(symbol string, timestamp int64) (*entity.Price, error)
+	DisableObserveCoin(symbol, externalID string) (*entity.Coin, error)
+	// SetCollectInterval sets interval between coin price collections
+	// in seconds. Zero interval resets it to the default one.
+	SetCollectInterval(symbol, externalID string, interval int64) (*entity.Coin, error)
+	// GetPrice returns price of coin with symbol in quote currency at given
+	// timestamp on given time axis found in given lookup mode: the nearest
+	// price, the last price before timestamp, the first price after it or
+	// price linearly interpolated between them.
+	// If currency is empty the default quote currency is used.
+	// If time axis is empty the source timestamp is used.
+	// If lookup mode is empty the nearest price is found.
+	// If max distance is positive prices farther from timestamp
+	// (in seconds) are not found.
+	GetPrice(symbol, externalID, currency string, timestamp int64,
+		timeAxis, mode string, maxDistance int64) (*entity.LookupPrice, error)
+	// GetPriceHistory returns page of prices of coin with symbol in quote
+	// currency with source timestamp from given timestamp to given one
+	// inclusive ordered by source timestamp in given order. The page starts
+	// after given cursor (from the first price if cursor is nil) and contains
+	// at most limit prices. Cursor of the next page is returned (nil if the
+	// page is the last one).
+	// If currency is empty the default quote currency is used.
+	// If order is empty prices are ordered from the oldest one.
+	GetPriceHistory(symbol, externalID, currency string, from, to int64, order string,
+		cursor *entity.PriceCursor, limit int) (entity.PriceList, *entity.PriceCursor, error)
 }
 
 // PriceCollectorUsecase used to get new coin prices.
 type PriceCollectorUsecase interface {
-	// GetNewObservedCoinPrices gets new prices for observed coins.
-	GetNewObservedCoinPrices() (entity.PriceList, error)
-	// SaveCoinPrices saves coin prices.
-	SaveCoinPrices(priceList entity.PriceList) (entity.PriceList, error)
+	// GetObservedCoins returns observed coins to schedule its prices collection.
+	// If DB is unavailable the last received observed coins are returned
+	// to keep collecting prices (they are spooled until DB is available).
+	GetObservedCoins() (entity.CoinList, error)
+	// GetNewCoinPrices gets new prices for given coins in all quote
+	// currencies with one batched request to provider. Failed coin prices
+	// are logged and not returned, and coins with failed prices are tracked.
+	// Prices whose source timestamp is not advanced are skipped.
+	// It returns new prices and coins whose prices are received from
+	// provider (even if they are skipped as not advanced) or are rejected
+	// by provider as unknown or malformed (retry does not help them).
+	// Requests to provider are canceled if context is done, then
+	// failures of coins are not tracked.
+	GetNewCoinPrices(ctx context.Context,
+		coins entity.CoinList) (newPrices entity.PriceList, received entity.CoinList, err error)
+	// SaveCoinPrices saves coin prices. If spool is not empty (to keep order
+	// of batches) or saving is failed because DB is unavailable, prices are
+	// appended to spool to be replayed later and are not returned. Error is
+	// returned only if prices are not spooled too. Prices rejected by DB are
+	// moved aside to spool rejected batches and error is returned. Saving is
+	// canceled if context is done (then prices are spooled).
+	SaveCoinPrices(ctx context.Context, priceList entity.PriceList) (entity.PriceList, error)
+}
+
+// SpoolReplayUsecase used to replay spooled coin prices which are failed to save.
+type SpoolReplayUsecase interface {
+	// ReplaySpooledPrices saves spooled prices batch by batch in order of
+	// spooling. Batches rejected by DB are moved aside to rejected batches
+	// to not block the next ones. It stops at the first batch failed because
+	// DB is unavailable which is kept in spool. It returns amount of saved prices.
+	// Replay is stopped if context is done.
+	ReplaySpooledPrices(ctx context.Context) (int, error)
+}
+
+// PriceStreamUsecase used to save coin prices streamed by provider.
+type PriceStreamUsecase interface {
+	// GetObservedCoins returns observed coins to stream its prices.
+	// Ticks of coins which are not observed any more are not saved.
+	GetObservedCoins() (entity.CoinList, error)
+	// AddTick buffers streamed coin price. Only the latest tick of each
+	// coin in each currency is kept until flush to throttle writes.
+	AddTick(tick *entity.CoinPriceAPI)
+	// FlushTicks saves buffered ticks and returns saved prices. Ticks whose
+	// source timestamp is not advanced since the latest saved price from the
+	// same source are skipped (prices of other sources do not make them stale).
+	// Saving is canceled if context is done.
+	FlushTicks(ctx context.Context) (entity.PriceList, error)
+}
+
+// BackfillUsecase used to backfill historical coin prices.
+type BackfillUsecase interface {
+	// StartBackfill queues backfill of coin prices from given timestamp to given
+	// one and returns its job. Queued jobs are run in background by the replica
+	// collecting prices. If backfill of the coin is already queued or running its
+	// job is returned. If "to" is zero or in future the current time is used.
+	// If externalID is given it is used to choose one of coins with the same symbol.
+	StartBackfill(symbol, externalID string, from, to int64) (*entity.BackfillJob, error)
+	// Backfill backfills coin prices in given quote currencies (all quote currencies
+	// if empty) from given timestamp to given one and returns finished job.
+	// The onProgress func (optional) is called after each request to provider.
+	// If "to" is zero or in future the current time is used.
+	// If externalID is given it is used to choose one of coins with the same symbol.
+	// Requests to provider are canceled if context is done.
+	Backfill(ctx context.Context, symbol, externalID string, currencies []string,
+		from, to int64, onProgress func(job entity.BackfillJob)) (*entity.BackfillJob, error)
+	// GetBackfillJob returns queued backfill job.
+	GetBackfillJob(jobID string) (*entity.BackfillJob, error)
+	// RunQueuedJob runs the oldest queued backfill job and returns it finished.
+	// Job progress is saved after each request to provider. If context is done
+	// job is queued again to be run from the beginning (e.g. by the next leader).
+	// If there are no queued jobs not found error is returned.
+	RunQueuedJob(ctx context.Context) (*entity.BackfillJob, error)
+	// RequeueJobs queues again running backfill jobs to run them from the
+	// beginning and returns amount of requeued jobs.
+	RequeueJobs() (int, error)
+	// PruneJobs removes jobs finished before job TTL and returns amount of them.
+	PruneJobs() (int, error)
+}
+
+// PriceGapUsecase used to detect and repair gaps in collected coin prices.
+type PriceGapUsecase interface {
+	// DetectGaps detects gaps in prices of observed coins in each quote
+	// currency within detection window before the current time. Gap is
+	// detected if consecutive prices are farther from each other than several
+	// expected intervals of the coin (but not less than min gap duration).
+	// Gaps within already saved ones are skipped. It saves and returns new gaps.
+	DetectGaps() (entity.PriceGapList, error)
+	// RepairGaps backfills prices of detected gaps from the newest one and
+	// sets their states. It stops if context is done and returns amount of
+	// repaired gaps. If backfill is unavailable gap states are not changed.
+	RepairGaps(ctx context.Context) (int, error)
+	// GetGaps returns gaps from the newest one filtered by coin symbol,
+	// quote currency and state if they are not empty. Limit is max amount
+	// of returned gaps. If externalID is given it is used to choose one
+	// of coins with the same symbol.
+	GetGaps(symbol, externalID, currency, state string, limit int) (entity.PriceGapList, error)
+}
+
+// StatusUsecase used to get service status.
+type StatusUsecase interface {
+	// GetStatus returns service status with price providers status,
+	// leader election status and prices spool status of the replica.
+	GetStatus() *entity.Status
 }
@@ -0,0 +1,2 @@
+ALTER TABLE prices
+DROP COLUMN IF EXISTS source;
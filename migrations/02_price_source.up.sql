@@ -0,0 +1,5 @@
+ALTER TABLE prices
+ADD COLUMN source VARCHAR(50) NOT NULL DEFAULT 'coingecko';
+
+ALTER TABLE prices
+ALTER COLUMN source DROP DEFAULT;
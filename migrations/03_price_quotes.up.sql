@@ -0,0 +1,11 @@
+CREATE TABLE price_quotes (
+    id UUID PRIMARY KEY,
+    price_id UUID NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    price VARCHAR(50) NOT NULL,
+    timestamp INT NOT NULL,
+    rejected BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+ALTER TABLE price_quotes
+ADD CONSTRAINT fk_price_quote_price FOREIGN KEY (price_id) REFERENCES prices (id) ON UPDATE CASCADE ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+DELETE FROM prices WHERE currency <> 'usd';
+
+ALTER TABLE prices
+DROP COLUMN IF EXISTS currency;
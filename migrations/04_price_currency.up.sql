@@ -0,0 +1,5 @@
+ALTER TABLE prices
+ADD COLUMN currency VARCHAR(10) NOT NULL DEFAULT 'usd';
+
+ALTER TABLE prices
+ALTER COLUMN currency DROP DEFAULT;
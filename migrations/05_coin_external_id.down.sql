@@ -0,0 +1,3 @@
+ALTER TABLE coins
+DROP COLUMN IF EXISTS external_id,
+DROP COLUMN IF EXISTS name;
@@ -0,0 +1,3 @@
+ALTER TABLE coins
+ADD COLUMN external_id VARCHAR(100) NOT NULL DEFAULT '',
+ADD COLUMN name VARCHAR(100) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE coins
+DROP COLUMN IF EXISTS fail_count,
+DROP COLUMN IF EXISTS failing;
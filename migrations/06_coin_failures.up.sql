@@ -0,0 +1,3 @@
+ALTER TABLE coins
+ADD COLUMN fail_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN failing BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,8 @@
+ALTER TABLE prices
+ADD COLUMN ingested_at BIGINT;
+
+-- before this migration the price timestamp was the ingestion time
+UPDATE prices SET ingested_at = timestamp;
+
+ALTER TABLE prices
+ALTER COLUMN ingested_at SET NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE coins
+DROP COLUMN IF EXISTS collect_interval;
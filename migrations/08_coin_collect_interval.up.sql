@@ -0,0 +1,2 @@
+ALTER TABLE coins
+ADD COLUMN collect_interval BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,17 @@
+CREATE TABLE price_gaps (
+    id UUID PRIMARY KEY,
+    coin_id UUID NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    from_timestamp BIGINT NOT NULL,
+    to_timestamp BIGINT NOT NULL,
+    expected_interval BIGINT NOT NULL,
+    state VARCHAR(10) NOT NULL,
+    backfilled INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    detected_at BIGINT NOT NULL
+);
+
+ALTER TABLE price_gaps
+ADD CONSTRAINT fk_price_gap_coin FOREIGN KEY (coin_id) REFERENCES coins (id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX idx_price_gaps_coin_currency_from ON price_gaps (coin_id, currency, from_timestamp);
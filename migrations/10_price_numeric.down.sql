@@ -0,0 +1,5 @@
+ALTER TABLE price_quotes
+ALTER COLUMN price TYPE VARCHAR(50) USING price::TEXT;
+
+ALTER TABLE prices
+ALTER COLUMN price TYPE VARCHAR(50) USING price::TEXT;
@@ -0,0 +1,6 @@
+-- prices were stored as strings formatted from floats (maybe in exponent notation)
+ALTER TABLE prices
+ALTER COLUMN price TYPE NUMERIC USING price::NUMERIC;
+
+ALTER TABLE price_quotes
+ALTER COLUMN price TYPE NUMERIC USING price::NUMERIC;
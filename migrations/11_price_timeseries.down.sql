@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_prices_coin_currency_timestamp_source;
+
+ALTER TABLE price_quotes
+ALTER COLUMN timestamp TYPE INT;
+
+ALTER TABLE prices
+ALTER COLUMN timestamp TYPE INT;
@@ -0,0 +1,15 @@
+-- INT overflows in 2038
+ALTER TABLE prices
+ALTER COLUMN timestamp TYPE BIGINT;
+
+ALTER TABLE price_quotes
+ALTER COLUMN timestamp TYPE BIGINT;
+
+-- keep the earliest ingested price of duplicated ones
+DELETE FROM prices p USING prices d
+WHERE p.coin_id = d.coin_id AND p.currency = d.currency
+    AND p.timestamp = d.timestamp AND p.source = d.source
+    AND (p.ingested_at, p.id) > (d.ingested_at, d.id);
+
+CREATE UNIQUE INDEX idx_prices_coin_currency_timestamp_source
+ON prices (coin_id, currency, timestamp, source);
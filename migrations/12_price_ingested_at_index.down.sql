@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_prices_coin_currency_ingested_at;
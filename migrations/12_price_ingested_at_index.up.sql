@@ -0,0 +1,3 @@
+-- prices are searched by source timestamp with unique index on coin, currency and timestamp
+CREATE INDEX idx_prices_coin_currency_ingested_at
+ON prices (coin_id, currency, ingested_at);
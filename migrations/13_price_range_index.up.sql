@@ -0,0 +1,3 @@
+-- prices are read page by page in order of timestamp and id
+CREATE INDEX idx_prices_coin_currency_timestamp_id
+ON prices (coin_id, currency, timestamp, id);
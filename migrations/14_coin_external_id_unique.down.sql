@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_coins_external_id;
+
+DROP INDEX IF EXISTS idx_coins_symbol;
+
+ALTER TABLE coins
+ADD CONSTRAINT coins_symbol_key UNIQUE (symbol);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_coins_symbol;
+DROP INDEX IF EXISTS idx_coins_external_id;
+-- many coins can share the same symbol and are told apart by canonical ID
+ALTER TABLE coins
+DROP CONSTRAINT IF EXISTS coins_symbol_key;
+
+CREATE INDEX idx_coins_symbol
+ON coins (symbol);
+-- coins without canonical ID (empty one) are not unique
+CREATE UNIQUE INDEX idx_coins_external_id
+ON coins (external_id)
+WHERE external_id <> '';
@@ -0,0 +1,24 @@
+DROP TABLE IF EXISTS backfill_jobs;
+CREATE TABLE backfill_jobs (
+    id UUID PRIMARY KEY,
+    coin_id UUID NOT NULL,
+    symbol VARCHAR(10) NOT NULL,
+    from_timestamp BIGINT NOT NULL,
+    to_timestamp BIGINT NOT NULL,
+    state VARCHAR(10) NOT NULL,
+    total_steps INT NOT NULL DEFAULT 0,
+    done_steps INT NOT NULL DEFAULT 0,
+    saved INT NOT NULL DEFAULT 0,
+    skipped INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    queued_at BIGINT NOT NULL,
+    started_at BIGINT NOT NULL DEFAULT 0,
+    finished_at BIGINT NOT NULL DEFAULT 0
+);
+
+ALTER TABLE backfill_jobs
+ADD CONSTRAINT fk_backfill_job_coin FOREIGN KEY (coin_id) REFERENCES coins (id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+-- one queued or running job per coin
+CREATE UNIQUE INDEX idx_backfill_jobs_coin_active ON backfill_jobs (coin_id) WHERE state IN ('queued', 'running');
+CREATE INDEX idx_backfill_jobs_state_queued_at ON backfill_jobs (state, queued_at);